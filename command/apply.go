@@ -22,6 +22,8 @@ func init() {
 		DryRunOn:   model.DryRunOnServerIfPossible,
 		StageRange: model.StageRange{},
 		Cleanup:    true,
+
+		WorkspaceFile: model.DefaultWorkspaceSource,
 	}
 	cmd.Parent = cmd
 	RegisterInitializable(cmd)
@@ -38,6 +40,9 @@ type Apply struct {
 	DryRunOn   model.DryRunOn
 	StageRange model.StageRange
 	Cleanup    bool
+
+	Workspace     bool
+	WorkspaceFile string
 }
 
 func (instance *Apply) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
@@ -46,7 +51,7 @@ func (instance *Apply) ConfigureCliCommands(context string, hc common.HasCommand
 	}
 
 	cmd := hc.Command("apply", "Apply the instances of this project using the provided values.").
-		Action(instance.ExecuteFromCli)
+		Action(instance.execute)
 
 	cmd.Flag("wait", "If set to value larger than 0 it will wait for this amount of time for successful"+
 		" running environment which was deployed. If it fails it will try to rollback.").
@@ -92,6 +97,16 @@ func (instance *Apply) ConfigureCliCommands(context string, hc common.HasCommand
 		Envar("KUBOR_CLEANUP").
 		Default(fmt.Sprint(instance.Cleanup)).
 		BoolVar(&instance.Cleanup)
+	cmd.Flag("workspace", "If set it will apply all members of the workspace file in the order of their"+
+		" dependencies instead of only the current project. All members share one wait and rollback budget;"+
+		" if one member fails all already applied members will be rolled back.").
+		Envar("KUBOR_WORKSPACE").
+		BoolVar(&instance.Workspace)
+	cmd.Flag("workspaceFile", "Specifies the location of the workspace file which is used with --workspace.").
+		Envar("KUBOR_WORKSPACE_FILE").
+		Default(instance.WorkspaceFile).
+		PlaceHolder("<workspace file>").
+		StringVar(&instance.WorkspaceFile)
 
 	cmd.Validate(func(clause *kingpin.CmdClause) error {
 		switch instance.Wait.Stage {
@@ -105,55 +120,73 @@ func (instance *Apply) ConfigureCliCommands(context string, hc common.HasCommand
 	return nil
 }
 
+func (instance *Apply) execute(context *kingpin.ParseContext) error {
+	if instance.Workspace {
+		return instance.runWorkspace()
+	}
+	return instance.ExecuteFromCli(context)
+}
+
 func (instance *Apply) isCleanupAllowed() bool {
 	return !instance.Predicate.IsRelevant() && !instance.StageRange.IsRelevant()
 }
 
 func (instance *Apply) RunWithArguments(arguments Arguments) error {
-	ct, err := kubernetes.NewCleanupTask(arguments.Project, arguments.DynamicClient, kubernetes.CleanupModeOrphans)
+	task, err := instance.newTask(arguments)
 	if err != nil {
 		return err
 	}
+
+	if instance.DryRun.IsDryRunAllowed() {
+		if _, err := task.stagedApplySet.Execute("dryRun", instance.DryRunOn, nil, false); err != nil {
+			return err
+		}
+	}
+
+	if instance.DryRun.IsApplyAllowed() {
+		if _, err := task.stagedApplySet.Execute("apply", model.DryRunNowhere, &instance.Wait, true); err != nil {
+			return err
+		}
+	}
+
+	return task.cleanup()
+}
+
+// newTask renders all objects of the given project and checks them against
+// policies, stages and claims without touching the cluster.
+func (instance *Apply) newTask(arguments Arguments) (*applyTask, error) {
+	ct, err := kubernetes.NewCleanupTask(arguments.Project, arguments.DynamicClient, kubernetes.CleanupModeOrphans)
+	if err != nil {
+		return nil, err
+	}
 	task := &applyTask{
 		source:        instance,
 		dynamicClient: arguments.DynamicClient,
 		arguments:     arguments,
 		cleanupTask:   &ct,
+		policies:      newPolicyChecker(arguments.Project),
+		duplicates:    kubernetes.NewDuplicateDetector(arguments.Project),
 	}
 	oh, err := model.NewObjectHandler(task.onObject, arguments.Project)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	cp, err := arguments.Project.RenderedTemplatesProvider()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = oh.Handle(cp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if instance.DryRun.IsDryRunAllowed() {
-		if _, err := task.stagedApplySet.Execute("dryRun", instance.DryRunOn, nil, false); err != nil {
-			return err
-		}
+	if err := task.policies.failOnErrors(); err != nil {
+		return nil, err
 	}
 
-	if instance.DryRun.IsApplyAllowed() {
-		if _, err := task.stagedApplySet.Execute("apply", model.DryRunNowhere, &instance.Wait, true); err != nil {
-			return err
-		}
-	}
-
-	if instance.Cleanup && instance.isCleanupAllowed() {
-		if err := ct.Execute(); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return task, nil
 }
 
 type applyTask struct {
@@ -161,9 +194,18 @@ type applyTask struct {
 	dynamicClient  dynamic.Interface
 	stagedApplySet kubernetes.StagedApplySet
 	cleanupTask    *kubernetes.CleanupTask
+	policies       *policyChecker
+	duplicates     *kubernetes.DuplicateDetector
 	arguments      Arguments
 }
 
+func (instance *applyTask) cleanup() error {
+	if instance.source.Cleanup && instance.source.isCleanupAllowed() {
+		return instance.cleanupTask.Execute()
+	}
+	return nil
+}
+
 func (instance *applyTask) onObject(source string, _ runtime.Object, object *unstructured.Unstructured) error {
 	if matches, err := instance.source.Predicate.Matches(object.Object); err != nil {
 		return err
@@ -171,6 +213,21 @@ func (instance *applyTask) onObject(source string, _ runtime.Object, object *uns
 		return nil
 	}
 
+	if err := instance.policies.check(source, object); err != nil {
+		return err
+	}
+
+	reference, err := kubernetes.GetObjectReference(object, instance.arguments.Project.Scheme)
+	if err != nil {
+		return err
+	}
+
+	if merged, err := instance.duplicates.Add(source, reference, object); err != nil {
+		return err
+	} else if merged {
+		return nil
+	}
+
 	apply, err := kubernetes.NewApplyObject(
 		instance.arguments.Project,
 		source,
@@ -183,11 +240,6 @@ func (instance *applyTask) onObject(source string, _ runtime.Object, object *uns
 	}
 	apply.KeepAliveInterval = instance.source.KeepAlive
 
-	reference, err := kubernetes.GetObjectReference(object, instance.arguments.Project.Scheme)
-	if err != nil {
-		return err
-	}
-
 	stage, err := instance.arguments.Project.Annotations.GetStageFor(object)
 	if err != nil {
 		return err
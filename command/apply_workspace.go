@@ -0,0 +1,205 @@
+package command
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/kubernetes"
+	"github.com/echocat/kubor/log"
+	"github.com/echocat/kubor/model"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type workspaceMemberStatus string
+
+const (
+	workspaceMemberStatusPending    = workspaceMemberStatus("pending")
+	workspaceMemberStatusChecked    = workspaceMemberStatus("checked")
+	workspaceMemberStatusApplied    = workspaceMemberStatus("applied")
+	workspaceMemberStatusFailed     = workspaceMemberStatus("failed")
+	workspaceMemberStatusRolledBack = workspaceMemberStatus("rolledBack")
+	workspaceMemberStatusSkipped    = workspaceMemberStatus("skipped")
+)
+
+type workspaceMemberReport struct {
+	member   model.WorkspaceMember
+	project  *model.Project
+	task     *applyTask
+	status   workspaceMemberStatus
+	duration time.Duration
+	err      error
+}
+
+type workspaceReport []*workspaceMemberReport
+
+// runWorkspace applies all members of the workspace in the order of their
+// dependencies. Every member is rendered and checked before anything is
+// applied. If one member fails every already applied member is rolled back.
+func (instance *Apply) runWorkspace() (err error) {
+	if instance.ProjectFactory == nil {
+		return fmt.Errorf("command not yet initialized")
+	}
+	workspace, err := model.LoadWorkspace(instance.WorkspaceFile)
+	if err != nil {
+		return err
+	}
+	members, err := workspace.Ordered()
+	if err != nil {
+		return fmt.Errorf("cannot handle workspace file '%s': %w", workspace.Source, err)
+	}
+
+	runtime, err := instance.newRuntime()
+	if err != nil {
+		return err
+	}
+	dc, err := runtime.NewDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	report := make(workspaceReport, len(members))
+	for i, member := range members {
+		report[i] = &workspaceMemberReport{member: member, status: workspaceMemberStatusPending}
+	}
+	defer func() {
+		if err != nil {
+			report.skipPending()
+		}
+		if rErr := report.render(os.Stdout); rErr != nil && err == nil {
+			err = rErr
+		}
+	}()
+
+	for _, mr := range report {
+		project, pErr := instance.ProjectFactory.ForSource(workspace.SourceOf(mr.member)).Create(runtime.ContextName())
+		if pErr != nil {
+			return mr.fail(pErr)
+		}
+		project.Templating = project.Templating.WithObjectLookup(kubernetes.NewObjectLookup(dc))
+		mr.project = project
+		task, tErr := instance.newTask(Arguments{
+			Project:       project,
+			Runtime:       runtime,
+			DynamicClient: dc,
+		})
+		if tErr != nil {
+			return mr.fail(tErr)
+		}
+		mr.task = task
+	}
+
+	if instance.DryRun.IsDryRunAllowed() {
+		for _, mr := range report {
+			if _, dErr := mr.task.stagedApplySet.Execute("dryRun", instance.DryRunOn, nil, false); dErr != nil {
+				return mr.fail(dErr)
+			}
+			mr.status = workspaceMemberStatusChecked
+		}
+	}
+
+	if !instance.DryRun.IsApplyAllowed() {
+		return nil
+	}
+
+	var consumed time.Duration
+	for i, mr := range report {
+		wu := instance.Wait
+		if to := instance.Wait.Timeout; to != nil {
+			if consumed > *to {
+				report.rollback(i)
+				return mr.fail(common.NewTimeoutError("timeout of %v reached - no more time to continue with left workspace members", *to))
+			}
+			left := *to - consumed
+			wu = instance.Wait.CopyWithTimeout(&left)
+		}
+		start := time.Now()
+		duration, aErr := mr.task.stagedApplySet.Execute("apply", model.DryRunNowhere, &wu, true)
+		mr.duration = time.Now().Sub(start)
+		if aErr != nil {
+			report.rollback(i)
+			return mr.fail(aErr)
+		}
+		consumed += duration
+		mr.status = workspaceMemberStatusApplied
+	}
+
+	for _, mr := range report {
+		if cErr := mr.task.cleanup(); cErr != nil {
+			return fmt.Errorf("workspace member '%s': %w", mr.member.Name, cErr)
+		}
+	}
+
+	return nil
+}
+
+func (instance *workspaceMemberReport) fail(err error) error {
+	instance.status = workspaceMemberStatusFailed
+	instance.err = err
+	return fmt.Errorf("workspace member '%s': %w", instance.member.Name, err)
+}
+
+func (instance *workspaceMemberReport) projectName() string {
+	if instance.project == nil {
+		return "-"
+	}
+	result := instance.project.ArtifactId.String()
+	if instance.project.GroupId != "" {
+		result = instance.project.GroupId.String() + ":" + result
+	}
+	if instance.project.Release != "" {
+		result += ":" + instance.project.Release
+	}
+	return result
+}
+
+// rollback rolls back all members which were applied before the member with
+// the given index - in reverse order.
+func (instance workspaceReport) rollback(failed int) {
+	for i := failed - 1; i >= 0; i-- {
+		mr := instance[i]
+		if mr.status != workspaceMemberStatusApplied {
+			continue
+		}
+		log.WithField("member", mr.member.Name).
+			Info("Rollback workspace member %s because of failure of %s...", mr.member.Name, instance[failed].member.Name)
+		mr.task.stagedApplySet.Rollback("apply")
+		mr.status = workspaceMemberStatusRolledBack
+	}
+}
+
+// skipPending marks all members which were not (completely) handled because
+// of a failure of another member as skipped.
+func (instance workspaceReport) skipPending() {
+	for _, mr := range instance {
+		if mr.status == workspaceMemberStatusPending || mr.status == workspaceMemberStatusChecked {
+			mr.status = workspaceMemberStatusSkipped
+		}
+	}
+}
+
+func (instance workspaceReport) render(to io.Writer) error {
+	w := tabwriter.NewWriter(to, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "MEMBER\tPROJECT\tOBJECTS\tSTATUS\tDURATION\tERROR"); err != nil {
+		return err
+	}
+	for _, mr := range instance {
+		objects := "-"
+		if mr.task != nil {
+			objects = fmt.Sprint(mr.task.stagedApplySet.Size())
+		}
+		duration := "-"
+		if mr.duration > 0 {
+			duration = mr.duration.Truncate(time.Millisecond).String()
+		}
+		message := ""
+		if mr.err != nil {
+			message = fmt.Sprint(log.Redact(mr.err.Error()))
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mr.member.Name, mr.projectName(), objects, mr.status, duration, message); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
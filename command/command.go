@@ -21,6 +21,9 @@ type RunnableConsumingCommandArguments interface {
 type Command struct {
 	ProjectFactory *model.ProjectFactory
 	Parent         RunnableConsumingCommandArguments
+
+	// Offline forces the usage of a mock runtime which never connects to a cluster.
+	Offline bool
 }
 
 func (instance *Command) Init(pf *model.ProjectFactory) error {
@@ -39,8 +42,15 @@ func (instance *Command) ExecuteFromCli(*kingpin.ParseContext) error {
 	return instance.Run()
 }
 
+func (instance *Command) newRuntime() (kubernetes.Runtime, error) {
+	if instance.Offline {
+		return kubernetes.NewMockRuntime()
+	}
+	return kubernetes.NewRuntime()
+}
+
 func (instance *Command) Run() error {
-	runtime, err := kubernetes.NewRuntime()
+	runtime, err := instance.newRuntime()
 	if err != nil {
 		return err
 	}
@@ -52,6 +62,7 @@ func (instance *Command) Run() error {
 	if err != nil {
 		return err
 	}
+	project.Templating = project.Templating.WithObjectLookup(kubernetes.NewObjectLookup(dc))
 	if instance.Parent == nil {
 		panic("no Parent defined")
 	}
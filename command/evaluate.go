@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/log"
 	"github.com/echocat/kubor/model"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -23,6 +24,7 @@ type Evaluate struct {
 
 	SourceHint bool
 	Predicate  common.EvaluatingPredicate
+	Redact     bool
 }
 
 func (instance *Evaluate) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
@@ -43,14 +45,23 @@ func (instance *Evaluate) ConfigureCliCommands(context string, hc common.HasComm
 		Short('p').
 		Envar("KUBOR_PREDICATE").
 		SetValue(&instance.Predicate)
+	cmd.Flag("offline", "Never connect to a cluster. Functions like lookup will not find anything.").
+		Envar("KUBOR_OFFLINE").
+		Default(fmt.Sprint(instance.Offline)).
+		BoolVar(&instance.Offline)
+	cmd.Flag("redact", "Redacts sensitive parts of the objects (like data of Secrets) before printing them.").
+		Envar("KUBOR_REDACT").
+		Default(fmt.Sprint(instance.Redact)).
+		BoolVar(&instance.Redact)
 
 	return nil
 }
 
 func (instance *Evaluate) RunWithArguments(arguments Arguments) error {
 	task := &evaluateTask{
-		source: instance,
-		first:  true,
+		source:   instance,
+		first:    true,
+		policies: newPolicyChecker(arguments.Project),
 	}
 	oh, err := model.NewObjectHandler(task.onObject, arguments.Project)
 	if err != nil {
@@ -62,12 +73,17 @@ func (instance *Evaluate) RunWithArguments(arguments Arguments) error {
 		return err
 	}
 
-	return oh.Handle(cp)
+	if err := oh.Handle(cp); err != nil {
+		return err
+	}
+
+	return task.policies.failOnErrors()
 }
 
 type evaluateTask struct {
-	source *Evaluate
-	first  bool
+	source   *Evaluate
+	first    bool
+	policies *policyChecker
 }
 
 func (instance *evaluateTask) onObject(source string, object runtime.Object, unstructured *unstructured.Unstructured) error {
@@ -77,6 +93,10 @@ func (instance *evaluateTask) onObject(source string, object runtime.Object, uns
 		return nil
 	}
 
+	if err := instance.policies.check(source, unstructured); err != nil {
+		return err
+	}
+
 	if instance.first {
 		instance.first = false
 	} else {
@@ -85,6 +105,16 @@ func (instance *evaluateTask) onObject(source string, object runtime.Object, uns
 	if instance.source.SourceHint {
 		fmt.Printf(sourceHintTemplate, source)
 	}
+	if instance.source.Redact {
+		object = redactObject(object)
+	}
 	encoder := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{Yaml: true, Pretty: true})
 	return encoder.Encode(object, os.Stdout)
 }
+
+func redactObject(object runtime.Object) runtime.Object {
+	if redacted, ok := log.Redact(object).(map[string]interface{}); ok {
+		return &unstructured.Unstructured{Object: redacted}
+	}
+	return object
+}
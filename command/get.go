@@ -24,6 +24,7 @@ type Get struct {
 
 	Predicate  common.EvaluatingPredicate
 	SourceHint bool
+	Redact     bool
 }
 
 func (instance *Get) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
@@ -42,6 +43,10 @@ func (instance *Get) ConfigureCliCommands(context string, hc common.HasCommands,
 		Short('p').
 		Envar("KUBOR_PREDICATE").
 		SetValue(&instance.Predicate)
+	cmd.Flag("redact", "Redacts sensitive parts of the objects (like data of Secrets) before printing them.").
+		Envar("KUBOR_REDACT").
+		Default(fmt.Sprint(instance.Redact)).
+		BoolVar(&instance.Redact)
 
 	return nil
 }
@@ -96,8 +101,12 @@ func (instance *getTask) onObject(source string, _ runtime.Object, unstructured
 		fmt.Printf(sourceHintTemplate, source)
 	}
 
+	var object runtime.Object = ul
+	if instance.source.Redact {
+		object = redactObject(object)
+	}
 	if err := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{Yaml: true, Pretty: true}).
-		Encode(ul, os.Stdout); err != nil {
+		Encode(object, os.Stdout); err != nil {
 		return err
 	}
 
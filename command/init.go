@@ -0,0 +1,243 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/alecthomas/kingpin"
+	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/log"
+	"github.com/echocat/kubor/model"
+	"github.com/echocat/kubor/wrapper"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	illegalNameCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func init() {
+	cmd := &InitProject{
+		Archetype: InitArchetypeWebService,
+		Namespace: "default",
+	}
+	RegisterInitializable(cmd)
+	common.RegisterCliFactory(cmd)
+}
+
+type InitProject struct {
+	Command
+
+	Directory   string
+	Archetype   InitArchetype
+	Namespace   string
+	Wrapper     bool
+	Force       bool
+	Interactive bool
+
+	version string
+	in      *bufio.Reader
+	out     io.Writer
+}
+
+func (instance *InitProject) ConfigureCliCommands(context string, hc common.HasCommands, version string) error {
+	if context != "" {
+		return nil
+	}
+	instance.version = version
+
+	cmd := hc.Command("init", "Creates a new project with a source file, templates and values of the given archetype."+
+		" The groupId and artifactId are taken from --groupId and --artifactId; if artifactId is absent the name of"+
+		" the directory is used.").
+		Action(instance.execute)
+	cmd.Arg("directory", "Directory where to create the project in.").
+		Default(".").
+		StringVar(&instance.Directory)
+	cmd.Flag("archetype", fmt.Sprintf("Archetype of the project to create. Can be: %v", AllInitArchetypes)).
+		Envar("KUBOR_INIT_ARCHETYPE").
+		Default(instance.Archetype.String()).
+		SetValue(&instance.Archetype)
+	cmd.Flag("namespace", "Namespace the project will be deployed to and claims.").
+		Envar("KUBOR_INIT_NAMESPACE").
+		Default(instance.Namespace).
+		StringVar(&instance.Namespace)
+	cmd.Flag("wrapper", "If set the wrapper will be installed into the project directory, too.").
+		Envar("KUBOR_INIT_WRAPPER").
+		BoolVar(&instance.Wrapper)
+	cmd.Flag("force", "If set existing files will be overwritten.").
+		Short('f').
+		BoolVar(&instance.Force)
+	cmd.Flag("interactive", "If set every property will be asked for; the provided flags are used as defaults.").
+		Short('i').
+		BoolVar(&instance.Interactive)
+
+	return nil
+}
+
+func (instance *InitProject) execute(*kingpin.ParseContext) error {
+	instance.in = bufio.NewReader(os.Stdin)
+	instance.out = os.Stdout
+	return instance.create()
+}
+
+func (instance *InitProject) create() error {
+	if instance.ProjectFactory == nil {
+		return fmt.Errorf("command not yet initialized")
+	}
+	variables := initVariables{
+		groupId:    instance.ProjectFactory.GroupId().String(),
+		artifactId: instance.ProjectFactory.ArtifactId().String(),
+		namespace:  instance.Namespace,
+	}
+	if variables.artifactId == "" {
+		name, err := instance.nameOfDirectory()
+		if err != nil {
+			return err
+		}
+		variables.artifactId = name
+	}
+
+	if instance.Interactive {
+		if err := instance.prompt(&variables); err != nil {
+			return err
+		}
+	}
+
+	files := map[string]string{
+		".kubor.yml": initProject,
+	}
+	for path, content := range instance.Archetype.files() {
+		files[path] = content
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if !instance.Force {
+		var existing []string
+		for _, path := range paths {
+			if _, err := os.Stat(filepath.Join(instance.Directory, path)); err == nil {
+				existing = append(existing, path)
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("the following files already exist in '%s' (use --force to overwrite them): %s", instance.Directory, strings.Join(existing, ", "))
+		}
+	}
+
+	for _, path := range paths {
+		file := filepath.Join(instance.Directory, path)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("cannot create directory of '%s': %w", file, err)
+		}
+		if err := ioutil.WriteFile(file, []byte(variables.replace(files[path])), 0644); err != nil {
+			return fmt.Errorf("cannot write '%s': %w", file, err)
+		}
+		log.WithField("file", file).Info("Created %s.", file)
+	}
+
+	if instance.Wrapper {
+		opt := wrapper.WriteOpt(wrapper.WoCreateOnly)
+		if instance.Force {
+			opt = wrapper.WoCreateOrUpdate
+		}
+		if err := wrapper.Write(instance.Directory, instance.version, opt); err != nil {
+			return fmt.Errorf("cannot install wrapper: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (instance *InitProject) nameOfDirectory() (string, error) {
+	abs, err := filepath.Abs(instance.Directory)
+	if err != nil {
+		return "", err
+	}
+	name := strings.Trim(illegalNameCharacters.ReplaceAllString(strings.ToLower(filepath.Base(abs)), "-"), "-")
+	if name == "" {
+		return "", fmt.Errorf("cannot derive an artifactId from directory '%s'; please provide --artifactId", instance.Directory)
+	}
+	return name, nil
+}
+
+func (instance *InitProject) prompt(variables *initVariables) error {
+	var err error
+	if variables.groupId, err = instance.ask("groupId", variables.groupId, validateInitName(true)); err != nil {
+		return err
+	}
+	if variables.artifactId, err = instance.ask("artifactId", variables.artifactId, validateInitName(false)); err != nil {
+		return err
+	}
+	if variables.namespace, err = instance.ask("namespace", variables.namespace, validateInitName(false)); err != nil {
+		return err
+	}
+	if _, err = instance.ask(fmt.Sprintf("archetype %v", AllInitArchetypes), instance.Archetype.String(), instance.Archetype.Set); err != nil {
+		return err
+	}
+	wrapperDefault := "n"
+	if instance.Wrapper {
+		wrapperDefault = "y"
+	}
+	answer, err := instance.ask("install wrapper (y/n)", wrapperDefault, func(plain string) error {
+		if plain != "y" && plain != "n" {
+			return fmt.Errorf("please answer y or n")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	instance.Wrapper = answer == "y"
+	return nil
+}
+
+// ask prompts for the given property until the answer is accepted by validate.
+// An empty answer selects the given default.
+func (instance *InitProject) ask(property string, def string, validate func(string) error) (string, error) {
+	for {
+		if _, err := fmt.Fprintf(instance.out, "%s [%s]: ", property, def); err != nil {
+			return "", err
+		}
+		line, err := instance.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return "", fmt.Errorf("no answer for %s", property)
+			}
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if vErr := validate(answer); vErr != nil {
+			if _, err := fmt.Fprintln(instance.out, vErr.Error()); err != nil {
+				return "", err
+			}
+			continue
+		}
+		return answer, nil
+	}
+}
+
+func validateInitName(optional bool) func(string) error {
+	return func(plain string) error {
+		if plain == "" {
+			if optional {
+				return nil
+			}
+			return fmt.Errorf("please provide a value")
+		}
+		_, err := model.Name(plain).MarshalText()
+		return err
+	}
+}
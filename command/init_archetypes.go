@@ -0,0 +1,211 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type InitArchetype string
+
+const (
+	InitArchetypeWebService = InitArchetype("web-service")
+	InitArchetypeWorker     = InitArchetype("worker")
+	InitArchetypeCronJob    = InitArchetype("cronjob")
+)
+
+var (
+	AllInitArchetypes = []InitArchetype{InitArchetypeWebService, InitArchetypeWorker, InitArchetypeCronJob}
+)
+
+func (instance *InitArchetype) Set(plain string) error {
+	for _, candidate := range AllInitArchetypes {
+		if string(candidate) == plain {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported archetype: %s; supported are: %v", plain, AllInitArchetypes)
+}
+
+func (instance InitArchetype) String() string {
+	return string(instance)
+}
+
+// files returns the files of this archetype by their path relative to the
+// project directory. Contained placeholders are replaced by initVariables.
+func (instance InitArchetype) files() map[string]string {
+	switch instance {
+	case InitArchetypeWorker:
+		return map[string]string{
+			"values/common.yml":               initWorkerValues,
+			"kubernetes/templates/worker.yml": initWorkerDeployment,
+			"kubernetes/templates/config.yml": initConfigMap,
+		}
+	case InitArchetypeCronJob:
+		return map[string]string{
+			"values/common.yml":                initCronJobValues,
+			"kubernetes/templates/cronjob.yml": initCronJob,
+			"kubernetes/templates/config.yml":  initConfigMap,
+		}
+	default:
+		return map[string]string{
+			"values/common.yml":                   initWebServiceValues,
+			"kubernetes/templates/deployment.yml": initWebServiceDeployment,
+			"kubernetes/templates/service.yml":    initWebServiceService,
+			"kubernetes/templates/config.yml":     initConfigMap,
+		}
+	}
+}
+
+type initVariables struct {
+	groupId    string
+	artifactId string
+	namespace  string
+}
+
+func (instance initVariables) replace(in string) string {
+	groupIdLine := ""
+	if instance.groupId != "" {
+		groupIdLine = "groupId: " + instance.groupId + "\n"
+	}
+	return strings.NewReplacer(
+		"__GROUP_ID_LINE__", groupIdLine,
+		"__ARTIFACT_ID__", instance.artifactId,
+		"__NAMESPACE__", instance.namespace,
+	).Replace(in)
+}
+
+const initProject = `__GROUP_ID_LINE__artifactId: __ARTIFACT_ID__
+
+valueFiles:
+- "{{ .Root }}/values/common.yml"
+- "?{{ .Root }}/values/{{ .Context }}.yml"
+
+claim:
+  namespaces: [__NAMESPACE__]
+`
+
+const initConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: "{{ .ArtifactId }}"
+  namespace: "{{ .Values.namespace }}"
+data:
+  LOG_LEVEL: "{{ .Values.logLevel }}"
+`
+
+const initWebServiceValues = `namespace: __NAMESPACE__
+image:
+  name: __ARTIFACT_ID__
+  tag: latest
+replicas: 2
+port: 8080
+logLevel: info
+`
+
+const initWebServiceDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "{{ .ArtifactId }}"
+  namespace: "{{ .Values.namespace }}"
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: "{{ .ArtifactId }}"
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: "{{ .ArtifactId }}"
+    spec:
+      containers:
+      - name: "{{ .ArtifactId }}"
+        image: "{{ .Values.image.name }}:{{ .Values.image.tag }}"
+        envFrom:
+        - configMapRef:
+            name: "{{ .ArtifactId }}"
+        ports:
+        - name: http
+          containerPort: {{ .Values.port }}
+        readinessProbe:
+          httpGet:
+            path: /
+            port: http
+`
+
+const initWebServiceService = `apiVersion: v1
+kind: Service
+metadata:
+  name: "{{ .ArtifactId }}"
+  namespace: "{{ .Values.namespace }}"
+spec:
+  selector:
+    app.kubernetes.io/name: "{{ .ArtifactId }}"
+  ports:
+  - name: http
+    port: 80
+    targetPort: http
+`
+
+const initWorkerValues = `namespace: __NAMESPACE__
+image:
+  name: __ARTIFACT_ID__
+  tag: latest
+replicas: 1
+logLevel: info
+`
+
+const initWorkerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "{{ .ArtifactId }}"
+  namespace: "{{ .Values.namespace }}"
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: "{{ .ArtifactId }}"
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: "{{ .ArtifactId }}"
+    spec:
+      containers:
+      - name: "{{ .ArtifactId }}"
+        image: "{{ .Values.image.name }}:{{ .Values.image.tag }}"
+        envFrom:
+        - configMapRef:
+            name: "{{ .ArtifactId }}"
+`
+
+const initCronJobValues = `namespace: __NAMESPACE__
+image:
+  name: __ARTIFACT_ID__
+  tag: latest
+schedule: "0 * * * *"
+logLevel: info
+`
+
+const initCronJob = `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: "{{ .ArtifactId }}"
+  namespace: "{{ .Values.namespace }}"
+spec:
+  schedule: "{{ .Values.schedule }}"
+  concurrencyPolicy: Forbid
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app.kubernetes.io/name: "{{ .ArtifactId }}"
+        spec:
+          restartPolicy: OnFailure
+          containers:
+          - name: "{{ .ArtifactId }}"
+            image: "{{ .Values.image.name }}:{{ .Values.image.tag }}"
+            envFrom:
+            - configMapRef:
+                name: "{{ .ArtifactId }}"
+`
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/echocat/kubor/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_InitProject_create(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-init")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+
+	cases := []struct {
+		archetype InitArchetype
+		expected  []string
+	}{{
+		archetype: InitArchetypeWebService,
+		expected: []string{
+			".kubor.yml",
+			"kubernetes/templates/config.yml",
+			"kubernetes/templates/deployment.yml",
+			"kubernetes/templates/service.yml",
+			"values/common.yml",
+		},
+	}, {
+		archetype: InitArchetypeWorker,
+		expected: []string{
+			".kubor.yml",
+			"kubernetes/templates/config.yml",
+			"kubernetes/templates/worker.yml",
+			"values/common.yml",
+		},
+	}, {
+		archetype: InitArchetypeCronJob,
+		expected: []string{
+			".kubor.yml",
+			"kubernetes/templates/config.yml",
+			"kubernetes/templates/cronjob.yml",
+			"values/common.yml",
+		},
+	}}
+	for _, c := range cases {
+		t.Run(c.archetype.String(), func(t *testing.T) {
+			dir := filepath.Join(root, "My_"+c.archetype.String())
+			instance := &InitProject{
+				Command:   Command{ProjectFactory: model.NewProjectFactory()},
+				Directory: dir,
+				Archetype: c.archetype,
+				Namespace: "team-a",
+			}
+			require.NoError(t, instance.create())
+
+			var actual []string
+			require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					rel, err := filepath.Rel(dir, path)
+					actual = append(actual, filepath.ToSlash(rel))
+					return err
+				}
+				return err
+			}))
+			sort.Strings(actual)
+			assert.Equal(t, c.expected, actual)
+
+			project, err := model.NewProjectFactory().ForSource(filepath.Join(dir, ".kubor.yml")).Create("")
+			require.NoError(t, err)
+			assert.Equal(t, model.Name("my-"+c.archetype.String()), project.ArtifactId)
+
+			task := newLintTask(project)
+			task.run()
+			assert.Empty(t, task.diagnostics.Errors())
+		})
+	}
+}
+
+func Test_InitProject_create_refusesToOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-init")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".kubor.yml"), []byte("artifactId: mine\n"), 0644))
+
+	instance := &InitProject{
+		Command:   Command{ProjectFactory: model.NewProjectFactory()},
+		Directory: dir,
+		Archetype: InitArchetypeWorker,
+		Namespace: "default",
+	}
+	err = instance.create()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use --force")
+	assert.Contains(t, err.Error(), ".kubor.yml")
+	content, err := ioutil.ReadFile(filepath.Join(dir, ".kubor.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "artifactId: mine\n", string(content))
+	_, err = os.Stat(filepath.Join(dir, "values", "common.yml"))
+	assert.True(t, os.IsNotExist(err))
+
+	instance.Force = true
+	require.NoError(t, instance.create())
+	content, err = ioutil.ReadFile(filepath.Join(dir, ".kubor.yml"))
+	require.NoError(t, err)
+	assert.NotEqual(t, "artifactId: mine\n", string(content))
+}
+
+func Test_InitProject_create_interactive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-init")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+
+	out := new(bytes.Buffer)
+	instance := &InitProject{
+		Command:     Command{ProjectFactory: model.NewProjectFactory()},
+		Directory:   dir,
+		Archetype:   InitArchetypeWebService,
+		Namespace:   "default",
+		Interactive: true,
+		in:          bufio.NewReader(strings.NewReader("acme\nshop\n\nbatch\ncronjob\nn\n")),
+		out:         out,
+	}
+	require.NoError(t, instance.create())
+
+	assert.Contains(t, out.String(), "unsupported archetype: batch")
+	assert.Equal(t, InitArchetypeCronJob, instance.Archetype)
+	_, err = os.Stat(filepath.Join(dir, "kubernetes", "templates", "cronjob.yml"))
+	assert.NoError(t, err)
+	content, err := ioutil.ReadFile(filepath.Join(dir, ".kubor.yml"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "groupId: acme\nartifactId: shop\n"), string(content))
+}
+
+func Test_InitArchetype_Set(t *testing.T) {
+	var instance InitArchetype
+	assert.NoError(t, instance.Set("worker"))
+	assert.Equal(t, InitArchetypeWorker, instance)
+	assert.Error(t, instance.Set("batch"))
+}
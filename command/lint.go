@@ -0,0 +1,535 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alecthomas/kingpin"
+	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/kubernetes"
+	"github.com/echocat/kubor/kubernetes/transformation"
+	"github.com/echocat/kubor/model"
+	"github.com/echocat/kubor/template"
+	yaml2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	lintYamlErrorLineRegexp  = regexp.MustCompile(`line (\d+): (.*)`)
+	lintValueReferenceRegexp = regexp.MustCompile(`\.Values\.([A-Za-z_][A-Za-z0-9_]*)`)
+	lintValueIndexRegexp     = regexp.MustCompile(`index\s+\$?\.Values\s+"([^"]+)"`)
+	lintValueWholeRegexp     = regexp.MustCompile(`\.Values([^.\w]|$)`)
+)
+
+func init() {
+	cmd := &Lint{
+		Output: LintOutput("text"),
+	}
+	cmd.Parent = cmd
+	cmd.Offline = true
+	RegisterInitializable(cmd)
+	common.RegisterCliFactory(cmd)
+}
+
+type Lint struct {
+	Command
+
+	Output LintOutput
+}
+
+func (instance *Lint) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
+	if context != "" {
+		return nil
+	}
+
+	cmd := hc.Command("lint", "Checks the project and its templates for problems without connecting to any cluster.").
+		Action(instance.ExecuteFromCli)
+
+	cmd.Flag("output", "Specifies how to render the found problems (text, json, github or gitlab).").
+		Short('o').
+		Envar("KUBOR_LINT_OUTPUT").
+		Default(instance.Output.String()).
+		SetValue(&instance.Output)
+
+	return nil
+}
+
+func (instance *Lint) ExecuteFromCli(_ *kingpin.ParseContext) error {
+	runtime, err := instance.newRuntime()
+	if err != nil {
+		return err
+	}
+	project, err := instance.createProject(runtime)
+	if err != nil {
+		task := newLintTask(nil)
+		source := instance.ProjectFactory.Source()
+		task.checkSourceSchema(source)
+		if len(task.diagnostics) == 0 {
+			task.add(lintDiagnostic{File: source, Severity: model.PolicySeverityError, Check: "project", Message: err.Error()})
+		}
+		return instance.finish(task)
+	}
+	return instance.RunWithArguments(Arguments{
+		Project: project,
+		Runtime: runtime,
+	})
+}
+
+func (instance *Lint) RunWithArguments(arguments Arguments) error {
+	task := newLintTask(arguments.Project)
+	task.run()
+	return instance.finish(task)
+}
+
+func (instance *Lint) finish(task *lintTask) error {
+	if err := task.diagnostics.render(instance.Output, os.Stdout); err != nil {
+		return err
+	}
+	if errs := task.diagnostics.Errors(); len(errs) > 0 {
+		return fmt.Errorf("lint found %d error(s)", len(errs))
+	}
+	return nil
+}
+
+type lintTask struct {
+	project       *model.Project
+	diagnostics   lintDiagnostics
+	duplicates    *kubernetes.DuplicateDetector
+	documentLines map[string][]int
+}
+
+func newLintTask(project *model.Project) *lintTask {
+	return &lintTask{
+		project:       project,
+		duplicates:    kubernetes.NewDuplicateDetector(project),
+		documentLines: map[string][]int{},
+	}
+}
+
+func (instance *lintTask) add(diagnostic lintDiagnostic) {
+	instance.diagnostics = append(instance.diagnostics, diagnostic)
+}
+
+func (instance *lintTask) run() {
+	p := instance.project
+	if p.Source != "" {
+		instance.checkSourceSchema(p.Source)
+	}
+	instance.checkProject()
+
+	files, err := p.Templating.TemplateFiles(p)
+	if err != nil {
+		instance.add(lintDiagnostic{File: p.Source, Severity: model.PolicySeverityError, Check: "templating", Message: err.Error()})
+		return
+	}
+	unparsable := map[string]bool{}
+	for _, file := range files {
+		if _, err := p.Templating.TemplateFactory().NewFromFile(file); err != nil {
+			instance.addTemplateError(file, "template-parse", err)
+			unparsable[file] = true
+		}
+	}
+	instance.checkRenderedObjects(unparsable)
+	instance.checkUnusedValues(files)
+}
+
+// checkSourceSchema reads the project source file in strict mode to find
+// properties which are unknown to kubor.
+func (instance *lintTask) checkSourceSchema(source string) {
+	f, err := os.Open(source)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		instance.add(lintDiagnostic{File: source, Severity: model.PolicySeverityError, Check: "project-schema", Message: err.Error()})
+		return
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	target := model.NewProject()
+	decoder := yaml2.NewDecoder(f)
+	decoder.SetStrict(true)
+	if err := decoder.Decode(&target); err == io.EOF {
+		return
+	} else if err != nil {
+		matches := lintYamlErrorLineRegexp.FindAllStringSubmatch(err.Error(), -1)
+		if len(matches) == 0 {
+			instance.add(lintDiagnostic{File: source, Severity: model.PolicySeverityError, Check: "project-schema", Message: err.Error()})
+		}
+		for _, match := range matches {
+			line, _ := strconv.Atoi(match[1])
+			instance.add(lintDiagnostic{File: source, Line: line, Severity: model.PolicySeverityError, Check: "project-schema", Message: match[2]})
+		}
+	}
+}
+
+func (instance *lintTask) checkProject() {
+	p := instance.project
+	if err := p.Validate(); err != nil {
+		instance.add(lintDiagnostic{File: p.Source, Severity: model.PolicySeverityError, Check: "project", Message: err.Error()})
+	}
+	for _, err := range p.Lint() {
+		instance.add(lintDiagnostic{File: p.Source, Severity: model.PolicySeverityError, Check: "project", Message: err.Error()})
+	}
+	for _, name := range instance.sortedTransformationNames() {
+		if !transformation.Default.Has(name) {
+			instance.add(lintDiagnostic{
+				File:     p.Source,
+				Line:     yamlLineOf(p.Source, "transformations", string(name)),
+				Severity: model.PolicySeverityError,
+				Check:    "unknown-transformation",
+				Message:  fmt.Sprintf("transformation %v is unknown", name),
+			})
+		}
+	}
+}
+
+func (instance *lintTask) sortedTransformationNames() []model.TransformationName {
+	result := make([]model.TransformationName, 0, len(instance.project.Transformations))
+	for name := range instance.project.Transformations {
+		result = append(result, name)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// checkRenderedObjects renders the templates, charts and overlays of the
+// project like every other command and checks all objects. Template files
+// which are already known to be unparsable are not reported again.
+func (instance *lintTask) checkRenderedObjects(unparsable map[string]bool) {
+	p := instance.project
+	// Lint should also work without having access to the secret key; in this
+	// case the encrypted values are used as they are.
+	data := *p
+	if decrypted, err := p.WithDecryptedValues(); err == nil {
+		data = decrypted
+	}
+	cp, err := data.RenderedTemplatesProviderAsIs()
+	if err != nil {
+		instance.add(lintDiagnostic{File: p.Source, Severity: model.PolicySeverityError, Check: "templating", Message: err.Error()})
+		return
+	}
+	oh, err := model.NewObjectHandler(instance.onObject, p)
+	if err != nil {
+		instance.add(lintDiagnostic{File: p.Source, Severity: model.PolicySeverityError, Check: "object", Message: err.Error()})
+		return
+	}
+
+	for {
+		source, content, err := cp()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if !unparsable[source] {
+				instance.addTemplateError(source, "template-render", err)
+			}
+			continue
+		}
+		served := false
+		if err := oh.Handle(func() (string, []byte, error) {
+			if served {
+				return "", nil, io.EOF
+			}
+			served = true
+			return source, content, nil
+		}); err != nil {
+			instance.add(lintDiagnostic{File: source, Severity: model.PolicySeverityError, Check: "object", Message: err.Error()})
+		}
+	}
+}
+
+func (instance *lintTask) addTemplateError(file string, check string, err error) {
+	diagnostic := lintDiagnostic{File: file, Severity: model.PolicySeverityError, Check: check, Message: err.Error()}
+	var templateErr *template.Error
+	if errors.As(err, &templateErr) {
+		diagnostic.File = templateErr.Source
+		diagnostic.Line = templateErr.Line
+		diagnostic.Column = templateErr.Column
+		diagnostic.Message = templateErr.Message
+		diagnostic.Expression = templateErr.Expression
+		for _, from := range templateErr.IncludedFrom {
+			diagnostic.IncludedFrom = append(diagnostic.IncludedFrom, from.String())
+		}
+		diagnostic.Snippet = templateErr.Snippet
+		diagnostic.details = templateErr.Details()
+	}
+	instance.add(diagnostic)
+}
+
+func (instance *lintTask) onObject(source string, _ runtime.Object, object *unstructured.Unstructured) error {
+	p := instance.project
+	file, line := instance.locationOf(source)
+	report := func(severity model.PolicySeverity, check string, message string, args ...interface{}) {
+		instance.add(lintDiagnostic{File: file, Line: line, Severity: severity, Check: check, Message: fmt.Sprintf(message, args...)})
+	}
+
+	reference, referenceErr := kubernetes.GetObjectReference(object, p.Scheme)
+	description := source
+	if referenceErr != nil {
+		report(model.PolicySeverityError, "object", "%s: %v", source, referenceErr)
+	} else {
+		description = reference.String()
+	}
+
+	if stage, err := p.Annotations.GetStageFor(object); err != nil {
+		report(model.PolicySeverityError, "annotation-stage", "%s: %v", description, err)
+	} else if !p.Stages.Contains(stage) {
+		report(model.PolicySeverityError, "unknown-stage", "%s has defined an unknown stage: %v; project defines: %v", description, stage, p.Stages)
+	}
+	if _, err := p.Annotations.GetApplyOnFor(object); err != nil {
+		report(model.PolicySeverityError, "annotation-apply-on", "%s: %v", description, err)
+	}
+	if _, err := p.Annotations.GetDryRunOnFor(object, model.DryRunOnServerIfPossible); err != nil {
+		report(model.PolicySeverityError, "annotation-dry-run-on", "%s: %v", description, err)
+	}
+	if _, err := p.Annotations.GetWaitUntilFor(object); err != nil {
+		report(model.PolicySeverityError, "annotation-wait-until", "%s: %v", description, err)
+	}
+	if _, err := p.Annotations.GetCleanupOn(object); err != nil {
+		report(model.PolicySeverityError, "annotation-cleanup-on", "%s: %v", description, err)
+	}
+	instance.checkAnnotationNames(object, description, report)
+
+	if referenceErr == nil {
+		if err := p.Claim.Validate(reference); err != nil {
+			report(model.PolicySeverityError, "claim", "%s: %v", description, err)
+		}
+		if _, err := instance.duplicates.Add(source, reference, object); err != nil {
+			report(model.PolicySeverityError, "duplicate-object", "%v", err)
+		}
+	}
+
+	if violations, err := p.Policies.Check(source, object); err != nil {
+		report(model.PolicySeverityError, "policy", "%v", err)
+	} else {
+		for _, violation := range violations {
+			report(violation.Rule.GetSeverity(), "policy:"+violation.Rule.Name, "%s: %s", description, violation.Message())
+		}
+	}
+
+	return nil
+}
+
+func (instance *lintTask) checkAnnotationNames(object *unstructured.Unstructured, description string, report func(severity model.PolicySeverity, check string, message string, args ...interface{})) {
+	p := instance.project
+	transformationPrefix := string(p.Annotations.Transformations.Name)
+	known := map[string]bool{}
+	domains := map[string]bool{}
+	for _, annotation := range p.Annotations.All() {
+		name := string(annotation.Name)
+		if name == transformationPrefix {
+			continue
+		}
+		known[name] = true
+		if i := strings.Index(name, "/"); i > 0 {
+			domains[name[:i+1]] = true
+		}
+	}
+
+	annotations := object.GetAnnotations()
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if transformationPrefix != "" && strings.HasPrefix(key, transformationPrefix) {
+			name := model.TransformationName(key[len(transformationPrefix):])
+			if !transformation.Default.Has(name) {
+				report(model.PolicySeverityError, "unknown-transformation", "%s references unknown transformation %v using annotation %s", description, name, key)
+			}
+		} else if i := strings.Index(key, "/"); i > 0 && domains[key[:i+1]] && !known[key] {
+			report(model.PolicySeverityWarning, "unknown-annotation", "%s has unknown annotation %s", description, key)
+		}
+	}
+}
+
+// checkUnusedValues reports every value which is not referenced by any file of
+// the directories containing template files nor by the project source file.
+// This is only a heuristic based on the text of the files: only references of
+// top level values like .Values.x or index .Values "x" are found and if at
+// least one file uses .Values as a whole every value is considered used.
+// Therefore the findings are only warnings.
+func (instance *lintTask) checkUnusedValues(templateFiles []string) {
+	p := instance.project
+	if len(p.Values) == 0 {
+		return
+	}
+
+	files := map[string]bool{}
+	if p.Source != "" {
+		files[p.Source] = true
+	}
+	if patchFiles, err := p.Templating.Overlays.PatchFiles(p); err == nil {
+		for _, patchFile := range patchFiles {
+			files[patchFile] = true
+		}
+	}
+	for _, library := range p.Libraries {
+		dir := model.LibraryDirectory(p.Root, library.Name)
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				files[path] = true
+			}
+			return nil
+		})
+	}
+	for _, templateFile := range templateFiles {
+		files[templateFile] = true
+		if siblings, err := ioutil.ReadDir(filepath.Dir(templateFile)); err == nil {
+			for _, sibling := range siblings {
+				if !sibling.IsDir() {
+					files[filepath.Join(filepath.Dir(templateFile), sibling.Name())] = true
+				}
+			}
+		}
+	}
+
+	used := map[string]bool{}
+	for _, c := range p.Templating.Charts {
+		// Charts receive only the values below valuesPath.
+		if path, err := model.ParseValuePath(c.ValuesPath); c.ValuesPath != "" && err == nil {
+			used[path[0]] = true
+		}
+	}
+	for file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		content := string(b)
+		for _, match := range lintValueIndexRegexp.FindAllStringSubmatch(content, -1) {
+			used[match[1]] = true
+		}
+		content = lintValueIndexRegexp.ReplaceAllString(content, "")
+		for _, match := range lintValueReferenceRegexp.FindAllStringSubmatch(content, -1) {
+			used[match[1]] = true
+		}
+		if lintValueWholeRegexp.MatchString(content) {
+			return
+		}
+	}
+
+	names := make([]string, 0, len(p.Values))
+	for name := range p.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[name] {
+			instance.add(lintDiagnostic{
+				File:     p.Source,
+				Line:     yamlLineOf(p.Source, "values", "*", name),
+				Severity: model.PolicySeverityWarning,
+				Check:    "unused-value",
+				Message:  fmt.Sprintf("value %s seems not to be used by any template (heuristic: no .Values.%s or index .Values %q found)", name, name, name),
+			})
+		}
+	}
+}
+
+// locationOf resolves a source like "<file>#<document index>" to the file and
+// the line where the document starts inside the file. As templates could
+// produce documents dynamically the line is only a best guess based on the
+// document separators of the template file itself; if it cannot be
+// determined 0 is returned.
+func (instance *lintTask) locationOf(source string) (string, int) {
+	i := strings.LastIndex(source, "#")
+	if i < 0 {
+		return source, 0
+	}
+	file := source[:i]
+	index, err := strconv.Atoi(source[i+1:])
+	if err != nil {
+		return source, 0
+	}
+	lines, ok := instance.documentLines[file]
+	if !ok {
+		lines = documentStartLinesOf(file)
+		instance.documentLines[file] = lines
+	}
+	if index < len(lines) {
+		return file, lines[index]
+	}
+	return file, 0
+}
+
+func documentStartLinesOf(file string) []int {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	result := []int{1}
+	for i, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == "---" {
+			if i == 0 {
+				result[0] = 2
+			} else {
+				result = append(result, i+2)
+			}
+		}
+	}
+	return result
+}
+
+// yamlLineOf returns the line of the key which is addressed by the given path
+// inside the given YAML file. A path element of "*" matches every element of
+// a sequence. If nothing could be found 0 is returned.
+func yamlLineOf(file string, path ...string) int {
+	if file == "" {
+		return 0
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return 0
+	}
+	return yamlLineOfNode(&node, path)
+}
+
+func yamlLineOfNode(node *yaml.Node, path []string) int {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return 0
+		}
+		return yamlLineOfNode(node.Content[0], path)
+	}
+	if len(path) == 0 {
+		return node.Line
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == path[0] {
+				if len(path) == 1 {
+					return node.Content[i].Line
+				}
+				return yamlLineOfNode(node.Content[i+1], path[1:])
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				if line := yamlLineOfNode(child, path[1:]); line > 0 {
+					return line
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/kubor/model"
+	"io"
+	"strings"
+)
+
+type LintOutput string
+
+func (instance *LintOutput) Set(plain string) error {
+	if plain != "text" && plain != "json" && plain != "github" && plain != "gitlab" {
+		return fmt.Errorf("unsupported output format: %s", plain)
+	}
+	*instance = LintOutput(plain)
+	return nil
+}
+
+func (instance LintOutput) String() string {
+	return string(instance)
+}
+
+type lintDiagnostic struct {
+	File     string               `json:"file"`
+	Line     int                  `json:"line,omitempty"`
+	Column   int                  `json:"column,omitempty"`
+	Severity model.PolicySeverity `json:"severity"`
+	Check    string               `json:"check"`
+	Message  string               `json:"message"`
+	// Expression, IncludedFrom and Snippet are set for errors of templates.
+	Expression   string   `json:"expression,omitempty"`
+	IncludedFrom []string `json:"includedFrom,omitempty"`
+	Snippet      string   `json:"snippet,omitempty"`
+
+	details string
+}
+
+func (instance lintDiagnostic) location() string {
+	result := instance.File
+	if instance.Line > 0 {
+		result += fmt.Sprintf(":%d", instance.Line)
+		if instance.Column > 0 {
+			result += fmt.Sprintf(":%d", instance.Column)
+		}
+	}
+	return result
+}
+
+func (instance lintDiagnostic) String() string {
+	return fmt.Sprintf("%s: %v: %s [%s]", instance.location(), instance.Severity, instance.Message, instance.Check)
+}
+
+type lintDiagnostics []lintDiagnostic
+
+func (instance lintDiagnostics) Errors() lintDiagnostics {
+	var result lintDiagnostics
+	for _, candidate := range instance {
+		if candidate.Severity.IsError() {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func (instance lintDiagnostics) render(output LintOutput, target io.Writer) error {
+	switch output {
+	case LintOutput("json"):
+		return instance.renderJson(target)
+	case LintOutput("github"):
+		return instance.renderGithub(target)
+	case LintOutput("gitlab"):
+		return instance.renderGitlab(target)
+	default:
+		return instance.renderText(target)
+	}
+}
+
+func (instance lintDiagnostics) renderText(target io.Writer) error {
+	for _, diagnostic := range instance {
+		if _, err := fmt.Fprintln(target, diagnostic.String()); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(diagnostic.details, "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(target, "    "+line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (instance lintDiagnostics) renderJson(target io.Writer) error {
+	enc := json.NewEncoder(target)
+	enc.SetIndent("", "  ")
+	if instance == nil {
+		return enc.Encode(lintDiagnostics{})
+	}
+	return enc.Encode(instance)
+}
+
+// renderGithub renders the diagnostics as GitHub Actions workflow commands.
+// See https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+func (instance lintDiagnostics) renderGithub(target io.Writer) error {
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	for _, diagnostic := range instance {
+		command := "error"
+		switch diagnostic.Severity {
+		case model.PolicySeverityWarning:
+			command = "warning"
+		case model.PolicySeverityInfo:
+			command = "notice"
+		}
+		properties := "file=" + escapeProperty.Replace(diagnostic.File)
+		if diagnostic.Line > 0 {
+			properties += fmt.Sprintf(",line=%d", diagnostic.Line)
+		}
+		if diagnostic.Column > 0 {
+			properties += fmt.Sprintf(",col=%d", diagnostic.Column)
+		}
+		properties += ",title=" + escapeProperty.Replace(diagnostic.Check)
+		if _, err := fmt.Fprintf(target, "::%s %s::%s\n", command, properties, escape.Replace(diagnostic.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type gitlabCodeQualityIssue struct {
+	Description string                    `json:"description"`
+	CheckName   string                    `json:"check_name"`
+	Fingerprint string                    `json:"fingerprint"`
+	Severity    string                    `json:"severity"`
+	Location    gitlabCodeQualityLocation `json:"location"`
+}
+
+type gitlabCodeQualityLocation struct {
+	Path  string                 `json:"path"`
+	Lines gitlabCodeQualityLines `json:"lines"`
+}
+
+type gitlabCodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// renderGitlab renders the diagnostics as GitLab Code Quality report.
+// See https://docs.gitlab.com/ee/user/project/merge_requests/code_quality.html
+func (instance lintDiagnostics) renderGitlab(target io.Writer) error {
+	issues := make([]gitlabCodeQualityIssue, len(instance))
+	for i, diagnostic := range instance {
+		severity := "major"
+		switch diagnostic.Severity {
+		case model.PolicySeverityWarning:
+			severity = "minor"
+		case model.PolicySeverityInfo:
+			severity = "info"
+		}
+		line := diagnostic.Line
+		if line <= 0 {
+			line = 1
+		}
+		fingerprint := sha256.Sum256([]byte(diagnostic.String()))
+		issues[i] = gitlabCodeQualityIssue{
+			Description: diagnostic.Message,
+			CheckName:   diagnostic.Check,
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			Severity:    severity,
+			Location: gitlabCodeQualityLocation{
+				Path:  diagnostic.File,
+				Lines: gitlabCodeQualityLines{Begin: line},
+			},
+		}
+	}
+	enc := json.NewEncoder(target)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
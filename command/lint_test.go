@@ -0,0 +1,161 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/echocat/kubor/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_lintTask_run(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-lint")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		".kubor.yml": `
+groupId: a
+artifactId: app
+policies:
+  rules:
+  - name: team
+    predicate: "{{ .metadata.labels.team }}=.+"
+    severity: warning
+templating:
+  charts:
+  - path: "{{ .Root }}/chart"
+    valuesPath: chart
+  overlays:
+  - base: "{{ .Root }}/base"
+values:
+- chart: {}
+  unused: 1
+`,
+		"kubernetes/templates/ok.yml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: from-template
+  namespace: a
+`,
+		"kubernetes/templates/stage.yml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: with-stage
+  namespace: a
+  annotations:
+    kubor.echocat.org/stage: unknown
+  labels:
+    team: a
+`,
+		"kubernetes/templates/parse.yml":  "a: {{ if }}\n",
+		"kubernetes/templates/render.yml": "a: {{ index 1 2 }}\n",
+		"chart/Chart.yaml":                "name: c\nversion: 1.0.0\n",
+		"chart/templates/cm.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: from-chart
+  namespace: a
+`,
+		"base/cm.yml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: from-overlay
+  namespace: a
+`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	project, err := model.NewProjectFactory().ForSource(filepath.Join(root, ".kubor.yml")).Create("")
+	require.NoError(t, err)
+
+	task := newLintTask(project)
+	task.run()
+
+	checks := map[string][]string{}
+	for _, diagnostic := range task.diagnostics {
+		rel, err := filepath.Rel(root, diagnostic.File)
+		require.NoError(t, err)
+		checks[diagnostic.Check] = append(checks[diagnostic.Check], filepath.ToSlash(rel))
+	}
+
+	assert.Equal(t, []string{"kubernetes/templates/parse.yml"}, checks["template-parse"])
+	assert.Equal(t, []string{"kubernetes/templates/render.yml"}, checks["template-render"])
+	assert.Equal(t, []string{"kubernetes/templates/stage.yml"}, checks["unknown-stage"])
+	assert.ElementsMatch(t, []string{
+		"kubernetes/templates/ok.yml",
+		"chart/templates/cm.yaml",
+		"base/cm.yml",
+	}, checks["policy:team"])
+	assert.Equal(t, []string{".kubor.yml"}, checks["unused-value"])
+	assert.Len(t, task.diagnostics.Errors(), 3)
+}
+
+func Test_lintDiagnostics_render(t *testing.T) {
+	diagnostics := lintDiagnostics{
+		{File: "a.yml", Line: 2, Column: 3, Severity: model.PolicySeverityError, Check: "template-parse", Message: "bad: x"},
+		{File: "b.yml", Severity: model.PolicySeverityWarning, Check: "policy:team", Message: "missing, label"},
+	}
+
+	cases := []struct {
+		output   LintOutput
+		expected string
+	}{{
+		output: "text",
+		expected: "a.yml:2:3: error: bad: x [template-parse]\n" +
+			"b.yml: warning: missing, label [policy:team]\n",
+	}, {
+		output: "github",
+		expected: "::error file=a.yml,line=2,col=3,title=template-parse::bad: x\n" +
+			"::warning file=b.yml,title=policy%3Ateam::missing, label\n",
+	}}
+	for _, c := range cases {
+		t.Run(c.output.String(), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.NoError(t, diagnostics.render(c.output, buf))
+			assert.Equal(t, c.expected, buf.String())
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, diagnostics.render("json", buf))
+		var actual []map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+		require.Len(t, actual, 2)
+		assert.Equal(t, "a.yml", actual[0]["file"])
+		assert.Equal(t, float64(2), actual[0]["line"])
+		assert.Equal(t, "policy:team", actual[1]["check"])
+		assert.NotContains(t, actual[1], "line")
+
+		buf.Reset()
+		require.NoError(t, lintDiagnostics(nil).render("json", buf))
+		assert.Equal(t, "[]", strings.TrimSpace(buf.String()))
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, diagnostics.render("gitlab", buf))
+		var actual []gitlabCodeQualityIssue
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+		require.Len(t, actual, 2)
+		assert.Equal(t, "major", actual[0].Severity)
+		assert.Equal(t, "minor", actual[1].Severity)
+		assert.Equal(t, 1, actual[1].Location.Lines.Begin)
+		assert.NotEqual(t, actual[0].Fingerprint, actual[1].Fingerprint)
+	})
+
+	var output LintOutput
+	assert.Error(t, output.Set("xml"))
+	assert.NoError(t, output.Set("gitlab"))
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"github.com/echocat/kubor/log"
+	"github.com/echocat/kubor/model"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type policyChecker struct {
+	project    *model.Project
+	violations model.PolicyViolations
+}
+
+func newPolicyChecker(project *model.Project) *policyChecker {
+	return &policyChecker{
+		project: project,
+	}
+}
+
+func (instance *policyChecker) check(source string, object *unstructured.Unstructured) error {
+	violations, err := instance.project.Policies.Check(source, object)
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		instance.report(violation)
+	}
+	instance.violations = append(instance.violations, violations...)
+	return nil
+}
+
+func (instance *policyChecker) report(violation model.PolicyViolation) {
+	l := log.
+		WithField("source", violation.Source).
+		WithField("object", violation.Object).
+		WithField("policy", violation.Rule.Name).
+		WithField("severity", violation.Rule.GetSeverity())
+	switch violation.Rule.GetSeverity() {
+	case model.PolicySeverityInfo:
+		l.Info("%v", violation)
+	case model.PolicySeverityWarning:
+		l.Warn("%v", violation)
+	default:
+		l.Error("%v", violation)
+	}
+}
+
+// failOnErrors returns an error if at least one of the reported violations
+// has the severity error.
+func (instance *policyChecker) failOnErrors() error {
+	if errs := instance.violations.Errors(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"github.com/echocat/kubor/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func Test_policyChecker_failOnErrors(t *testing.T) {
+	var rule model.PolicyRule
+	require.NoError(t, rule.Predicate.Set("{{ .kind }}=Deployment"))
+	rule.Name = "onlyDeployments"
+
+	project := model.NewProject()
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "app"},
+	}}
+
+	rule.Severity = model.PolicySeverityWarning
+	project.Policies.Resolved = model.PolicyRules{rule}
+	warnings := newPolicyChecker(&project)
+	require.NoError(t, warnings.check("a.yml", object))
+	assert.NoError(t, warnings.failOnErrors())
+
+	rule.Severity = model.PolicySeverityError
+	project.Policies.Resolved = model.PolicyRules{rule}
+	errors := newPolicyChecker(&project)
+	require.NoError(t, errors.check("a.yml", object))
+	assert.Error(t, errors.failOnErrors())
+}
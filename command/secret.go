@@ -0,0 +1,166 @@
+package command
+
+import (
+	"fmt"
+	"github.com/alecthomas/kingpin"
+	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/secret"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+)
+
+func init() {
+	cmd := &Secret{}
+	cmd.Parent = cmd
+	cmd.Offline = true
+	RegisterInitializable(cmd)
+	common.RegisterCliFactory(cmd)
+}
+
+type Secret struct {
+	Command
+
+	Files      []string
+	Value      string
+	InPlace    bool
+	KeyFile    string
+	NewKeyFile string
+
+	action func(key secret.Key) error
+}
+
+func (instance *Secret) ConfigureCliCommands(context string, hc common.HasCommands, version string) error {
+	if context != "" {
+		return nil
+	}
+	secretCmd := hc.Command("secret", "Manage encrypted values and values files.")
+
+	generateKeyCmd := secretCmd.Command("generateKey", "Generates a new secret key.").
+		Action(instance.executeGenerateKey)
+	generateKeyCmd.Flag("output", "File where to store the key. If empty the key will be printed to stdout.").
+		Short('o').
+		PlaceHolder("<file>").
+		StringVar(&instance.KeyFile)
+
+	encryptCmd := secretCmd.Command("encrypt", "Encrypts every plain value of the given values files in place or the given string.").
+		Action(instance.execute(instance.encrypt))
+	encryptCmd.Arg("file", "Values files to encrypt.").
+		StringsVar(&instance.Files)
+	encryptCmd.Flag("string", "If set this string will be encrypted and printed to stdout.").
+		PlaceHolder("<string>").
+		StringVar(&instance.Value)
+
+	decryptCmd := secretCmd.Command("decrypt", "Decrypts the given values files and prints them to stdout.").
+		Action(instance.execute(instance.decrypt))
+	decryptCmd.Arg("file", "Values files to decrypt.").
+		Required().
+		StringsVar(&instance.Files)
+	decryptCmd.Flag("inPlace", "Writes the decrypted values back to the files instead of printing them.").
+		BoolVar(&instance.InPlace)
+
+	rotateCmd := secretCmd.Command("rotate", "Re-encrypts the given values files using a new key.").
+		Action(instance.execute(instance.rotate))
+	rotateCmd.Arg("file", "Values files to re-encrypt.").
+		Required().
+		StringsVar(&instance.Files)
+	rotateCmd.Flag("newKeyFile", "File of the new key.").
+		Required().
+		PlaceHolder("<file>").
+		StringVar(&instance.NewKeyFile)
+
+	return nil
+}
+
+func (instance *Secret) executeGenerateKey(*kingpin.ParseContext) error {
+	key, err := secret.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if instance.KeyFile == "" {
+		_, err := fmt.Println(key.String())
+		return err
+	}
+	return key.Save(instance.KeyFile)
+}
+
+func (instance *Secret) execute(action func(key secret.Key) error) kingpin.Action {
+	return func(*kingpin.ParseContext) error {
+		instance.action = action
+		return instance.Run()
+	}
+}
+
+func (instance *Secret) RunWithArguments(arguments Arguments) error {
+	key, err := arguments.Project.Secrets.Key(*arguments.Project)
+	if err != nil {
+		return err
+	}
+	return instance.action(key)
+}
+
+func (instance *Secret) encrypt(key secret.Key) error {
+	if instance.Value != "" {
+		encrypted, err := key.Encrypt(instance.Value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(encrypted)
+		return err
+	}
+	if len(instance.Files) == 0 {
+		return fmt.Errorf("neither a file nor --string was provided")
+	}
+	return instance.transformFiles(key.EncryptAll, true)
+}
+
+func (instance *Secret) decrypt(key secret.Key) error {
+	return instance.transformFiles(key.DecryptAll, instance.InPlace)
+}
+
+func (instance *Secret) rotate(key secret.Key) error {
+	newKey, err := secret.LoadKeyFile(instance.NewKeyFile)
+	if err != nil {
+		return err
+	}
+	return instance.transformFiles(func(v interface{}) (interface{}, error) {
+		decrypted, err := key.DecryptAll(v)
+		if err != nil {
+			return nil, err
+		}
+		return newKey.EncryptAll(decrypted)
+	}, true)
+}
+
+func (instance *Secret) transformFiles(transform func(interface{}) (interface{}, error), inPlace bool) error {
+	for _, file := range instance.Files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("cannot read values file '%s': %w", file, err)
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("cannot read values file '%s': %w", file, err)
+		}
+		var content yaml.MapSlice
+		if err := yaml.Unmarshal(b, &content); err != nil {
+			return fmt.Errorf("cannot read values file '%s': %w", file, err)
+		}
+		transformed, err := transform(content)
+		if err != nil {
+			return fmt.Errorf("cannot handle values file '%s': %w", file, err)
+		}
+		out, err := yaml.Marshal(transformed)
+		if err != nil {
+			return fmt.Errorf("cannot handle values file '%s': %w", file, err)
+		}
+		if !inPlace {
+			if _, err := os.Stdout.Write(out); err != nil {
+				return err
+			}
+		} else if err := ioutil.WriteFile(file, out, fi.Mode().Perm()); err != nil {
+			return fmt.Errorf("cannot write values file '%s': %w", file, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_Secret_transformFiles_keepsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported")
+	}
+	root, err := ioutil.TempDir("", "kubor-secret")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	file := filepath.Join(root, "values.yml")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a: b\n"), 0600))
+
+	instance := &Secret{Files: []string{file}}
+	require.NoError(t, instance.transformFiles(func(v interface{}) (interface{}, error) {
+		return v, nil
+	}, true))
+
+	fi, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
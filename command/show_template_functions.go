@@ -3,7 +3,6 @@ package command
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/alecthomas/kingpin"
 	"github.com/echocat/kubor/common"
 	"github.com/echocat/kubor/template/functions"
 	"gopkg.in/yaml.v2"
@@ -86,10 +85,15 @@ func init() {
 	cmd := &ShowTemplateFunctions{
 		Output: ShowTemplateFunctionsOutput("text"),
 	}
+	cmd.Parent = cmd
+	cmd.Offline = true
+	RegisterInitializable(cmd)
 	common.RegisterCliFactory(cmd)
 }
 
 type ShowTemplateFunctions struct {
+	Command
+
 	Output              ShowTemplateFunctionsOutput
 	FulltextSearchTerm  ShowTemplateFulltextTerm
 	FunctionNameFilters []string
@@ -157,14 +161,16 @@ func (instance *ShowTemplateFunctions) createFulltextPredicate() func(functionNa
 	}
 }
 
-func (instance *ShowTemplateFunctions) ExecuteFromCli(_ *kingpin.ParseContext) error {
+func (instance *ShowTemplateFunctions) RunWithArguments(arguments Arguments) error {
 	namePredicate, err := instance.createPredicate()
 	if err != nil {
 		return err
 	}
 	fulltextPredicate := instance.createFulltextPredicate()
 
-	context := instance.newShowTemplateFunctionsContext(namePredicate, fulltextPredicate, functions.CategoriesDefault)
+	// Contains also the plugins of the project (if there is one).
+	categories := arguments.Project.Templating.FunctionCategories()
+	context := instance.newShowTemplateFunctionsContext(namePredicate, fulltextPredicate, categories)
 
 	switch instance.Output {
 	case ShowTemplateFunctionsOutput("yaml"):
@@ -0,0 +1,256 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/kubernetes"
+	"github.com/echocat/kubor/model"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	testSnapshotNameIllegalRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func init() {
+	cmd := &Test{}
+	cmd.Parent = cmd
+	cmd.Offline = true
+	RegisterInitializable(cmd)
+	common.RegisterCliFactory(cmd)
+}
+
+type Test struct {
+	Command
+
+	Files           []string
+	JunitReport     string
+	UpdateSnapshots bool
+}
+
+func (instance *Test) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
+	if context != "" {
+		return nil
+	}
+
+	cmd := hc.Command("test", "Renders the templates with the values of the test files (templating.testFilePattern) and checks the rendered objects without connecting to any cluster.").
+		Action(instance.ExecuteFromCli)
+
+	cmd.Flag("junitReport", "If set a JUnit XML report is written to this file.").
+		PlaceHolder("<file>").
+		Envar("KUBOR_TEST_JUNIT_REPORT").
+		StringVar(&instance.JunitReport)
+	cmd.Flag("updateSnapshots", "Writes the snapshots of snapshot assertions instead of comparing them.").
+		Envar("KUBOR_TEST_UPDATE_SNAPSHOTS").
+		Default(fmt.Sprint(instance.UpdateSnapshots)).
+		BoolVar(&instance.UpdateSnapshots)
+	cmd.Arg("file", "Test files to run. If empty all files of templating.testFilePattern are used.").
+		StringsVar(&instance.Files)
+
+	return nil
+}
+
+func (instance *Test) RunWithArguments(arguments Arguments) error {
+	files := instance.Files
+	if len(files) == 0 {
+		var err error
+		if files, err = arguments.Project.Templating.TestFiles(arguments.Project); err != nil {
+			return err
+		}
+	}
+
+	var results testResults
+	snapshotOwners := map[string]string{}
+	for _, file := range files {
+		results = append(results, instance.runFile(arguments, file, snapshotOwners)...)
+	}
+
+	if err := results.renderText(os.Stdout); err != nil {
+		return err
+	}
+	if instance.JunitReport != "" {
+		if err := results.writeJunit(instance.JunitReport); err != nil {
+			return err
+		}
+	}
+	if failed := results.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d test(s) failed", len(failed), len(results))
+	}
+	return nil
+}
+
+// runFile runs the tests of the given file. snapshotOwners contains for every
+// snapshot file the test which uses it to detect tests which would share
+// one (like "A b" and "a-b").
+func (instance *Test) runFile(arguments Arguments, file string, snapshotOwners map[string]string) testResults {
+	tf, err := loadTestFile(file)
+	if err != nil {
+		return testResults{{File: file, Name: "load", Error: err}}
+	}
+	result := make(testResults, len(tf.Tests))
+	for i, tc := range tf.Tests {
+		start := time.Now()
+		result[i] = instance.runTest(arguments, file, fmt.Sprintf("tests[%d] (%s) of %s", i, tc.Name, file), tc, snapshotOwners)
+		result[i].Duration = time.Since(start)
+	}
+	return result
+}
+
+func (instance *Test) runTest(arguments Arguments, file string, id string, tc testCase, snapshotOwners map[string]string) testResult {
+	result := testResult{File: file, Name: tc.Name}
+
+	env := tc.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	context := tc.Context
+	if context == "" {
+		context = arguments.Project.Context
+	}
+	p, err := instance.ProjectFactory.
+		WithValues(tc.valueAssignments(file)).
+		WithEnv(env).
+		Create(context)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	p.Templating = p.Templating.WithObjectLookup(kubernetes.NewObjectLookup(arguments.DynamicClient))
+
+	objects, err := renderTestObjects(p)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	snapshots := 0
+	for i, assertion := range tc.Asserts {
+		failure, err := assertion.Check(objects, func(selected []testObject) (string, error) {
+			snapshots++
+			snapshot := testSnapshotFile(file, tc.Name, snapshots)
+			if owner, ok := snapshotOwners[snapshot]; ok && owner != id {
+				return "", fmt.Errorf("snapshot %s is already used by %s; rename one of the tests", snapshot, owner)
+			}
+			snapshotOwners[snapshot] = id
+			return instance.checkSnapshot(snapshot, selected, &result)
+		})
+		if err != nil {
+			result.Error = fmt.Errorf("asserts[%d] (%v): %w", i, assertion, err)
+			return result
+		}
+		if failure != "" {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s:%d: asserts[%d] (%v): %s", file, assertion.line, i, assertion, failure))
+		}
+	}
+	return result
+}
+
+func renderTestObjects(p *model.Project) ([]testObject, error) {
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, err
+	}
+	var result []testObject
+	oh, err := model.NewObjectHandler(func(source string, _ runtime.Object, object *unstructured.Unstructured) error {
+		template := source
+		if i := strings.LastIndexByte(template, '#'); i >= 0 {
+			template = template[:i]
+		}
+		if abs, err := filepath.Abs(template); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				template = filepath.ToSlash(rel)
+			}
+		}
+		result = append(result, testObject{
+			template: template,
+			object:   object,
+		})
+		return nil
+	}, p)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := p.RenderedTemplatesProvider()
+	if err != nil {
+		return nil, err
+	}
+	if err := oh.Handle(cp); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// testSnapshotFile returns for the n-th snapshot assertion of the given test
+// <dir of test file>/__snapshots__/<base name of test file>/<test>[-n].yaml.
+func testSnapshotFile(file string, test string, n int) string {
+	name := strings.Trim(testSnapshotNameIllegalRegexp.ReplaceAllString(strings.ToLower(test), "-"), "-")
+	if n > 1 {
+		name += fmt.Sprintf("-%d", n)
+	}
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return filepath.Join(filepath.Dir(file), "__snapshots__", base, name+".yaml")
+}
+
+func (instance *Test) checkSnapshot(file string, objects []testObject, result *testResult) (string, error) {
+	buf := new(bytes.Buffer)
+	for i, object := range objects {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		_, _ = fmt.Fprintf(buf, "# Source: %s\n", object.template)
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(object.object.Object); err != nil {
+			return "", err
+		}
+		if err := encoder.Close(); err != nil {
+			return "", err
+		}
+	}
+	actual := buf.Bytes()
+
+	expected, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) && !instance.UpdateSnapshots {
+		return fmt.Sprintf("snapshot %s does not exist; run kubor test --updateSnapshots to create it", file), nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("cannot read snapshot %s: %w", file, err)
+	} else if err == nil && bytes.Equal(expected, actual) {
+		return "", nil
+	} else if instance.UpdateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return "", fmt.Errorf("cannot write snapshot %s: %w", file, err)
+		}
+		if err := ioutil.WriteFile(file, actual, 0644); err != nil {
+			return "", fmt.Errorf("cannot write snapshot %s: %w", file, err)
+		}
+		result.UpdatedSnapshots = append(result.UpdatedSnapshots, file)
+		return "", nil
+	}
+	return fmt.Sprintf("snapshot %s does not match: %s", file, firstDifference(string(expected), string(actual))), nil
+}
+
+func firstDifference(expected, actual string) string {
+	el, al := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(el) || i < len(al); i++ {
+		var e, a string
+		if i < len(el) {
+			e = el[i]
+		}
+		if i < len(al) {
+			a = al[i]
+		}
+		if e != a || i >= len(el) || i >= len(al) {
+			return fmt.Sprintf("line %d is %q but expected %q", i+1, a, e)
+		}
+	}
+	return "content differs"
+}
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type testResult struct {
+	File             string
+	Name             string
+	Duration         time.Duration
+	Failures         []string
+	Error            error
+	UpdatedSnapshots []string
+}
+
+func (instance testResult) Failed() bool {
+	return instance.Error != nil || len(instance.Failures) > 0
+}
+
+type testResults []testResult
+
+func (instance testResults) Failed() testResults {
+	var result testResults
+	for _, candidate := range instance {
+		if candidate.Failed() {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func (instance testResults) renderText(target io.Writer) error {
+	for _, result := range instance {
+		status := "PASS"
+		if result.Failed() {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(target, "--- %s: %s: %s (%.2fs)\n", status, result.File, result.Name, result.Duration.Seconds()); err != nil {
+			return err
+		}
+		lines := result.Failures
+		if result.Error != nil {
+			lines = append(lines, "error: "+result.Error.Error())
+		}
+		for _, file := range result.UpdatedSnapshots {
+			lines = append(lines, "updated snapshot "+file)
+		}
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(target, "    "+strings.ReplaceAll(line, "\n", "\n    ")); err != nil {
+				return err
+			}
+		}
+	}
+	status := "PASS"
+	if failed := instance.Failed(); len(failed) > 0 {
+		status = "FAIL"
+	}
+	_, err := fmt.Fprintf(target, "%s: %d test(s), %d failed\n", status, len(instance), len(instance.Failed()))
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func junitTimeOf(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// writeJunit writes the results as JUnit XML report with one testsuite per
+// test file.
+func (instance testResults) writeJunit(file string) error {
+	var report junitTestSuites
+	indexes := map[string]int{}
+	durations := map[string]time.Duration{}
+	for _, result := range instance {
+		i, ok := indexes[result.File]
+		if !ok {
+			i = len(report.Suites)
+			indexes[result.File] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.File})
+		}
+		suite := &report.Suites[i]
+		tc := junitTestCase{
+			Name:      result.Name,
+			ClassName: result.File,
+			Time:      junitTimeOf(result.Duration),
+		}
+		if result.Error != nil {
+			tc.Error = &junitMessage{Message: result.Error.Error(), Content: result.Error.Error()}
+			suite.Errors++
+		} else if len(result.Failures) > 0 {
+			tc.Failure = &junitMessage{Message: result.Failures[0], Content: strings.Join(result.Failures, "\n")}
+			suite.Failures++
+		}
+		suite.Tests++
+		durations[result.File] += result.Duration
+		suite.Time = junitTimeOf(durations[result.File])
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("cannot write JUnit report %s: %w", file, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return fmt.Errorf("cannot write JUnit report %s: %w", file, err)
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("cannot write JUnit report %s: %w", file, err)
+	}
+	if _, err := io.WriteString(f, "\n"); err != nil {
+		return fmt.Errorf("cannot write JUnit report %s: %w", file, err)
+	}
+	return nil
+}
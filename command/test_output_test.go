@@ -0,0 +1,72 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_testResults_renderText(t *testing.T) {
+	results := testResults{
+		{File: "a.yml", Name: "ok", Duration: 1500 * time.Millisecond, UpdatedSnapshots: []string{"__snapshots__/a/ok.yaml"}},
+		{File: "a.yml", Name: "failing", Failures: []string{"a.yml:3: first\nsecond", "a.yml:5: third"}},
+		{File: "b.yml", Name: "broken", Error: errors.New("cannot render")},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, results.renderText(buf))
+	assert.Equal(t, `--- PASS: a.yml: ok (1.50s)
+    updated snapshot __snapshots__/a/ok.yaml
+--- FAIL: a.yml: failing (0.00s)
+    a.yml:3: first
+    second
+    a.yml:5: third
+--- FAIL: b.yml: broken (0.00s)
+    error: cannot render
+FAIL: 3 test(s), 2 failed
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, results[:1].renderText(buf))
+	assert.Contains(t, buf.String(), "PASS: 1 test(s), 0 failed\n")
+}
+
+func Test_testResults_writeJunit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-test")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "junit.xml")
+
+	require.NoError(t, testResults{
+		{File: "a.yml", Name: "ok", Duration: 1500 * time.Millisecond},
+		{File: "b.yml", Name: "broken", Error: errors.New("cannot render <x>")},
+		{File: "a.yml", Name: "failing", Duration: 250 * time.Millisecond, Failures: []string{"first", "second"}},
+	}.writeJunit(file))
+
+	actual, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a.yml" tests="2" failures="1" errors="0" time="1.750">
+    <testcase name="ok" classname="a.yml" time="1.500"></testcase>
+    <testcase name="failing" classname="a.yml" time="0.250">
+      <failure message="first">first&#xA;second</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="b.yml" tests="1" failures="0" errors="1" time="0.000">
+    <testcase name="broken" classname="b.yml" time="0.000">
+      <error message="cannot render &lt;x&gt;">cannot render &lt;x&gt;</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(actual))
+
+	assert.Error(t, testResults{}.writeJunit(filepath.Join(dir, "unknown", "junit.xml")))
+}
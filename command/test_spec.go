@@ -0,0 +1,336 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/kubor/model"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// testFile is the content of a file located by templating.testFilePattern.
+type testFile struct {
+	Tests []testCase `yaml:"tests"`
+}
+
+func loadTestFile(file string) (testFile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return testFile{}, fmt.Errorf("cannot read test file '%s': %w", file, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	var result testFile
+	if err := decoder.Decode(&result); err != nil {
+		return testFile{}, fmt.Errorf("cannot read test file '%s': %w", file, err)
+	}
+	if err := result.Validate(); err != nil {
+		return testFile{}, fmt.Errorf("cannot read test file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+func (instance testFile) Validate() error {
+	seen := map[string]bool{}
+	for i, tc := range instance.Tests {
+		if tc.Name == "" {
+			return fmt.Errorf("tests[%d].name should not be empty", i)
+		}
+		if seen[tc.Name] {
+			return fmt.Errorf("test %s is defined more than once", tc.Name)
+		}
+		seen[tc.Name] = true
+		if len(tc.Asserts) == 0 {
+			return fmt.Errorf("test %s: asserts should not be empty", tc.Name)
+		}
+		for j, assertion := range tc.Asserts {
+			if err := assertion.Validate(); err != nil {
+				return fmt.Errorf("test %s: asserts[%d]: %w", tc.Name, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// testCase renders the project with the given Values, Context and Env (which
+// replaces the environment of the process) and checks the rendered objects.
+type testCase struct {
+	Name    string                 `yaml:"name"`
+	Context string                 `yaml:"context,omitempty"`
+	Values  map[string]interface{} `yaml:"values,omitempty"`
+	Env     map[string]string      `yaml:"env,omitempty"`
+	Asserts []testAssertion        `yaml:"asserts"`
+}
+
+func (instance testCase) valueAssignments(file string) model.ValueAssignments {
+	var result model.ValueAssignments
+	for key, value := range instance.Values {
+		result = append(result, model.ValueAssignment{
+			Path:   []string{key},
+			Value:  value,
+			Origin: model.ValueOrigin{Kind: model.ValueOriginTest, Source: file},
+		})
+	}
+	return result
+}
+
+// testSelector selects rendered objects; empty properties match everything.
+// Template is a glob pattern which is matched against the file name of the
+// template and its path relative to the project root.
+type testSelector struct {
+	Template   string `yaml:"template,omitempty"`
+	ApiVersion string `yaml:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty"`
+	Name       string `yaml:"name,omitempty"`
+}
+
+func (instance testSelector) Matches(object testObject) bool {
+	o := object.object
+	if instance.Template != "" {
+		base, _ := filepath.Match(instance.Template, filepath.Base(object.template))
+		relative, _ := filepath.Match(instance.Template, object.template)
+		if !base && !relative {
+			return false
+		}
+	}
+	return (instance.ApiVersion == "" || instance.ApiVersion == o.GetAPIVersion()) &&
+		(instance.Kind == "" || instance.Kind == o.GetKind()) &&
+		(instance.Namespace == "" || instance.Namespace == o.GetNamespace()) &&
+		(instance.Name == "" || instance.Name == o.GetName())
+}
+
+func (instance testSelector) Select(objects []testObject) []testObject {
+	var result []testObject
+	for _, object := range objects {
+		if instance.Matches(object) {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+func (instance testSelector) String() string {
+	var parts []string
+	for _, p := range []struct{ name, value string }{
+		{"template", instance.Template},
+		{"apiVersion", instance.ApiVersion},
+		{"kind", instance.Kind},
+		{"namespace", instance.Namespace},
+		{"name", instance.Name},
+	} {
+		if p.value != "" {
+			parts = append(parts, p.name+"="+p.value)
+		}
+	}
+	if len(parts) == 0 {
+		return "all objects"
+	}
+	return "objects with " + strings.Join(parts, ",")
+}
+
+// testAssertion contains exactly one of Exists, Count, Equals, Matches or
+// Snapshot which is checked against the objects selected by Select. Equals and
+// Matches require a Path (JSONPath like .spec.replicas) and have to be true for
+// every selected object.
+type testAssertion struct {
+	Select   testSelector `yaml:"select,omitempty"`
+	Exists   *bool        `yaml:"exists,omitempty"`
+	Count    *int         `yaml:"count,omitempty"`
+	Path     string       `yaml:"path,omitempty"`
+	Equals   interface{}  `yaml:"equals,omitempty"`
+	Matches  string       `yaml:"matches,omitempty"`
+	Snapshot bool         `yaml:"snapshot,omitempty"`
+
+	// hasEquals is required because equals could also be null.
+	hasEquals bool
+	line      int
+}
+
+func (instance *testAssertion) UnmarshalYAML(node *yaml.Node) error {
+	type plain testAssertion
+	if err := node.Decode((*plain)(instance)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "equals" {
+			instance.hasEquals = true
+		}
+	}
+	instance.line = node.Line
+	return nil
+}
+
+func (instance testAssertion) Validate() error {
+	kinds := 0
+	for _, set := range []bool{instance.Exists != nil, instance.Count != nil, instance.hasEquals, instance.Matches != "", instance.Snapshot} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of exists, count, equals, matches or snapshot is required")
+	}
+	if instance.hasEquals || instance.Matches != "" {
+		if instance.Path == "" {
+			return fmt.Errorf("path is required for equals and matches")
+		}
+		if _, err := newTestJsonPath(instance.Path); err != nil {
+			return err
+		}
+	} else if instance.Path != "" {
+		return fmt.Errorf("path is only supported for equals and matches")
+	}
+	if instance.Matches != "" {
+		if _, err := regexp.Compile(instance.Matches); err != nil {
+			return fmt.Errorf("illegal matches '%s': %w", instance.Matches, err)
+		}
+	}
+	return nil
+}
+
+func (instance testAssertion) String() string {
+	switch {
+	case instance.Exists != nil:
+		return "exists"
+	case instance.Count != nil:
+		return "count"
+	case instance.hasEquals:
+		return "equals " + instance.Path
+	case instance.Matches != "":
+		return "matches " + instance.Path
+	default:
+		return "snapshot"
+	}
+}
+
+// Check returns a description of the failure or an empty string if the
+// assertion holds.
+func (instance testAssertion) Check(objects []testObject, snapshot func([]testObject) (string, error)) (string, error) {
+	selected := instance.Select.Select(objects)
+	switch {
+	case instance.Exists != nil:
+		if *instance.Exists && len(selected) == 0 {
+			return fmt.Sprintf("expected %s to exist but there are none", instance.Select), nil
+		}
+		if !*instance.Exists && len(selected) > 0 {
+			return fmt.Sprintf("expected no %s but there are %d", instance.Select, len(selected)), nil
+		}
+	case instance.Count != nil:
+		if len(selected) != *instance.Count {
+			return fmt.Sprintf("expected %d of %s but there are %d", *instance.Count, instance.Select, len(selected)), nil
+		}
+	case instance.Snapshot:
+		return snapshot(selected)
+	default:
+		if len(selected) == 0 {
+			return fmt.Sprintf("expected %s to exist but there are none", instance.Select), nil
+		}
+		for _, object := range selected {
+			if failure, err := instance.checkPath(object); failure != "" || err != nil {
+				return failure, err
+			}
+		}
+	}
+	return "", nil
+}
+
+func (instance testAssertion) checkPath(object testObject) (string, error) {
+	jp, err := newTestJsonPath(instance.Path)
+	if err != nil {
+		return "", err
+	}
+	results, err := jp.FindResults(object.object.Object)
+	if err != nil {
+		return fmt.Sprintf("%s of %s: %v", instance.Path, object, err), nil
+	}
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+
+	if instance.Matches != "" {
+		pattern := regexp.MustCompile(instance.Matches)
+		for _, value := range values {
+			if !pattern.MatchString(fmt.Sprint(value)) {
+				return fmt.Sprintf("expected %s of %s to match '%s' but got %v", instance.Path, object, instance.Matches, value), nil
+			}
+		}
+		return "", nil
+	}
+
+	var actual interface{} = values
+	if len(values) == 1 {
+		actual = values[0]
+	}
+	expected, err := plainJsonOf(instance.Equals)
+	if err != nil {
+		return "", err
+	}
+	plainActual, err := plainJsonOf(actual)
+	if err != nil {
+		return "", err
+	}
+	if !reflect.DeepEqual(expected, plainActual) {
+		return fmt.Sprintf("expected %s of %s to be %s but got %s", instance.Path, object, mustJsonOf(expected), mustJsonOf(plainActual)), nil
+	}
+	return "", nil
+}
+
+func newTestJsonPath(path string) (*jsonpath.JSONPath, error) {
+	expression := path
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	result := jsonpath.New("path")
+	if err := result.Parse(expression); err != nil {
+		return nil, fmt.Errorf("illegal path '%s': %w", path, err)
+	}
+	return result, nil
+}
+
+// plainJsonOf converts the given value into its JSON representation using only
+// maps, slices, strings, float64, bool and nil to be comparable.
+func plainJsonOf(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func mustJsonOf(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// testObject is a rendered object together with the template it comes from.
+type testObject struct {
+	template string
+	object   *unstructured.Unstructured
+}
+
+func (instance testObject) String() string {
+	name := instance.object.GetName()
+	if ns := instance.object.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+	return instance.object.GetKind() + " " + name
+}
@@ -0,0 +1,115 @@
+package command
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_testAssertion_Check(t *testing.T) {
+	objects := []testObject{{
+		template: "kubernetes/templates/app.yml",
+		object: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app", "namespace": "a", "annotations": nil},
+			"spec":       map[string]interface{}{"replicas": int64(2), "ports": []interface{}{int64(80), int64(443)}},
+		}},
+	}, {
+		template: "kubernetes/templates/app.yml",
+		object: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "app", "namespace": "a"},
+		}},
+	}, {
+		template: "kubernetes/templates/config.yml",
+		object: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config", "namespace": "b"},
+		}},
+	}}
+
+	cases := []struct {
+		name      string
+		assertion string
+		expected  string
+	}{
+		{"existsByTemplateName", "select: {template: app.yml}\ncount: 2", ""},
+		{"existsByTemplatePath", "select: {template: kubernetes/*/config.yml}\nexists: true", ""},
+		{"selectsByAllProperties", "select: {apiVersion: v1, kind: Service, namespace: a, name: app}\ncount: 1", ""},
+		{"existsFails", "select: {kind: Secret}\nexists: true", "expected objects with kind=Secret to exist but there are none"},
+		{"notExistsFails", "select: {namespace: b}\nexists: false", "expected no objects with namespace=b but there are 1"},
+		{"countFails", "count: 2", "expected 2 of all objects but there are 3"},
+		{"equals", "select: {kind: Deployment}\npath: .spec.replicas\nequals: 2", ""},
+		{"equalsList", "select: {kind: Deployment}\npath: .spec.ports[*]\nequals: [80, 443]", ""},
+		{"equalsNull", "select: {kind: Deployment}\npath: .metadata.annotations\nequals: null", ""},
+		{"equalsFails", "select: {kind: Deployment}\npath: .spec.replicas\nequals: 3", "expected .spec.replicas of Deployment a/app to be 3 but got 2"},
+		{"equalsWithoutObjects", "select: {kind: Secret}\npath: .spec\nequals: 1", "expected objects with kind=Secret to exist but there are none"},
+		{"matches", "path: .metadata.name\nmatches: ^(app|config)$", ""},
+		{"matchesFails", "path: .metadata.namespace\nmatches: ^a$", "expected .metadata.namespace of ConfigMap b/config to match '^a$' but got b"},
+		{"missingPath", "select: {kind: Service}\npath: .spec.replicas\nequals: 1", "spec.replicas of Service a/app: spec is not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var assertion testAssertion
+			require.NoError(t, yaml.Unmarshal([]byte(c.assertion), &assertion))
+			require.NoError(t, assertion.Validate())
+			actual, err := assertion.Check(objects, nil)
+			require.NoError(t, err)
+			if c.expected == "" {
+				assert.Empty(t, actual)
+			} else {
+				assert.Contains(t, actual, c.expected)
+			}
+		})
+	}
+}
+
+func Test_loadTestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-test")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name    string
+		content string
+		error   string
+	}{
+		{"valid", "tests:\n- name: a\n  asserts:\n  - count: 1\n  - path: .a\n    equals: null\n", ""},
+		{"unknownField", "tests:\n- name: a\n  assert: []\n", "field assert not found"},
+		{"withoutName", "tests:\n- asserts:\n  - count: 1\n", "tests[0].name should not be empty"},
+		{"duplicateName", "tests:\n- name: a\n  asserts: [{count: 1}]\n- name: a\n  asserts: [{count: 1}]\n", "test a is defined more than once"},
+		{"withoutAsserts", "tests:\n- name: a\n", "test a: asserts should not be empty"},
+		{"twoKinds", "tests:\n- name: a\n  asserts: [{count: 1, snapshot: true}]\n", "exactly one of exists, count, equals, matches or snapshot is required"},
+		{"withoutPath", "tests:\n- name: a\n  asserts: [{equals: 1}]\n", "path is required for equals and matches"},
+		{"unexpectedPath", "tests:\n- name: a\n  asserts: [{count: 1, path: .a}]\n", "path is only supported for equals and matches"},
+		{"illegalPath", "tests:\n- name: a\n  asserts: [{path: '.a[', equals: 1}]\n", "illegal path '.a['"},
+		{"illegalMatches", "tests:\n- name: a\n  asserts: [{path: .a, matches: '('}]\n", "illegal matches '('"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(dir, c.name+".yml")
+			require.NoError(t, ioutil.WriteFile(file, []byte(c.content), 0644))
+			_, err := loadTestFile(file)
+			if c.error == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.error)
+			}
+		})
+	}
+}
+
+func Test_testSnapshotFile(t *testing.T) {
+	assert.Equal(t, filepath.Join("tests", "__snapshots__", "app", "with-env.yaml"), testSnapshotFile(filepath.Join("tests", "app.yml"), "With Env!", 1))
+	assert.Equal(t, filepath.Join("tests", "__snapshots__", "app", "with-env-2.yaml"), testSnapshotFile(filepath.Join("tests", "app.yml"), "With Env!", 2))
+}
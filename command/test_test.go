@@ -0,0 +1,149 @@
+package command
+
+import (
+	"github.com/echocat/kubor/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Test_RunWithArguments(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-test")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		".kubor.yml": "groupId: a\nartifactId: app\nvalues:\n- replicas: 1\n",
+		"kubernetes/templates/deployment.yml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-{{ envOr "STAGE" "dev" }}
+  namespace: a
+spec:
+  replicas: {{ .Values.replicas }}
+`,
+		"kubernetes/tests/app.yml": `tests:
+- name: defaults
+  asserts:
+  - select: {template: deployment.yml, kind: Deployment}
+    exists: true
+  - select: {kind: Service}
+    count: 0
+  - path: .spec.replicas
+    equals: 1
+  - select: {template: kubernetes/templates/*.yml}
+    path: .metadata.name
+    matches: ^app-dev$
+- name: With Env
+  values: {replicas: 3}
+  env: {STAGE: prod}
+  asserts:
+  - snapshot: true
+`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	factory := model.NewProjectFactory().ForSource(filepath.Join(root, ".kubor.yml"))
+	project, err := factory.Create("")
+	require.NoError(t, err)
+	snapshot := filepath.Join(root, "kubernetes", "tests", "__snapshots__", "app", "with-env.yaml")
+	report := filepath.Join(root, "junit.xml")
+	run := func(updateSnapshots bool) error {
+		instance := &Test{
+			Command:         Command{ProjectFactory: factory},
+			JunitReport:     report,
+			UpdateSnapshots: updateSnapshots,
+		}
+		return instance.RunWithArguments(Arguments{Project: project})
+	}
+
+	err = run(false)
+	assert.EqualError(t, err, "1 of 2 test(s) failed")
+	junit, err := ioutil.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(junit), `<testsuite name="`+filepath.Join(root, "kubernetes", "tests", "app.yml")+`" tests="2" failures="1" errors="0"`)
+	assert.Contains(t, string(junit), "snapshot "+snapshot+" does not exist; run kubor test --updateSnapshots to create it")
+	_, err = os.Stat(snapshot)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, run(true))
+	content, err := ioutil.ReadFile(snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, `# Source: kubernetes/templates/deployment.yml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-prod
+  namespace: a
+spec:
+  replicas: 3
+`, string(content))
+
+	require.NoError(t, run(false))
+
+	require.NoError(t, ioutil.WriteFile(snapshot, []byte(strings.Replace(string(content), "replicas: 3", "replicas: 2", 1)), 0644))
+	err = run(false)
+	assert.EqualError(t, err, "1 of 2 test(s) failed")
+	junit, err = ioutil.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(junit), `does not match: line 8 is &#34;  replicas: 3&#34; but expected &#34;  replicas: 2&#34;`)
+
+	require.NoError(t, run(true))
+	content, err = ioutil.ReadFile(snapshot)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "replicas: 3")
+}
+
+func Test_Test_RunWithArguments_snapshotCollision(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-test")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		".kubor.yml": "groupId: a\nartifactId: app\n",
+		"kubernetes/templates/deployment.yml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: a
+`,
+		"kubernetes/tests/app.yml": `tests:
+- name: A b
+  asserts:
+  - snapshot: true
+- name: a-b
+  asserts:
+  - snapshot: true
+`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	factory := model.NewProjectFactory().ForSource(filepath.Join(root, ".kubor.yml"))
+	project, err := factory.Create("")
+	require.NoError(t, err)
+	report := filepath.Join(root, "junit.xml")
+	instance := &Test{
+		Command:         Command{ProjectFactory: factory},
+		JunitReport:     report,
+		UpdateSnapshots: true,
+	}
+
+	err = instance.RunWithArguments(Arguments{Project: project})
+	assert.EqualError(t, err, "1 of 2 test(s) failed")
+	junit, err := ioutil.ReadFile(report)
+	require.NoError(t, err)
+	file := filepath.Join(root, "kubernetes", "tests", "app.yml")
+	assert.Contains(t, string(junit), "snapshot "+filepath.Join(root, "kubernetes", "tests", "__snapshots__", "app", "a-b.yaml")+" is already used by tests[0] (A b) of "+file+"; rename one of the tests")
+}
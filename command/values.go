@@ -1,10 +1,15 @@
 package command
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/alecthomas/kingpin"
 	"github.com/echocat/kubor/common"
+	"github.com/echocat/kubor/model"
 	"gopkg.in/yaml.v2"
+	"io"
 	"os"
+	"strconv"
 )
 
 func init() {
@@ -16,20 +21,87 @@ func init() {
 
 type Values struct {
 	Command
+
+	Explain bool
 }
 
 func (instance *Values) ConfigureCliCommands(context string, hc common.HasCommands, version string) error {
 	if context != "" {
 		return nil
 	}
-	hc.Command("values", "Get the aggregated values used by the project based on the given parameters.").
+	cmd := hc.Command("values", "Get the aggregated values used by the project based on the given parameters.").
 		Action(func(context *kingpin.ParseContext) error {
 			return instance.Run()
 		})
+	cmd.Flag("explain", "Print every value together with its origin and the conditional values which were skipped.").
+		Envar("KUBOR_VALUES_EXPLAIN").
+		BoolVar(&instance.Explain)
 	return nil
 }
 
 func (instance *Values) RunWithArguments(arguments Arguments) error {
+	if instance.Explain {
+		return instance.explain(arguments.Project, os.Stdout)
+	}
 	enc := yaml.NewEncoder(os.Stdout)
 	return enc.Encode(arguments.Project.Values)
 }
+
+func (instance *Values) explain(project *model.Project, to io.Writer) error {
+	explanation := project.ValuesExplanation
+	for _, plainPath := range explanation.Origins.Paths() {
+		path, err := model.ParseValuePath(plainPath)
+		if err != nil {
+			return err
+		}
+		origin := explanation.Origins[plainPath]
+		switch origin.Kind {
+		case model.ValueOriginFile:
+			origin.Line = yamlLineOf(origin.Source, path...)
+		case model.ValueOriginDefault, model.ValueOriginConditional:
+			origin.Line = yamlLineOf(origin.Source, append([]string{"values", strconv.Itoa(origin.Index)}, path...)...)
+		}
+		value, err := json.Marshal(valueAt(project.Values, path))
+		if err != nil {
+			return fmt.Errorf("cannot format value %s: %w", plainPath, err)
+		}
+		if _, err := fmt.Fprintf(to, "%s = %s\n    from %v\n", plainPath, string(value), origin); err != nil {
+			return err
+		}
+	}
+	if len(explanation.Skipped) > 0 {
+		if _, err := fmt.Fprintln(to, "\nSkipped conditional values:"); err != nil {
+			return err
+		}
+	}
+	for _, skipped := range explanation.Skipped {
+		location := skipped.Source
+		if line := yamlLineOf(skipped.Source, "values", strconv.Itoa(skipped.Index)); line > 0 {
+			location = fmt.Sprintf("%s:%d", location, line)
+		}
+		if _, err := fmt.Fprintf(to, "values[%d] on %s (%s)\n", skipped.Index, skipped.Predicate, location); err != nil {
+			return err
+		}
+		for _, reason := range skipped.Reasons {
+			if _, err := fmt.Fprintf(to, "    %s\n", reason); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func valueAt(values map[string]interface{}, path []string) interface{} {
+	var current interface{} = values
+	for _, key := range path {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			current = m[key]
+		case model.Values:
+			current = m[key]
+		default:
+			return nil
+		}
+	}
+	return current
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"fmt"
+	"github.com/alecthomas/kingpin"
+	"github.com/echocat/kubor/common"
+	"os"
+	"text/tabwriter"
+)
+
+func init() {
+	cmd := &Vendor{}
+	cmd.Parent = cmd
+	cmd.Offline = true
+	RegisterInitializable(cmd)
+	common.RegisterCliFactory(cmd)
+}
+
+type Vendor struct {
+	Command
+
+	Update bool
+}
+
+func (instance *Vendor) ConfigureCliCommands(context string, hc common.HasCommands, _ string) error {
+	if context != "" {
+		return nil
+	}
+	cmd := hc.Command("vendor", "Fetches all libraries of the project into .kubor/vendor and writes the lock file .kubor/libraries.lock.").
+		Action(func(context *kingpin.ParseContext) error {
+			return instance.Run()
+		})
+	cmd.Flag("update", "Fetch the configured versions of all libraries again instead of the commits of the lock file.").
+		Envar("KUBOR_VENDOR_UPDATE").
+		BoolVar(&instance.Update)
+	return nil
+}
+
+func (instance *Vendor) RunWithArguments(arguments Arguments) error {
+	p := arguments.Project
+	lock, err := p.Libraries.Vendor(p.Root, instance.Update)
+	if err != nil {
+		return err
+	}
+	if len(lock.Libraries) == 0 {
+		_, err := fmt.Fprintln(os.Stdout, "Project does not define any libraries.")
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "LIBRARY\tSOURCE\tVERSION\tCHECKSUM")
+	for _, l := range lock.Libraries {
+		source, version := l.Path, ""
+		if l.Git != "" {
+			source, version = l.Git, l.Commit
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.Name, source, version, l.Checksum)
+	}
+	return w.Flush()
+}
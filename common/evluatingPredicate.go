@@ -37,6 +37,37 @@ func (instance EvaluatingPredicate) Matches(data interface{}) (bool, error) {
 	return true, nil
 }
 
+// Explain returns the reasons why this predicate does not match the given
+// data. If it matches the result is empty.
+func (instance EvaluatingPredicate) Explain(data interface{}) ([]string, error) {
+	var result []string
+	if len(instance.Includes) > 0 {
+		var mismatches []string
+		matched := false
+		for _, matcher := range instance.Includes {
+			if val, err := matcher.Value(data); err != nil {
+				return nil, err
+			} else if matcher.check.MatchString(val) {
+				matched = true
+				break
+			} else {
+				mismatches = append(mismatches, fmt.Sprintf("%s evaluated to '%s' which does not match %v", matcher.valueTemplateSource, val, matcher.check))
+			}
+		}
+		if !matched {
+			result = append(result, mismatches...)
+		}
+	}
+	for _, matcher := range instance.Excludes {
+		if val, err := matcher.Value(data); err != nil {
+			return nil, err
+		} else if matcher.check.MatchString(val) {
+			result = append(result, fmt.Sprintf("%s evaluated to '%s' which matches excluded %v", matcher.valueTemplateSource, val, matcher.check))
+		}
+	}
+	return result, nil
+}
+
 func (instance *EvaluatingPredicate) UnmarshalJSON(b []byte) error {
 	var plains []string
 	if err := json.Unmarshal(b, &plains); err != nil {
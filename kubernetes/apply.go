@@ -666,9 +666,7 @@ func (instance *StagedApplySet) Add(stage model.Stage, apply Apply) {
 func (instance StagedApplySet) Execute(scope string, dry model.DryRunOn, wu *model.WaitUntil, rollbackIfNeeded bool) (relevantDuration time.Duration, err error) {
 	defer func() {
 		if err != nil && rollbackIfNeeded {
-			for _, action := range instance {
-				action.Rollback(scope)
-			}
+			instance.Rollback(scope)
 		}
 	}()
 	for stage := range instance {
@@ -690,6 +688,21 @@ func (instance StagedApplySet) Execute(scope string, dry model.DryRunOn, wu *mod
 	return
 }
 
+// Rollback rolls back every already applied object of every stage.
+func (instance StagedApplySet) Rollback(scope string) {
+	for _, set := range instance {
+		set.Rollback(scope)
+	}
+}
+
+// Size returns the amount of objects of all stages.
+func (instance StagedApplySet) Size() (result int) {
+	for _, set := range instance {
+		result += len(set)
+	}
+	return
+}
+
 func (instance StagedApplySet) ExecuteStage(scope string, stage model.Stage, dryRunOn model.DryRunOn, wu *model.WaitUntil) (relevantDuration time.Duration, err error) {
 	set := instance[stage]
 	start := time.Now()
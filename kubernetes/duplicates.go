@@ -0,0 +1,85 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echocat/kubor/model"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	ErrDuplicateObject = errors.New("duplicate object")
+)
+
+func NewDuplicateDetector(project *model.Project) *DuplicateDetector {
+	return &DuplicateDetector{
+		project: project,
+		entries: map[model.ObjectReference]*duplicateEntry{},
+	}
+}
+
+// DuplicateDetector keeps track of all objects of a project to detect if one
+// object (also in one of its twin GroupVersionKinds) is defined more than once.
+type DuplicateDetector struct {
+	project *model.Project
+	entries map[model.ObjectReference]*duplicateEntry
+}
+
+type duplicateEntry struct {
+	source string
+	object *unstructured.Unstructured
+}
+
+// Add registers the given object. If an object with the same reference was
+// already registered this will fail with ErrDuplicateObject. Only if both
+// objects are of the same GroupVersionKind and are annotated to be merged, the
+// given object will be merged into the already registered one. In this case
+// merged is true and the given object should not be handled any further. The
+// registered object never shares any content with the given one.
+func (instance *DuplicateDetector) Add(source string, reference model.ObjectReference, object *unstructured.Unstructured) (merged bool, err error) {
+	if existing := instance.entries[reference]; existing != nil {
+		if ok, err := instance.isMergeAllowed(existing, object); err != nil {
+			return false, fmt.Errorf("%v (source: %s): %w", reference, source, err)
+		} else if !ok {
+			return false, fmt.Errorf("%w: %v is defined in %s and in %s", ErrDuplicateObject, reference, existing.source, source)
+		}
+		mergeObjectMaps(existing.object.Object, object.DeepCopy().Object)
+		return true, nil
+	}
+
+	for _, twin := range reference.AllTwinsBy(model.DefaultGroupVersionKindRegistry) {
+		if existing := instance.entries[twin]; existing != nil {
+			return false, fmt.Errorf("%w: %v is defined in %s and as %v in %s", ErrDuplicateObject, reference, source, twin, existing.source)
+		}
+	}
+
+	instance.entries[reference] = &duplicateEntry{
+		source: source,
+		object: object,
+	}
+	return false, nil
+}
+
+func (instance *DuplicateDetector) isMergeAllowed(existing *duplicateEntry, object *unstructured.Unstructured) (bool, error) {
+	if existingOnDuplicate, err := instance.project.Annotations.GetOnDuplicateFor(existing.object); err != nil {
+		return false, err
+	} else if onDuplicate, err := instance.project.Annotations.GetOnDuplicateFor(object); err != nil {
+		return false, err
+	} else {
+		return existingOnDuplicate == model.OnDuplicateMerge && onDuplicate == model.OnDuplicateMerge, nil
+	}
+}
+
+// mergeObjectMaps merges source into target. Maps are merged recursively; all
+// other values of source (including lists) replace the ones of target.
+func mergeObjectMaps(target map[string]interface{}, source map[string]interface{}) {
+	for key, value := range source {
+		if sourceMap, ok := value.(map[string]interface{}); ok {
+			if targetMap, ok := target[key].(map[string]interface{}); ok {
+				mergeObjectMaps(targetMap, sourceMap)
+				continue
+			}
+		}
+		target[key] = value
+	}
+}
@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"errors"
+	"github.com/echocat/kubor/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"testing"
+)
+
+func Test_DuplicateDetector_Add_fails(t *testing.T) {
+	cases := []struct {
+		name   string
+		first  map[string]interface{}
+		second map[string]interface{}
+	}{{
+		name: "duplicate",
+		first: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "bar"},
+		},
+		second: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "bar"},
+		},
+	}, {
+		name: "twin",
+		first: map[string]interface{}{
+			"apiVersion": "extensions/v1beta1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "bar"},
+		},
+		second: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "bar"},
+		},
+	}, {
+		name: "onlyOneAnnotatedToMerge",
+		first: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{"name": "foo", "namespace": "bar", "annotations": map[string]interface{}{
+				model.AnnotationOnDuplicate: "merge",
+			}},
+		},
+		second: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "bar"},
+		},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			project := model.NewProject()
+			instance := NewDuplicateDetector(&project)
+			first := &unstructured.Unstructured{Object: c.first}
+			second := &unstructured.Unstructured{Object: c.second}
+			firstReference, err := GetObjectReference(first, project.Scheme)
+			require.NoError(t, err)
+			secondReference, err := GetObjectReference(second, project.Scheme)
+			require.NoError(t, err)
+
+			merged, err := instance.Add("a.yml#0", firstReference, first)
+			require.NoError(t, err)
+			assert.False(t, merged)
+
+			merged, err = instance.Add("b.yml#0", secondReference, second)
+			assert.True(t, errors.Is(err, ErrDuplicateObject), "%v", err)
+			assert.Contains(t, err.Error(), "a.yml#0")
+			assert.Contains(t, err.Error(), "b.yml#0")
+			assert.False(t, merged)
+		})
+	}
+}
+
+func Test_DuplicateDetector_Add_mergesIfAnnotated(t *testing.T) {
+	project := model.NewProject()
+	instance := NewDuplicateDetector(&project)
+	first := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{"name": "foo", "namespace": "bar", "annotations": map[string]interface{}{
+			model.AnnotationOnDuplicate: "merge",
+		}},
+		"spec": map[string]interface{}{"a": "1", "nested": map[string]interface{}{"x": "1"}},
+	}}
+	second := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{"name": "foo", "namespace": "bar", "annotations": map[string]interface{}{
+			model.AnnotationOnDuplicate: "merge",
+		}},
+		"spec": map[string]interface{}{"b": "2", "nested": map[string]interface{}{"y": "2"}, "other": map[string]interface{}{"z": "3"}},
+	}}
+	reference, err := GetObjectReference(first, project.Scheme)
+	require.NoError(t, err)
+
+	_, err = instance.Add("a.yml#0", reference, first)
+	require.NoError(t, err)
+
+	merged, err := instance.Add("a.yml#1", reference, second)
+	require.NoError(t, err)
+	assert.True(t, merged)
+	assert.Equal(t, map[string]interface{}{
+		"a":      "1",
+		"b":      "2",
+		"nested": map[string]interface{}{"x": "1", "y": "2"},
+		"other":  map[string]interface{}{"z": "3"},
+	}, first.Object["spec"])
+
+	second.Object["spec"].(map[string]interface{})["other"].(map[string]interface{})["z"] = "changed"
+	assert.Equal(t, "3", first.Object["spec"].(map[string]interface{})["other"].(map[string]interface{})["z"])
+}
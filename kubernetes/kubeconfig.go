@@ -43,10 +43,7 @@ func ConfigureKubeConfigFlags(hf common.HasFlags) {
 
 func NewRuntime() (Runtime, error) {
 	if kubeConfigPath == "mock" {
-		if kubeContext == "" {
-			kubeContext = "mock"
-		}
-		return newRuntimeMock(kubeContext)
+		return NewMockRuntime()
 	}
 	clientConfig, contextName, err := NewKubeClientConfig()
 	if err != nil {
@@ -55,6 +52,15 @@ func NewRuntime() (Runtime, error) {
 	return newRuntimeImpl(clientConfig, contextName)
 }
 
+// NewMockRuntime creates a Runtime that never connects to any cluster,
+// regardless of what was provided using --kubeconfig.
+func NewMockRuntime() (Runtime, error) {
+	if kubeContext == "" {
+		kubeContext = "mock"
+	}
+	return newRuntimeMock(kubeContext)
+}
+
 func NewKubeClientConfig() (clientcmd.ClientConfig, string, error) {
 	if kubeConfigPath == "mock" {
 		return nil, "", errors.New("this operation is not supported if --kubeconfig=mock was specified")
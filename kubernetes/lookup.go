@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"sync"
+)
+
+// NewObjectLookup creates a lookup of live objects using the given client.
+// Every object (or list) is only requested once per lookup.
+func NewObjectLookup(client dynamic.Interface) template.ObjectLookup {
+	return &objectLookup{
+		client: client,
+		cache:  map[string]*objectLookupEntry{},
+	}
+}
+
+type objectLookupEntry struct {
+	once   sync.Once
+	result interface{}
+	err    error
+}
+
+type objectLookup struct {
+	client dynamic.Interface
+	mutex  sync.Mutex
+	cache  map[string]*objectLookupEntry
+}
+
+func (instance *objectLookup) Lookup(apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+	key := fmt.Sprintf("object:%s/%s/%s/%s", apiVersion, kind, namespace, name)
+	result, err := instance.cached(key, func() (interface{}, error) {
+		resource, err := instance.resourceFor(apiVersion, kind, namespace)
+		if err != nil {
+			return nil, err
+		}
+		object, err := resource.Get(context.Background(), name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return map[string]interface{}(nil), nil
+		} else if err != nil {
+			return nil, err
+		}
+		return object.Object, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func (instance *objectLookup) LookupList(apiVersion, kind, namespace, labelSelector string) ([]map[string]interface{}, error) {
+	key := fmt.Sprintf("list:%s/%s/%s/%s", apiVersion, kind, namespace, labelSelector)
+	result, err := instance.cached(key, func() (interface{}, error) {
+		resource, err := instance.resourceFor(apiVersion, kind, namespace)
+		if err != nil {
+			return nil, err
+		}
+		list, err := resource.List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
+		if errors.IsNotFound(err) {
+			return []map[string]interface{}{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		items := list.Items
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].GetNamespace() != items[j].GetNamespace() {
+				return items[i].GetNamespace() < items[j].GetNamespace()
+			}
+			return items[i].GetName() < items[j].GetName()
+		})
+		objects := make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			objects[i] = item.Object
+		}
+		return objects, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]map[string]interface{}), nil
+}
+
+// cached calls loader only once per key; concurrent lookups of other keys are
+// not blocked while the cluster is requested.
+func (instance *objectLookup) cached(key string, loader func() (interface{}, error)) (interface{}, error) {
+	instance.mutex.Lock()
+	entry, ok := instance.cache[key]
+	if !ok {
+		entry = &objectLookupEntry{}
+		instance.cache[key] = entry
+	}
+	instance.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.result, entry.err = loader()
+	})
+	return entry.result, entry.err
+}
+
+func (instance *objectLookup) resourceFor(apiVersion, kind, namespace string) (dynamic.ResourceInterface, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(kind))
+	if namespace == "" {
+		return instance.client.Resource(gvr), nil
+	}
+	return instance.client.Resource(gvr).Namespace(namespace), nil
+}
@@ -0,0 +1,81 @@
+package kubernetes
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_objectLookup(t *testing.T) {
+	client := dynamicFake.NewSimpleDynamicClient(runtime.NewScheme(),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"namespace": "ns", "name": "b", "labels": map[string]interface{}{"app": "demo"}},
+			"data":       map[string]interface{}{"password": "c2VjcmV0"},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"namespace": "ns", "name": "a", "labels": map[string]interface{}{"app": "demo"}},
+			"data":       map[string]interface{}{"password": "c2VjcmV0"},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"namespace": "ns", "name": "c", "labels": map[string]interface{}{"app": "other"}},
+			"data":       map[string]interface{}{"password": "c2VjcmV0"},
+		}},
+	)
+	instance := NewObjectLookup(client)
+
+	actual, err := instance.Lookup("v1", "Secret", "ns", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "c2VjcmV0", actual["data"].(map[string]interface{})["password"])
+
+	actual, err = instance.Lookup("v1", "Secret", "ns", "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, actual)
+
+	list, err := instance.LookupList("v1", "Secret", "ns", "app=demo")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0]["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "b", list[1]["metadata"].(map[string]interface{})["name"])
+}
+
+func Test_objectLookup_cached(t *testing.T) {
+	instance := NewObjectLookup(nil).(*objectLookup)
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var calls int32
+
+	done := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := instance.cached("slow", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				close(blocked)
+				<-release
+				return "slow", nil
+			})
+			done <- err
+		}()
+	}
+	<-blocked
+
+	actual, err := instance.cached("fast", func() (interface{}, error) {
+		return "fast", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fast", actual)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, <-done)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,210 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"strings"
+)
+
+// Resource is one plain Kubernetes object together with the file it was read
+// from.
+type Resource struct {
+	Source string
+	Object map[string]interface{}
+}
+
+func (instance Resource) apiVersion() string {
+	v, _ := instance.Object["apiVersion"].(string)
+	return v
+}
+
+func (instance Resource) kind() string {
+	v, _ := instance.Object["kind"].(string)
+	return v
+}
+
+func (instance Resource) metadata() map[string]interface{} {
+	m, ok := instance.Object["metadata"].(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		instance.Object["metadata"] = m
+	}
+	return m
+}
+
+func (instance Resource) name() string {
+	v, _ := instance.metadata()["name"].(string)
+	return v
+}
+
+func (instance Resource) namespace() string {
+	v, _ := instance.metadata()["namespace"].(string)
+	return v
+}
+
+func (instance Resource) String() string {
+	return fmt.Sprintf("%s/%s %s (source: %s)", instance.apiVersion(), instance.kind(), instance.name(), instance.Source)
+}
+
+// Target selects resources. Every empty property matches everything.
+type Target struct {
+	Group     string `yaml:"group,omitempty" json:"group,omitempty"`
+	Version   string `yaml:"version,omitempty" json:"version,omitempty"`
+	Kind      string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+func (instance Target) Matches(resource Resource) bool {
+	gv, _ := schema.ParseGroupVersion(resource.apiVersion())
+	return (instance.Group == "" || instance.Group == gv.Group) &&
+		(instance.Version == "" || instance.Version == gv.Version) &&
+		(instance.Kind == "" || instance.Kind == resource.kind()) &&
+		(instance.Name == "" || instance.Name == resource.name()) &&
+		(instance.Namespace == "" || instance.Namespace == resource.namespace())
+}
+
+func (instance Target) String() string {
+	return fmt.Sprintf("group=%s,version=%s,kind=%s,name=%s,namespace=%s", instance.Group, instance.Version, instance.Kind, instance.Name, instance.Namespace)
+}
+
+// ApplyStrategicMergePatch applies the given patch to the resource with the
+// same apiVersion, kind, name (and namespace if set in the patch). Kinds which
+// are not known to the Kubernetes client are patched using JSON merge patch.
+// A patch with "$patch: delete" removes the resource.
+func ApplyStrategicMergePatch(resources []Resource, patch map[string]interface{}) ([]Resource, error) {
+	target := Resource{Object: patch}
+	gv, err := schema.ParseGroupVersion(target.apiVersion())
+	if err != nil {
+		return nil, fmt.Errorf("illegal apiVersion of patch %v: %w", target, err)
+	}
+	selector := Target{Group: gv.Group, Version: gv.Version, Kind: target.kind(), Name: target.name(), Namespace: target.namespace()}
+	if selector.Kind == "" || selector.Name == "" {
+		return nil, fmt.Errorf("patch %v does not define kind and metadata.name", target)
+	}
+
+	result := make([]Resource, 0, len(resources))
+	matched := false
+	for _, resource := range resources {
+		if !selector.Matches(resource) {
+			result = append(result, resource)
+			continue
+		}
+		matched = true
+		if directive, _ := patch["$patch"].(string); directive == "delete" {
+			continue
+		}
+		patched, err := strategicMergePatch(resource, patch, gv.WithKind(selector.Kind))
+		if err != nil {
+			return nil, fmt.Errorf("cannot patch %v: %w", resource, err)
+		}
+		result = append(result, patched)
+	}
+	if !matched {
+		return nil, fmt.Errorf("patch does not match any resource: %v", selector)
+	}
+	return result, nil
+}
+
+func strategicMergePatch(resource Resource, patch map[string]interface{}, gvk schema.GroupVersionKind) (Resource, error) {
+	original, err := json.Marshal(resource.Object)
+	if err != nil {
+		return Resource{}, err
+	}
+	plainPatch, err := json.Marshal(patch)
+	if err != nil {
+		return Resource{}, err
+	}
+	var patched []byte
+	if dataStruct, sErr := scheme.Scheme.New(gvk); sErr == nil {
+		patched, err = strategicpatch.StrategicMergePatch(original, plainPatch, dataStruct)
+	} else {
+		patched, err = jsonpatch.MergePatch(original, plainPatch)
+	}
+	if err != nil {
+		return Resource{}, err
+	}
+	return resource.withJson(patched)
+}
+
+// ApplyJsonPatch applies the given RFC 6902 operations (as JSON) to every
+// resource which matches the target.
+func ApplyJsonPatch(resources []Resource, target Target, operations []byte) ([]Resource, error) {
+	patch, err := jsonpatch.DecodePatch(operations)
+	if err != nil {
+		return nil, fmt.Errorf("illegal JSON patch for %v: %w", target, err)
+	}
+	result := make([]Resource, len(resources))
+	matched := false
+	for i, resource := range resources {
+		result[i] = resource
+		if !target.Matches(resource) {
+			continue
+		}
+		matched = true
+		original, err := json.Marshal(resource.Object)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := patch.Apply(original)
+		if err != nil {
+			return nil, fmt.Errorf("cannot patch %v: %w", resource, err)
+		}
+		if result[i], err = resource.withJson(patched); err != nil {
+			return nil, err
+		}
+	}
+	if !matched {
+		return nil, fmt.Errorf("JSON patch does not match any resource: %v", target)
+	}
+	return result, nil
+}
+
+func (instance Resource) withJson(plain []byte) (Resource, error) {
+	result := Resource{Source: instance.Source}
+	if err := json.Unmarshal(plain, &result.Object); err != nil {
+		return Resource{}, err
+	}
+	return result, nil
+}
+
+// Image replaces the name, tag or digest of every container image with the
+// given name.
+type Image struct {
+	Name    string `yaml:"name" json:"name"`
+	NewName string `yaml:"newName,omitempty" json:"newName,omitempty"`
+	NewTag  string `yaml:"newTag,omitempty" json:"newTag,omitempty"`
+	Digest  string `yaml:"digest,omitempty" json:"digest,omitempty"`
+}
+
+func (instance Image) apply(image string) (string, bool) {
+	name, suffix := splitImage(image)
+	if name != instance.Name {
+		return image, false
+	}
+	if instance.NewName != "" {
+		name = instance.NewName
+	}
+	if instance.Digest != "" {
+		suffix = "@" + instance.Digest
+	} else if instance.NewTag != "" {
+		suffix = ":" + instance.NewTag
+	}
+	return name + suffix, true
+}
+
+// splitImage splits the given image into its name and its tag or digest
+// (including the separator).
+func splitImage(image string) (name string, suffix string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i:]
+	}
+	return image, ""
+}
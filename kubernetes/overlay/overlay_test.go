@@ -0,0 +1,159 @@
+package overlay
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ApplyStrategicMergePatch(t *testing.T) {
+	resources := []Resource{{
+		Source: "base/app.yml",
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app"},
+			"spec": map[string]interface{}{
+				"replicas": 1,
+				"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "registry/app:1.0"},
+						map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0"},
+					},
+				}},
+			},
+		},
+	}, {
+		Source: "base/config.yml",
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config"},
+		},
+	}}
+
+	actual, err := ApplyStrategicMergePatch(resources, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"replicas": 3,
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "app", "image": "registry/app:2.0"}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+
+	spec := actual[0].Object["spec"].(map[string]interface{})
+	assert.Equal(t, float64(3), spec["replicas"])
+	containers := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	require.Len(t, containers, 2)
+	assert.Equal(t, "registry/app:2.0", containers[0].(map[string]interface{})["image"])
+	assert.Equal(t, "sidecar:1.0", containers[1].(map[string]interface{})["image"])
+
+	actual, err = ApplyStrategicMergePatch(resources, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config"},
+		"$patch":     "delete",
+	})
+	require.NoError(t, err)
+	assert.Len(t, actual, 1)
+
+	_, err = ApplyStrategicMergePatch(resources, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "other"},
+	})
+	assert.EqualError(t, err, "patch does not match any resource: group=,version=v1,kind=ConfigMap,name=other,namespace=")
+}
+
+func Test_ApplyJsonPatch(t *testing.T) {
+	resources := []Resource{{
+		Source: "base/app.yml",
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app"},
+			"spec":       map[string]interface{}{"replicas": 1},
+		},
+	}}
+
+	actual, err := ApplyJsonPatch(resources, Target{Kind: "Deployment"}, []byte(`[{"op":"replace","path":"/spec/replicas","value":5}]`))
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), actual[0].Object["spec"].(map[string]interface{})["replicas"])
+	assert.Equal(t, "base/app.yml", actual[0].Source)
+
+	_, err = ApplyJsonPatch(resources, Target{Kind: "Service"}, []byte(`[]`))
+	assert.Error(t, err)
+}
+
+func Test_Transformers_Apply(t *testing.T) {
+	resources := []Resource{{
+		Source: "base/app.yml",
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app"},
+			"spec": map[string]interface{}{
+				"replicas": 1,
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "app"}},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "app"}},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":    "app",
+								"image":   "registry/app:1.0",
+								"envFrom": []interface{}{map[string]interface{}{"configMapRef": map[string]interface{}{"name": "config"}}},
+							},
+							map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0"},
+						},
+					},
+				},
+			},
+		},
+	}, {
+		Source: "base/config.yml",
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config"},
+		},
+	}}
+
+	Transformers{
+		Namespace:    "dev",
+		NamePrefix:   "dev-",
+		CommonLabels: map[string]string{"stage": "dev"},
+		Images:       []Image{{Name: "registry/app", NewTag: "3.0"}},
+	}.Apply(resources)
+
+	deployment := resources[0]
+	assert.Equal(t, "dev-app", deployment.name())
+	assert.Equal(t, "dev", deployment.namespace())
+	assert.Equal(t, map[string]interface{}{"stage": "dev"}, deployment.metadata()["labels"])
+
+	spec := deployment.Object["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"app": "app", "stage": "dev"}, spec["selector"].(map[string]interface{})["matchLabels"])
+	container := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "registry/app:3.0", container["image"])
+	assert.Equal(t, "dev-config", container["envFrom"].([]interface{})[0].(map[string]interface{})["configMapRef"].(map[string]interface{})["name"])
+
+	assert.Equal(t, "dev-config", resources[1].name())
+}
+
+func Test_splitImage(t *testing.T) {
+	for image, expected := range map[string][2]string{
+		"app":                      {"app", ""},
+		"app:1.0":                  {"app", ":1.0"},
+		"registry:5000/app":        {"registry:5000/app", ""},
+		"registry:5000/app:1.0":    {"registry:5000/app", ":1.0"},
+		"registry/app@sha256:abcd": {"registry/app", "@sha256:abcd"},
+	} {
+		name, suffix := splitImage(image)
+		assert.Equal(t, expected, [2]string{name, suffix}, image)
+	}
+}
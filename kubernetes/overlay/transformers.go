@@ -0,0 +1,203 @@
+package overlay
+
+var (
+	clusterScopedKinds = map[string]bool{
+		"APIService":                     true,
+		"ClusterRole":                    true,
+		"ClusterRoleBinding":             true,
+		"CustomResourceDefinition":       true,
+		"MutatingWebhookConfiguration":   true,
+		"Namespace":                      true,
+		"PersistentVolume":               true,
+		"PodSecurityPolicy":              true,
+		"PriorityClass":                  true,
+		"StorageClass":                   true,
+		"ValidatingWebhookConfiguration": true,
+	}
+
+	// selectorPaths contains for every kind the paths of label selectors and
+	// pod templates which have to contain the common labels, too.
+	selectorPaths = map[string][][]string{
+		"Deployment":            {{"spec", "selector", "matchLabels"}, {"spec", "template", "metadata", "labels"}},
+		"ReplicaSet":            {{"spec", "selector", "matchLabels"}, {"spec", "template", "metadata", "labels"}},
+		"StatefulSet":           {{"spec", "selector", "matchLabels"}, {"spec", "template", "metadata", "labels"}},
+		"DaemonSet":             {{"spec", "selector", "matchLabels"}, {"spec", "template", "metadata", "labels"}},
+		"ReplicationController": {{"spec", "selector"}, {"spec", "template", "metadata", "labels"}},
+		"Service":               {{"spec", "selector"}},
+		"Job":                   {{"spec", "template", "metadata", "labels"}},
+		"CronJob":               {{"spec", "jobTemplate", "spec", "template", "metadata", "labels"}},
+	}
+
+	templateAnnotationPaths = map[string][]string{
+		"Deployment":            {"spec", "template", "metadata", "annotations"},
+		"ReplicaSet":            {"spec", "template", "metadata", "annotations"},
+		"StatefulSet":           {"spec", "template", "metadata", "annotations"},
+		"DaemonSet":             {"spec", "template", "metadata", "annotations"},
+		"ReplicationController": {"spec", "template", "metadata", "annotations"},
+		"Job":                   {"spec", "template", "metadata", "annotations"},
+		"CronJob":               {"spec", "jobTemplate", "spec", "template", "metadata", "annotations"},
+	}
+
+	// nameReferences maps keys of objects which reference other objects by
+	// name to the kind of the referenced object. The key "name" inside of
+	// these objects contains the name.
+	nameReferences = map[string]string{
+		"configMapRef":    "ConfigMap",
+		"configMapKeyRef": "ConfigMap",
+		"configMap":       "ConfigMap",
+		"secretRef":       "Secret",
+		"secretKeyRef":    "Secret",
+	}
+)
+
+// Transformers are applied to every resource of an overlay after all patches.
+type Transformers struct {
+	Namespace         string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	NamePrefix        string            `yaml:"namePrefix,omitempty" json:"namePrefix,omitempty"`
+	NameSuffix        string            `yaml:"nameSuffix,omitempty" json:"nameSuffix,omitempty"`
+	CommonLabels      map[string]string `yaml:"commonLabels,omitempty" json:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string `yaml:"commonAnnotations,omitempty" json:"commonAnnotations,omitempty"`
+	Images            []Image           `yaml:"images,omitempty" json:"images,omitempty"`
+}
+
+// Apply modifies the given resources. References to renamed ConfigMaps,
+// Secrets, ServiceAccounts and PersistentVolumeClaims are renamed, too.
+func (instance Transformers) Apply(resources []Resource) {
+	renamed := map[string]map[string]string{}
+	for _, resource := range resources {
+		metadata := resource.metadata()
+		if instance.Namespace != "" && !clusterScopedKinds[resource.kind()] {
+			metadata["namespace"] = instance.Namespace
+		}
+		if instance.NamePrefix != "" || instance.NameSuffix != "" {
+			name := resource.name()
+			newName := instance.NamePrefix + name + instance.NameSuffix
+			metadata["name"] = newName
+			if renamed[resource.kind()] == nil {
+				renamed[resource.kind()] = map[string]string{}
+			}
+			renamed[resource.kind()][name] = newName
+		}
+		if len(instance.CommonLabels) > 0 {
+			setAll(metadata, "labels", instance.CommonLabels)
+			for _, path := range selectorPaths[resource.kind()] {
+				setAllAt(resource.Object, path, instance.CommonLabels)
+			}
+		}
+		if len(instance.CommonAnnotations) > 0 {
+			setAll(metadata, "annotations", instance.CommonAnnotations)
+			if path, ok := templateAnnotationPaths[resource.kind()]; ok {
+				setAllAt(resource.Object, path, instance.CommonAnnotations)
+			}
+		}
+		if len(instance.Images) > 0 {
+			instance.replaceImages(resource.Object)
+		}
+	}
+	if len(renamed) > 0 {
+		for _, resource := range resources {
+			renameReferences(resource.Object, renamed)
+		}
+	}
+}
+
+func (instance Transformers) replaceImages(v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			if containers, ok := value.([]interface{}); ok && (key == "containers" || key == "initContainers") {
+				for _, plainContainer := range containers {
+					container, ok := plainContainer.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					if image, ok := container["image"].(string); ok {
+						for _, candidate := range instance.Images {
+							if replaced, ok := candidate.apply(image); ok {
+								container["image"] = replaced
+								break
+							}
+						}
+					}
+				}
+			}
+			instance.replaceImages(value)
+		}
+	case []interface{}:
+		for _, value := range x {
+			instance.replaceImages(value)
+		}
+	}
+}
+
+func renameReferences(v interface{}, renamed map[string]map[string]string) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			switch key {
+			case "serviceAccountName":
+				renameValue(x, key, renamed["ServiceAccount"])
+			case "imagePullSecrets":
+				if list, ok := value.([]interface{}); ok {
+					for _, item := range list {
+						if m, ok := item.(map[string]interface{}); ok {
+							renameValue(m, "name", renamed["Secret"])
+						}
+					}
+				}
+			case "secret":
+				if m, ok := value.(map[string]interface{}); ok {
+					renameValue(m, "secretName", renamed["Secret"])
+				}
+			case "persistentVolumeClaim":
+				if m, ok := value.(map[string]interface{}); ok {
+					renameValue(m, "claimName", renamed["PersistentVolumeClaim"])
+				}
+			default:
+				if kind, ok := nameReferences[key]; ok {
+					if m, ok := value.(map[string]interface{}); ok {
+						renameValue(m, "name", renamed[kind])
+					}
+				}
+			}
+			renameReferences(value, renamed)
+		}
+	case []interface{}:
+		for _, value := range x {
+			renameReferences(value, renamed)
+		}
+	}
+}
+
+func renameValue(in map[string]interface{}, key string, names map[string]string) {
+	if name, ok := in[key].(string); ok {
+		if newName, ok := names[name]; ok {
+			in[key] = newName
+		}
+	}
+}
+
+func setAll(in map[string]interface{}, key string, values map[string]string) {
+	target, ok := in[key].(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+		in[key] = target
+	}
+	for k, v := range values {
+		target[k] = v
+	}
+}
+
+// setAllAt sets the given values at the map of the given path if all parents
+// of this map exist.
+func setAllAt(in map[string]interface{}, path []string, values map[string]string) {
+	current := in
+	for _, key := range path[:len(path)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	setAll(current, path[len(path)-1], values)
+}
@@ -60,6 +60,7 @@ func (instance *ensureKuborAnnotations) ensureOfPath(project *model.Project, tar
 	instance.ensureAnnotation(&annotations, pa.DryRunOn)
 	instance.ensureAnnotation(&annotations, pa.WaitUntil)
 	instance.ensureAnnotation(&annotations, pa.CleanupOn)
+	instance.ensureAnnotation(&annotations, pa.OnDuplicate)
 	instance.ensurePrefixedAnnotations(&annotations, pa.Transformations)
 
 	return unstructured.SetNestedStringMap(target.Object, annotations, fields...)
@@ -35,3 +35,17 @@ func (instance transformation) DefaultEnabled(*unstructured.Unstructured) bool {
 func (instance transformation) GetPriority() int32 {
 	return 0
 }
+
+func (instance Transformations) Has(name model.TransformationName) bool {
+	for _, candidate := range instance.Updates {
+		if candidate.GetName() == name {
+			return true
+		}
+	}
+	for _, candidate := range instance.Creates {
+		if candidate.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
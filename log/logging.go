@@ -44,6 +44,16 @@ type RootLogger interface {
 
 	Init() error
 	ConfigureFlags(HasFlags)
+	// IsStructured returns true if the output is meant to be read by machines
+	// (like json).
+	IsStructured() bool
+}
+
+// ErrorWithFields could be implemented by errors which should be logged using
+// WithError together with additional fields.
+type ErrorWithFields interface {
+	error
+	LogFields() map[string]interface{}
 }
 
 func WithField(key string, value interface{}) Logger {
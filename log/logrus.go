@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 )
@@ -124,6 +125,10 @@ func (instance *LogrusLogger) IsFatalEnabled() bool {
 	return instance.Delegate.Level >= logrus.FatalLevel
 }
 
+func (instance *LogrusLogger) IsStructured() bool {
+	return instance.Format == "json"
+}
+
 func (instance *LogrusLogger) ConfigureFlags(hf HasFlags) {
 	hf.Flag("logLevel", "Specifies the minimum required log level.").
 		Envar("KUBOR_LOG_LEVEL").
@@ -174,13 +179,13 @@ type LogrusEntry struct {
 func (instance *LogrusEntry) WithField(key string, value interface{}) Logger {
 	return &LogrusEntry{
 		Root:     instance.Root,
-		Delegate: instance.Delegate.WithField(key, value),
+		Delegate: instance.Delegate.WithField(key, DefaultRedactor.RedactField(key, value)),
 	}
 }
 
 func (instance *LogrusEntry) WithDeepField(key string, value interface{}) Logger {
 	return instance.WithField(key, JsonValue{
-		Value: value,
+		Value: DefaultRedactor.Redact(value),
 	})
 }
 
@@ -192,34 +197,75 @@ func (instance *LogrusEntry) WithDeepFieldOn(key string, value interface{}, on f
 }
 
 func (instance *LogrusEntry) WithError(err error) Logger {
+	logged := err
+	if err != nil {
+		logged = redactedError{err}
+	}
+	delegate := instance.Delegate.WithError(logged)
+	var ewf ErrorWithFields
+	if errors.As(err, &ewf) {
+		for key, value := range ewf.LogFields() {
+			delegate = delegate.WithField(key, DefaultRedactor.RedactField(key, value))
+		}
+	}
 	return &LogrusEntry{
 		Root:     instance.Root,
-		Delegate: instance.Delegate.WithError(err),
+		Delegate: delegate,
 	}
 }
 
+// redactedError redacts the message of the wrapped error, which could contain
+// sensitive values (like a failed decryption or rendering).
+type redactedError struct {
+	error
+}
+
+func (instance redactedError) Error() string {
+	return DefaultRedactor.RedactString(instance.error.Error())
+}
+
+func (instance redactedError) Unwrap() error {
+	return instance.error
+}
+
 func (instance *LogrusEntry) Trace(msg string, args ...interface{}) {
-	instance.Delegate.Tracef(msg, args...)
+	if instance.Delegate.Logger.IsLevelEnabled(logrus.TraceLevel) {
+		instance.Delegate.Trace(redactedMessage(msg, args...))
+	}
 }
 
 func (instance *LogrusEntry) Debug(msg string, args ...interface{}) {
-	instance.Delegate.Debugf(msg, args...)
+	if instance.Delegate.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		instance.Delegate.Debug(redactedMessage(msg, args...))
+	}
 }
 
 func (instance *LogrusEntry) Info(msg string, args ...interface{}) {
-	instance.Delegate.Infof(msg, args...)
+	if instance.Delegate.Logger.IsLevelEnabled(logrus.InfoLevel) {
+		instance.Delegate.Info(redactedMessage(msg, args...))
+	}
 }
 
 func (instance *LogrusEntry) Warn(msg string, args ...interface{}) {
-	instance.Delegate.Warnf(msg, args...)
+	if instance.Delegate.Logger.IsLevelEnabled(logrus.WarnLevel) {
+		instance.Delegate.Warn(redactedMessage(msg, args...))
+	}
 }
 
 func (instance *LogrusEntry) Error(msg string, args ...interface{}) {
-	instance.Delegate.Errorf(msg, args...)
+	if instance.Delegate.Logger.IsLevelEnabled(logrus.ErrorLevel) {
+		instance.Delegate.Error(redactedMessage(msg, args...))
+	}
 }
 
 func (instance *LogrusEntry) Fatal(msg string, args ...interface{}) {
-	instance.Delegate.Fatalf(msg, args...)
+	instance.Delegate.Fatal(redactedMessage(msg, args...))
+}
+
+// redactedMessage formats the message and redacts all sensitive values of it.
+// This covers the arguments which are not redacted like fields are.
+func redactedMessage(msg string, args ...interface{}) string {
+	return DefaultRedactor.RedactString(fmt.Sprintf(msg, args...))
 }
 
 func (instance *LogrusEntry) IsTraceEnabled() bool {
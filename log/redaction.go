@@ -0,0 +1,262 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// MinimumSensitiveValueLength prevents that too short values (like "1" or
+	// "true") are masked everywhere they occur.
+	MinimumSensitiveValueLength = 4
+
+	lastAppliedConfigurationAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+var (
+	DefaultRedactor = NewRedactor(DefaultRedactionNamePatterns...)
+
+	DefaultRedactionNamePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|credentials?|private_?key)$`),
+	}
+
+	// redactionSalt is random for every process; so markers of RedactedValue
+	// cannot be used to guess the original (short) values.
+	redactionSalt = newRedactionSalt()
+)
+
+func newRedactionSalt() []byte {
+	result := make([]byte, 32)
+	if _, err := rand.Read(result); err != nil {
+		panic(fmt.Sprintf("cannot create redaction salt: %v", err))
+	}
+	return result
+}
+
+// Redact returns a copy of the given value where every sensitive part is
+// replaced using the DefaultRedactor.
+func Redact(v interface{}) interface{} {
+	return DefaultRedactor.Redact(v)
+}
+
+// Redactor replaces sensitive parts of values with a marker which contains a
+// short salted hash of the original value. This allows to compare if a value
+// was changed inside of one execution without revealing it.
+//
+// Sensitive are:
+// 1. data and stringData of Kubernetes Secrets,
+// 2. string values of all keys which match one of the name patterns (other
+//    values like automountServiceAccountToken: false are not secrets) and
+// 3. every occurrence of a registered sensitive value.
+type Redactor struct {
+	mutex        sync.RWMutex
+	namePatterns []*regexp.Regexp
+	sensitive    map[string]bool
+	// replacer is lazy created out of sensitive.
+	replacer *strings.Replacer
+}
+
+func NewRedactor(namePatterns ...*regexp.Regexp) *Redactor {
+	return &Redactor{
+		namePatterns: namePatterns,
+		sensitive:    map[string]bool{},
+	}
+}
+
+func (instance *Redactor) AddNamePattern(pattern *regexp.Regexp) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	instance.namePatterns = append(instance.namePatterns, pattern)
+}
+
+// AddSensitiveValue registers the given value to be replaced everywhere it
+// occurs.
+func (instance *Redactor) AddSensitiveValue(v string) {
+	if len(v) < MinimumSensitiveValueLength {
+		return
+	}
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	instance.sensitive[v] = true
+	instance.replacer = nil
+}
+
+func (instance *Redactor) IsSensitiveName(name string) bool {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+	for _, pattern := range instance.namePatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactField redacts the given value of a field with the given name. The
+// name is also checked without its prefix (value.password => password). Values
+// other than strings, maps and slices are returned as they are.
+func (instance *Redactor) RedactField(name string, v interface{}) interface{} {
+	if _, ok := v.(string); ok && (instance.IsSensitiveName(name) || instance.IsSensitiveName(name[strings.LastIndex(name, ".")+1:])) {
+		return RedactedValue(v)
+	}
+	switch v.(type) {
+	case string, map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return instance.Redact(v)
+	}
+	return v
+}
+
+// Redact returns a copy of the given value with every sensitive part replaced.
+// Values which are neither maps, slices nor scalars are converted using their
+// JSON representation first.
+func (instance *Redactor) Redact(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case string:
+		return instance.RedactString(x)
+	case error:
+		return instance.RedactString(x.Error())
+	case map[string]interface{}:
+		return instance.redactMap(x)
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			converted[fmt.Sprint(key)] = value
+		}
+		return instance.redactMap(converted)
+	case []interface{}:
+		result := make([]interface{}, len(x))
+		for i, value := range x {
+			result[i] = instance.Redact(value)
+		}
+		return result
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return instance.RedactString(fmt.Sprint(v))
+		}
+		var generic interface{}
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return instance.RedactString(string(b))
+		}
+		return instance.Redact(generic)
+	}
+}
+
+func (instance *Redactor) redactMap(in map[string]interface{}) map[string]interface{} {
+	secret := isKubernetesSecret(in)
+	result := make(map[string]interface{}, len(in))
+	for key, value := range in {
+		if secret && (key == "data" || key == "stringData") {
+			result[key] = redactAllValues(value)
+		} else if secret && key == "metadata" {
+			result[key] = instance.redactSecretMetadata(value)
+		} else if _, ok := value.(string); ok && instance.IsSensitiveName(key) {
+			result[key] = RedactedValue(value)
+		} else {
+			result[key] = instance.Redact(value)
+		}
+	}
+	return result
+}
+
+// redactSecretMetadata also redacts the last applied configuration of kubectl
+// because it contains the whole Secret.
+func (instance *Redactor) redactSecretMetadata(v interface{}) interface{} {
+	result := instance.Redact(v)
+	if metadata, ok := result.(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if value, ok := annotations[lastAppliedConfigurationAnnotation]; ok {
+				annotations[lastAppliedConfigurationAnnotation] = RedactedValue(value)
+			}
+		}
+	}
+	return result
+}
+
+// RedactString replaces every occurrence of a registered sensitive value in
+// the given string.
+func (instance *Redactor) RedactString(s string) string {
+	if replacer := instance.stringReplacer(); replacer != nil {
+		return replacer.Replace(s)
+	}
+	return s
+}
+
+func (instance *Redactor) stringReplacer() *strings.Replacer {
+	instance.mutex.RLock()
+	replacer, empty := instance.replacer, len(instance.sensitive) == 0
+	instance.mutex.RUnlock()
+	if replacer != nil || empty {
+		return replacer
+	}
+
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	values := make([]string, 0, len(instance.sensitive))
+	for value := range instance.sensitive {
+		values = append(values, value)
+	}
+	// Longer values first, to prevent that contained shorter ones win.
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, RedactedValue(value))
+	}
+	instance.replacer = strings.NewReplacer(pairs...)
+	return instance.replacer
+}
+
+// RedactedValue returns the marker that replaces the given value. The marker is
+// only stable inside of the current process.
+func RedactedValue(v interface{}) string {
+	var plain []byte
+	if s, ok := v.(string); ok {
+		plain = []byte(s)
+	} else if b, err := json.Marshal(v); err == nil {
+		plain = b
+	} else {
+		plain = []byte(fmt.Sprint(v))
+	}
+	mac := hmac.New(sha256.New, redactionSalt)
+	mac.Write(plain)
+	return "<redacted hmac:" + hex.EncodeToString(mac.Sum(nil)[:6]) + ">"
+}
+
+func redactAllValues(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			result[key] = RedactedValue(value)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			result[fmt.Sprint(key)] = RedactedValue(value)
+		}
+		return result
+	case nil:
+		return nil
+	default:
+		return RedactedValue(v)
+	}
+}
+
+func isKubernetesSecret(in map[string]interface{}) bool {
+	kind, _ := in["kind"].(string)
+	apiVersion, _ := in["apiVersion"].(string)
+	return kind == "Secret" && apiVersion == "v1"
+}
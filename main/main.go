@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"github.com/alecthomas/kingpin"
 	"github.com/echocat/kubor/command"
@@ -8,6 +9,7 @@ import (
 	"github.com/echocat/kubor/kubernetes"
 	"github.com/echocat/kubor/log"
 	"github.com/echocat/kubor/model"
+	"github.com/echocat/kubor/template"
 	"os"
 	"runtime"
 	"time"
@@ -62,5 +64,24 @@ func main() {
 	app.Command("version", "Print the actual version and other useful information.").
 		Action(version)
 
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	command, err := app.Parse(os.Args[1:])
+	var templateErr *template.Error
+	if errors.As(err, &templateErr) {
+		reportTemplateError(err, templateErr)
+		os.Exit(1)
+	}
+	kingpin.MustParse(command, err)
+}
+
+// reportTemplateError prints errors of templates together with their details
+// (like the snippet) which are hard to read as part of a single line.
+func reportTemplateError(err error, templateErr *template.Error) {
+	if log.DefaultLogger.IsStructured() {
+		log.WithError(err).Error("Cannot process template %v.", templateErr.Location)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "kubor: error: %v\n", err)
+	if details := templateErr.Details(); details != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "\n%s", details)
+	}
 }
@@ -11,6 +11,7 @@ const (
 	AnnotationDryRunOn             = "kubor.echocat.org/dry-run-on"
 	AnnotationWaitUntil            = "kubor.echocat.org/wait-until"
 	AnnotationCleanupOn            = "kubor.echocat.org/cleanup-on"
+	AnnotationOnDuplicate          = "kubor.echocat.org/on-duplicate"
 	AnnotationTransformationPrefix = "transformation.kubor.echocat.org/"
 )
 
@@ -20,6 +21,7 @@ type Annotations struct {
 	DryRunOn        Annotation `yaml:"dryRunOn,omitempty" json:"dryRunOn,omitempty"`
 	WaitUntil       Annotation `yaml:"waitUntil,omitempty" json:"waitUntil,omitempty"`
 	CleanupOn       Annotation `yaml:"cleanupOn,omitempty" json:"cleanupOn,omitempty"`
+	OnDuplicate     Annotation `yaml:"onDuplicate,omitempty" json:"onDuplicate,omitempty"`
 	Transformations Annotation `yaml:"transformations,omitempty" json:"transformations,omitempty"`
 }
 
@@ -30,6 +32,7 @@ func NewAnnotations() Annotations {
 		DryRunOn:        Annotation{AnnotationDryRunOn, AnnotationActionDrop},
 		WaitUntil:       Annotation{AnnotationWaitUntil, AnnotationActionDrop},
 		CleanupOn:       Annotation{AnnotationCleanupOn, AnnotationActionLeave},
+		OnDuplicate:     Annotation{AnnotationOnDuplicate, AnnotationActionDrop},
 		Transformations: Annotation{AnnotationTransformationPrefix, AnnotationActionDrop},
 	}
 }
@@ -84,6 +87,16 @@ func (instance Annotations) GetCleanupOn(v *unstructured.Unstructured) (CleanupO
 	return result, result.Set(plain)
 }
 
+func (instance Annotations) GetOnDuplicateFor(v *unstructured.Unstructured) (OnDuplicate, error) {
+	as := v.GetAnnotations()
+	plain := as[string(instance.OnDuplicate.Name)]
+	if plain == "" {
+		return OnDuplicateFail, nil
+	}
+	var result OnDuplicate
+	return result, result.Set(plain)
+}
+
 func (instance Annotations) GetTransformation(v *unstructured.Unstructured, name TransformationName) (result Transformation, err error) {
 	as := v.GetAnnotations()
 	plain := as[string(instance.Transformations.Name)+string(name)]
@@ -111,3 +124,16 @@ func (instance Annotations) GetTransformation(v *unstructured.Unstructured, name
 	}
 	return
 }
+
+// All returns all annotations of this instance by their property name.
+func (instance Annotations) All() map[string]Annotation {
+	return map[string]Annotation{
+		"stage":           instance.Stage,
+		"applyOn":         instance.ApplyOn,
+		"dryRunOn":        instance.DryRunOn,
+		"waitUntil":       instance.WaitUntil,
+		"cleanupOn":       instance.CleanupOn,
+		"onDuplicate":     instance.OnDuplicate,
+		"transformations": instance.Transformations,
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/template/chart"
+	"io"
+	"path/filepath"
+)
+
+// Chart is a Helm chart (a directory or a .tgz archive) which is rendered
+// together with the templates of the project.
+type Chart struct {
+	// Path is a pattern (like templateFilePattern) of the chart directory or
+	// archive.
+	Path string `yaml:"path" json:"path"`
+	// ValuesPath selects the values (like redis.master) which overlay the
+	// values.yaml of the chart. If empty only the values.yaml is used.
+	ValuesPath string `yaml:"valuesPath,omitempty" json:"valuesPath,omitempty"`
+	// ReleaseName is provided as .Release.Name; if empty the artifactId is used.
+	ReleaseName string `yaml:"releaseName,omitempty" json:"releaseName,omitempty"`
+	// Namespace is provided as .Release.Namespace.
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+type Charts []Chart
+
+func (instance Charts) Validate() error {
+	for i, c := range instance {
+		if c.Path == "" {
+			return fmt.Errorf("templating.charts[%d].path should not be empty", i)
+		}
+		if c.ValuesPath != "" {
+			if _, err := ParseValuePath(c.ValuesPath); err != nil {
+				return fmt.Errorf("templating.charts[%d].valuesPath: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RenderedProvider provides every rendered document of every chart. Charts
+// are rendered not before their first document is requested.
+func (instance Charts) RenderedProvider(project Project) (ContentProvider, error) {
+	var sources []string
+	var definitions []Chart
+	for _, c := range instance {
+		files, err := renderFilePatterns([]string{c.Path}, "chart", project)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			sources = append(sources, file)
+			definitions = append(definitions, c)
+		}
+	}
+
+	var pending []chart.Document
+	var current string
+	i := 0
+	return func() (string, []byte, error) {
+		for len(pending) == 0 {
+			if i >= len(sources) {
+				return "", nil, io.EOF
+			}
+			current = sources[i]
+			documents, err := definitions[i].render(current, project)
+			i++
+			if err != nil {
+				return current, nil, err
+			}
+			pending = documents
+		}
+		document := pending[0]
+		pending = pending[1:]
+		return filepath.Join(current, filepath.FromSlash(document.File)), document.Content, nil
+	}, nil
+}
+
+func (instance Chart) render(source string, project Project) ([]chart.Document, error) {
+	c, err := chart.Load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if instance.ValuesPath != "" {
+		valuesPath, err := ParseValuePath(instance.ValuesPath)
+		if err != nil {
+			return nil, err
+		}
+		var current interface{} = map[string]interface{}(project.Values)
+		for _, key := range valuesPath {
+			if m, ok := normalizeValue(current).(map[string]interface{}); ok {
+				current = m[key]
+			} else {
+				current = nil
+			}
+		}
+		values, _ = normalizeValue(current).(map[string]interface{})
+	}
+
+	release := chart.Release{
+		Name:      instance.ReleaseName,
+		Namespace: instance.Namespace,
+		Service:   "kubor",
+		Revision:  1,
+		IsInstall: true,
+	}
+	if release.Name == "" {
+		release.Name = project.ArtifactId.String()
+	}
+
+	return c.Render(release, values, chart.DefaultCapabilities())
+}
+
+func concatContentProviders(providers ...ContentProvider) ContentProvider {
+	return func() (string, []byte, error) {
+		for len(providers) > 0 {
+			name, content, err := providers[0]()
+			if err != io.EOF {
+				return name, content, err
+			}
+			providers = providers[1:]
+		}
+		return "", nil, io.EOF
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Chart_render(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-chart")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte("name: foo\nversion: 1.0.0\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicas: 1\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "templates", "a.yaml"), []byte("replicas: {{ .Values.replicas }}\nsecret: {{ .Values.secret }}\n"), 0644))
+	project := Project{
+		ArtifactId: "app",
+		Values:     Values{"secret": "s3cr3t", "foo": map[string]interface{}{"replicas": 3}},
+	}
+
+	cases := []struct {
+		valuesPath string
+		expected   string
+	}{
+		{"", "replicas: 1\nsecret: \n"},
+		{"foo", "replicas: 3\nsecret: \n"},
+		{"unknown", "replicas: 1\nsecret: \n"},
+	}
+	for _, c := range cases {
+		actual, err := Chart{Path: dir, ValuesPath: c.valuesPath}.render(dir, project)
+		require.NoError(t, err, c.valuesPath)
+		require.Len(t, actual, 1, c.valuesPath)
+		assert.Equal(t, c.expected, string(actual[0].Content), c.valuesPath)
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"path"
+)
+
+// AllowedEnv contains names or glob patterns (like CI_*) of environment
+// variables which are available while rendering (as .Env and by functions
+// like env). If it is not set all environment variables are available.
+// Transformations (like gitlab) only see the allowed variables, too.
+type AllowedEnv []string
+
+func (instance AllowedEnv) Validate() error {
+	for _, pattern := range instance {
+		if pattern == "" {
+			return fmt.Errorf("allowedEnv should not contain an empty pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("illegal allowedEnv '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Allows returns true if the environment variable with the given name is
+// allowed.
+func (instance AllowedEnv) Allows(name string) bool {
+	if instance == nil {
+		return true
+	}
+	for _, pattern := range instance {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a copy of env which contains only the allowed variables.
+// The secret key (see SecretKeyEnvar) is never contained, so it cannot be
+// leaked by templates or plugins.
+func (instance AllowedEnv) Filter(env map[string]string) map[string]string {
+	result := make(map[string]string, len(env))
+	for name, value := range env {
+		if name != SecretKeyEnvar && instance.Allows(name) {
+			result[name] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_AllowedEnv_Filter(t *testing.T) {
+	env := map[string]string{"CI_COMMIT_SHA": "abc", "CI_JOB_TOKEN": "secret", "HOME": "/root", "BUILD_NUMBER": "7"}
+
+	assert.Equal(t, env, AllowedEnv(nil).Filter(env))
+	assert.Equal(t, map[string]string{}, AllowedEnv{}.Filter(env))
+	assert.Equal(t, map[string]string{"CI_COMMIT_SHA": "abc", "BUILD_NUMBER": "7"}, AllowedEnv{"CI_COMMIT_*", "BUILD_NUMBER"}.Filter(env))
+
+	withKey := map[string]string{"HOME": "/root", SecretKeyEnvar: "key"}
+	assert.Equal(t, map[string]string{"HOME": "/root"}, AllowedEnv(nil).Filter(withKey))
+	assert.Equal(t, map[string]string{}, AllowedEnv{"KUBOR_*"}.Filter(withKey))
+
+	assert.NoError(t, AllowedEnv{"CI_*"}.Validate())
+	assert.Error(t, AllowedEnv{"CI_["}.Validate())
+}
+
+func Test_Project_env_onlyAllowed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-env")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".kubor.yml"), []byte("artifactId: demo\nallowedEnv: [CI_COMMIT_SHA]\n"), 0644))
+	require.NoError(t, os.Setenv("CI_JOB_TOKEN", "process"))
+	//noinspection GoUnhandledErrorResult
+	defer os.Unsetenv("CI_JOB_TOKEN")
+
+	project, err := NewProjectFactory().
+		ForSource(filepath.Join(dir, ".kubor.yml")).
+		WithEnv(map[string]string{"CI_COMMIT_SHA": "abc", "CI_JOB_TOKEN": "secret"}).
+		Create("")
+	require.NoError(t, err)
+
+	tmpl, err := project.Templating.TemplateFactory().New("test", `{{ env "CI_COMMIT_SHA" }}|{{ env "CI_JOB_TOKEN" }}|{{ envOr "CI_JOB_TOKEN" "none" }}`)
+	require.NoError(t, err)
+	actual, err := tmpl.ExecuteToString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc||none", actual)
+}
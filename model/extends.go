@@ -0,0 +1,227 @@
+package model
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// extendsConcatenatedPaths are lists which are concatenated (base first)
+	// instead of replaced if a project extends another one.
+	extendsConcatenatedPaths = map[string]bool{
+		"allowedEnv":         true,
+		"values":             true,
+		"valueFiles":         true,
+		"policies.files":     true,
+		"policies.rules":     true,
+		"templating.plugins": true,
+	}
+	// extendsRelativePaths are paths (or lists of paths) which are resolved
+	// against the directory of the base file they are defined in.
+	extendsRelativePaths = [][]string{
+		{"valueFiles"},
+		{"valuesSchema"},
+		{"policies", "files"},
+		{"templating", "templateFilePattern"},
+		{"templating", "testFilePattern"},
+	}
+)
+
+// Extends contains paths or glob patterns of project files the project is
+// based on. Relative paths are resolved against the directory of the file
+// which contains the extends. Relative file paths inside of a base (like
+// valueFiles, policies.files, templating.templateFilePattern or commands of
+// templating.plugins) are resolved against the directory of the base, too.
+// Paths starting with a template (like {{ .Root }}/values.yml) are kept.
+type Extends []string
+
+func (instance *Extends) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain string
+	if err := unmarshal(&plain); err == nil {
+		*instance = Extends{plain}
+		return nil
+	}
+	var plains []string
+	if err := unmarshal(&plains); err != nil {
+		return err
+	}
+	*instance = plains
+	return nil
+}
+
+// valuesSource is the location of an entry of values inside of a project file.
+type valuesSource struct {
+	file  string
+	index int
+}
+
+// loadProjectSource reads the given project file and all project files it
+// extends (recursively). The result is the merged raw content: the content of
+// every extending file overlays the content of its bases. Maps are merged
+// recursively, a null value removes a key and lists are replaced - except the
+// ones of extendsConcatenatedPaths which are concatenated. Additionally it
+// returns for every entry of the merged values the file it is coming from.
+func loadProjectSource(file string) (map[string]interface{}, []valuesSource, error) {
+	return loadProjectSourceWith(file, nil)
+}
+
+func loadProjectSourceWith(file string, chain []string) (map[string]interface{}, []valuesSource, error) {
+	absolute, err := filepath.Abs(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot resolve source file '%s': %w", file, err)
+	}
+	for i, candidate := range chain {
+		if candidate == absolute {
+			return nil, nil, fmt.Errorf("cyclic extends: %s", strings.Join(append(chain[i:], absolute), " -> "))
+		}
+	}
+	isBase := len(chain) > 0
+	chain = append(chain, absolute)
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read source file '%s': %w", file, err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, nil, fmt.Errorf("cannot read source file '%s': %w", file, err)
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	raw = normalizeValue(raw).(map[string]interface{})
+
+	var extends Extends
+	if plain, ok := raw["extends"]; ok {
+		if b, err := yaml.Marshal(plain); err != nil {
+			return nil, nil, fmt.Errorf("cannot read extends of source file '%s': %w", file, err)
+		} else if err := yaml.Unmarshal(b, &extends); err != nil {
+			return nil, nil, fmt.Errorf("cannot read extends of source file '%s': %w", file, err)
+		}
+		delete(raw, "extends")
+	}
+	if isBase {
+		rebaseProjectSourcePaths(raw, filepath.Dir(absolute))
+	}
+
+	result := map[string]interface{}{}
+	var sources []valuesSource
+	for _, pattern := range extends {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(file), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot handle extends '%s' of source file '%s': %w", pattern, file, err)
+		}
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("there does not at least one file exist that matches extends '%s' of source file '%s'", pattern, file)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			base, baseSources, err := loadProjectSourceWith(match, chain)
+			if err != nil {
+				return nil, nil, err
+			}
+			result = mergeProjectSources(nil, result, base)
+			sources = append(sources, baseSources...)
+		}
+	}
+
+	if plain, ok := raw["values"]; ok {
+		entries, isList := plain.([]interface{})
+		if !isList {
+			sources = nil
+		}
+		for i := range entries {
+			sources = append(sources, valuesSource{file: file, index: i})
+		}
+	}
+
+	return mergeProjectSources(nil, result, raw), sources, nil
+}
+
+func mergeProjectSources(path []string, base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range overlay {
+		childPath := append(append([]string{}, path...), key)
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		switch o := value.(type) {
+		case map[string]interface{}:
+			if b, ok := result[key].(map[string]interface{}); ok {
+				result[key] = mergeProjectSources(childPath, b, o)
+				continue
+			}
+		case []interface{}:
+			if b, ok := result[key].([]interface{}); ok && extendsConcatenatedPaths[strings.Join(childPath, ".")] {
+				result[key] = append(append([]interface{}{}, b...), o...)
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// rebaseProjectSourcePaths resolves all relative paths of the given raw
+// project source (see extendsRelativePaths) against dir.
+func rebaseProjectSourcePaths(raw map[string]interface{}, dir string) {
+	for _, path := range extendsRelativePaths {
+		parent := raw
+		for _, key := range path[:len(path)-1] {
+			parent, _ = parent[key].(map[string]interface{})
+		}
+		if parent == nil {
+			continue
+		}
+		key := path[len(path)-1]
+		switch v := parent[key].(type) {
+		case string:
+			parent[key] = rebasePattern(v, dir)
+		case []interface{}:
+			for i, candidate := range v {
+				if pattern, ok := candidate.(string); ok {
+					v[i] = rebasePattern(pattern, dir)
+				}
+			}
+		}
+	}
+
+	// Executables of plugins are only resolved against the project root if
+	// they contain a path separator; the others are looked up in PATH.
+	templating, _ := raw["templating"].(map[string]interface{})
+	plugins, _ := templating["plugins"].([]interface{})
+	for _, candidate := range plugins {
+		plugin, _ := candidate.(map[string]interface{})
+		command, _ := plugin["command"].([]interface{})
+		if len(command) == 0 {
+			continue
+		}
+		if executable, ok := command[0].(string); ok && strings.ContainsAny(executable, "/"+string(filepath.Separator)) {
+			command[0] = rebasePattern(executable, dir)
+		}
+	}
+}
+
+// rebasePattern resolves the given (maybe optional, see renderFilePatterns)
+// pattern against dir if it is relative.
+func rebasePattern(pattern string, dir string) string {
+	prefix := ""
+	if strings.HasPrefix(pattern, "?") {
+		prefix, pattern = "?", pattern[1:]
+	}
+	if pattern == "" || filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "{{") {
+		return prefix + pattern
+	}
+	return prefix + filepath.Join(dir, pattern)
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_loadProjectSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-extends")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	shared := filepath.Join(dir, "rebasesPathsOfBases", "shared")
+
+	cases := []struct {
+		name     string
+		files    map[string]string
+		source   string
+		expected map[string]interface{}
+		error    string
+	}{{
+		name: "mergesBases",
+		files: map[string]string{
+			"base/a.yml": "groupId: base\nlabels:\n  a: 1\n  b: 2\nvalueFiles:\n- a.yml\nstages:\n- first\n",
+			"base/b.yml": "labels:\n  c: 3\n",
+			".kubor.yml": "extends: base/*.yml\nartifactId: child\nlabels:\n  b: 20\n  c: null\nvalueFiles:\n- b.yml\nstages:\n- second\n",
+		},
+		source: ".kubor.yml",
+		expected: map[string]interface{}{
+			"groupId":    "base",
+			"artifactId": "child",
+			"labels":     map[string]interface{}{"a": 1, "b": 20},
+			"valueFiles": []interface{}{filepath.Join(dir, "mergesBases", "base", "a.yml"), "b.yml"},
+			"stages":     []interface{}{"second"},
+		},
+	}, {
+		name: "rebasesPathsOfBases",
+		files: map[string]string{
+			"shared/base.yml": `
+valueFiles: [values.yml, "?missing.yml", "{{ .Root }}/root.yml", /abs/values.yml]
+valuesSchema: schema.json
+policies:
+  files: [policies/*.yml]
+templating:
+  templateFilePattern: [templates/*.yml]
+  plugins:
+  - name: a
+    command: [./plugin.sh, arg/x]
+  - name: b
+    command: [jq, .]
+`,
+			"project/.kubor.yml": "extends: ../shared/base.yml\nvalueFiles: [own.yml]\n",
+		},
+		source: "project/.kubor.yml",
+		expected: map[string]interface{}{
+			"valueFiles": []interface{}{
+				filepath.Join(shared, "values.yml"),
+				"?" + filepath.Join(shared, "missing.yml"),
+				"{{ .Root }}/root.yml",
+				"/abs/values.yml",
+				"own.yml",
+			},
+			"valuesSchema": filepath.Join(shared, "schema.json"),
+			"policies": map[string]interface{}{
+				"files": []interface{}{filepath.Join(shared, "policies", "*.yml")},
+			},
+			"templating": map[string]interface{}{
+				"templateFilePattern": []interface{}{filepath.Join(shared, "templates", "*.yml")},
+				"plugins": []interface{}{
+					map[string]interface{}{"name": "a", "command": []interface{}{filepath.Join(shared, "plugin.sh"), "arg/x"}},
+					map[string]interface{}{"name": "b", "command": []interface{}{"jq", "."}},
+				},
+			},
+		},
+	}, {
+		name: "detectsCycles",
+		files: map[string]string{
+			"a.yml":      "extends: b.yml\n",
+			"b.yml":      "extends: [a.yml]\n",
+			".kubor.yml": "extends: a.yml\n",
+		},
+		source: ".kubor.yml",
+		error:  "cyclic extends",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := filepath.Join(dir, c.name)
+			for name, content := range c.files {
+				file := filepath.Join(root, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+				require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+			}
+
+			actual, _, err := loadProjectSource(filepath.Join(root, c.source))
+			if c.error != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.error)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func Test_ProjectFactory_Create_loadsValueFilesOfBases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-extends")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shared"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "project"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shared", "base.yml"), []byte("valueFiles: [values.yml]\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shared", "values.yml"), []byte("a: shared\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "project", ".kubor.yml"), []byte("extends: ../shared/base.yml\ngroupId: a\nartifactId: app\n"), 0644))
+
+	actual, err := NewProjectFactory().ForSource(filepath.Join(dir, "project", ".kubor.yml")).Create("")
+	require.NoError(t, err)
+	assert.Equal(t, Values{"a": "shared"}, actual.Values)
+}
+
+func Test_ProjectFactory_Create_explainsValuesOfBases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-extends")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "base"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "base", "base.yml"), []byte("values:\n- a: base\n- \"on\": \"{{ .Values.a }}=other\"\n  b: base\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".kubor.yml"), []byte("extends: base/base.yml\ngroupId: a\nartifactId: app\nvalues:\n- c: child\n"), 0644))
+
+	actual, err := NewProjectFactory().ForSource(filepath.Join(dir, ".kubor.yml")).Create("")
+	require.NoError(t, err)
+
+	base := filepath.Join(dir, "base", "base.yml")
+	assert.Equal(t, ValueOrigin{Kind: ValueOriginDefault, Source: base, Index: 0}, actual.ValuesExplanation.Origins["a"])
+	assert.Equal(t, ValueOrigin{Kind: ValueOriginDefault, Source: filepath.Join(dir, ".kubor.yml"), Index: 0}, actual.ValuesExplanation.Origins["c"])
+	require.Len(t, actual.ValuesExplanation.Skipped, 1)
+	assert.Equal(t, base, actual.ValuesExplanation.Skipped[0].Source)
+	assert.Equal(t, 1, actual.ValuesExplanation.Skipped[0].Index)
+}
@@ -0,0 +1,470 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/echocat/kubor/log"
+	"github.com/echocat/kubor/template"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// LibraryReferencePrefix marks paths (like in include or readFile) which
+	// reference a file of a library: @<library>/<file>
+	LibraryReferencePrefix = "@"
+
+	libraryVendorDirectory = ".kubor/vendor"
+	libraryLockFile        = ".kubor/libraries.lock"
+	libraryChecksumPrefix  = "sha256:"
+)
+
+var (
+	libraryNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	// libraryScpUrlRegexp matches the scp like syntax of git: <user>@<host>:<path>
+	libraryScpUrlRegexp = regexp.MustCompile(`^[^/@:]+@[^/:]+:`)
+)
+
+// Library is a bundle of template files which is vendored using "kubor vendor"
+// into .kubor/vendor/<name> and could be referenced using @<name>/<file>.
+type Library struct {
+	Name string `yaml:"name" json:"name"`
+	// Git is the URL of the repository which contains the library. Relative
+	// paths are resolved relative to the project.
+	Git string `yaml:"git,omitempty" json:"git,omitempty"`
+	// Path is a local directory which contains the library. It is resolved
+	// relative to the project.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Version is the branch, tag or commit of Git; if empty the default branch
+	// is used.
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	// Checksum (sha256:<hex>) the vendored library has to match if set.
+	Checksum string `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+}
+
+func (instance Library) String() string {
+	if instance.Git != "" {
+		if instance.Version != "" {
+			return fmt.Sprintf("%s (%s@%s)", instance.Name, instance.Git, instance.Version)
+		}
+		return fmt.Sprintf("%s (%s)", instance.Name, instance.Git)
+	}
+	return fmt.Sprintf("%s (%s)", instance.Name, instance.Path)
+}
+
+func (instance Library) matches(locked LockedLibrary) bool {
+	return instance.Name == locked.Name &&
+		instance.Git == locked.Git &&
+		instance.Path == locked.Path &&
+		instance.Version == locked.Version
+}
+
+type Libraries []Library
+
+func (instance Libraries) Validate() error {
+	seen := map[string]bool{}
+	for i, l := range instance {
+		if !libraryNameRegexp.MatchString(l.Name) {
+			return fmt.Errorf("libraries[%d].name '%s' is not a valid name", i, l.Name)
+		}
+		if seen[l.Name] {
+			return fmt.Errorf("library %s is defined more than once", l.Name)
+		}
+		seen[l.Name] = true
+		if (l.Git == "") == (l.Path == "") {
+			return fmt.Errorf("libraries[%d] requires either git or path", i)
+		}
+		if l.Path != "" && l.Version != "" {
+			return fmt.Errorf("libraries[%d].version is only supported for git", i)
+		}
+		if l.Checksum != "" && !strings.HasPrefix(l.Checksum, libraryChecksumPrefix) {
+			return fmt.Errorf("libraries[%d].checksum has to start with %s", i, libraryChecksumPrefix)
+		}
+	}
+	return nil
+}
+
+func (instance Libraries) Get(name string) (Library, bool) {
+	for _, l := range instance {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Library{}, false
+}
+
+// LockedLibrary records which version of a Library was vendored.
+type LockedLibrary struct {
+	Name     string `yaml:"name" json:"name"`
+	Git      string `yaml:"git,omitempty" json:"git,omitempty"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	Version  string `yaml:"version,omitempty" json:"version,omitempty"`
+	Commit   string `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Checksum string `yaml:"checksum" json:"checksum"`
+}
+
+type LibrariesLock struct {
+	Libraries []LockedLibrary `yaml:"libraries" json:"libraries"`
+}
+
+func (instance LibrariesLock) Get(name string) (LockedLibrary, bool) {
+	for _, l := range instance.Libraries {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return LockedLibrary{}, false
+}
+
+// LoadLibrariesLock reads the lock file of the given project root. If it does
+// not exist an empty lock is returned.
+func LoadLibrariesLock(root string) (LibrariesLock, error) {
+	file := filepath.Join(root, libraryLockFile)
+	var result LibrariesLock
+	if b, err := ioutil.ReadFile(file); os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return result, fmt.Errorf("cannot read libraries lock file '%s': %w", file, err)
+	} else if err := yaml.Unmarshal(b, &result); err != nil {
+		return result, fmt.Errorf("cannot read libraries lock file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+func (instance LibrariesLock) Save(root string) error {
+	file := filepath.Join(root, libraryLockFile)
+	b, err := yaml.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("cannot write libraries lock file '%s': %w", file, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("cannot write libraries lock file '%s': %w", file, err)
+	}
+	if err := ioutil.WriteFile(file, b, 0644); err != nil {
+		return fmt.Errorf("cannot write libraries lock file '%s': %w", file, err)
+	}
+	return nil
+}
+
+// LibraryDirectory returns the directory the library with the given name is
+// vendored to.
+func LibraryDirectory(root string, name string) string {
+	return filepath.Join(root, filepath.FromSlash(libraryVendorDirectory), name)
+}
+
+// PathResolver resolves references like @<library>/<file> to the vendored
+// files. Every library is verified against the lock file (and its configured
+// checksum) on its first usage.
+func (instance Libraries) PathResolver(root string) template.PathResolver {
+	if len(instance) == 0 {
+		return nil
+	}
+	var mutex sync.Mutex
+	verified := map[string]error{}
+	var lock *LibrariesLock
+
+	verify := func(library Library) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err, ok := verified[library.Name]; ok {
+			return err
+		}
+		if lock == nil {
+			l, err := LoadLibrariesLock(root)
+			if err != nil {
+				return err
+			}
+			lock = &l
+		}
+		err := library.verify(root, *lock)
+		verified[library.Name] = err
+		return err
+	}
+
+	return func(path string) (string, bool, error) {
+		if !strings.HasPrefix(path, LibraryReferencePrefix) {
+			return "", false, nil
+		}
+		parts := strings.SplitN(filepath.ToSlash(path[len(LibraryReferencePrefix):]), "/", 2)
+		library, ok := instance.Get(parts[0])
+		if !ok {
+			return "", false, fmt.Errorf("%s references unknown library %s", path, parts[0])
+		}
+		if err := verify(library); err != nil {
+			return "", false, err
+		}
+		dir := LibraryDirectory(root, library.Name)
+		if len(parts) == 1 {
+			return dir, true, nil
+		}
+		resolved := filepath.Join(dir, filepath.FromSlash(parts[1]))
+		if resolved != dir && !strings.HasPrefix(resolved, dir+string(filepath.Separator)) {
+			return "", false, fmt.Errorf("%s points outside of library %s", path, library.Name)
+		}
+		return resolved, true, nil
+	}
+}
+
+func (instance Library) verify(root string, lock LibrariesLock) error {
+	locked, ok := lock.Get(instance.Name)
+	if !ok {
+		return fmt.Errorf("library %v is not vendored; run 'kubor vendor'", instance)
+	}
+	if !instance.matches(locked) {
+		return fmt.Errorf("library %v has changed since it was vendored; run 'kubor vendor'", instance)
+	}
+	dir := LibraryDirectory(root, instance.Name)
+	checksum, err := LibraryChecksum(dir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("library %v is not vendored; run 'kubor vendor'", instance)
+	} else if err != nil {
+		return err
+	}
+	if checksum != locked.Checksum {
+		return fmt.Errorf("vendored library %s was modified (expected checksum %s but got %s); run 'kubor vendor'", instance.Name, locked.Checksum, checksum)
+	}
+	if instance.Checksum != "" && instance.Checksum != checksum {
+		return fmt.Errorf("library %s has checksum %s but %s is expected", instance.Name, checksum, instance.Checksum)
+	}
+	return nil
+}
+
+// LibraryChecksum calculates the checksum over all files (names and contents)
+// of the given directory.
+func LibraryChecksum(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+	summary := new(bytes.Buffer)
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		//noinspection GoUnhandledErrorResult
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), filepath.ToSlash(rel))
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("cannot calculate checksum of '%s': %w", dir, err)
+	}
+	sum := sha256.Sum256(summary.Bytes())
+	return libraryChecksumPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// Vendor fetches all libraries into .kubor/vendor and writes the lock file.
+// Libraries which are already locked are fetched in the locked commit unless
+// update is true. Directories of libraries which are no longer defined are
+// removed.
+func (instance Libraries) Vendor(root string, update bool) (LibrariesLock, error) {
+	lock, err := LoadLibrariesLock(root)
+	if err != nil {
+		return LibrariesLock{}, err
+	}
+	vendorDir := filepath.Join(root, filepath.FromSlash(libraryVendorDirectory))
+	if err := os.MkdirAll(vendorDir, 0755); err != nil {
+		return LibrariesLock{}, fmt.Errorf("cannot create vendor directory '%s': %w", vendorDir, err)
+	}
+
+	result := LibrariesLock{Libraries: []LockedLibrary{}}
+	for _, library := range instance {
+		var previous *LockedLibrary
+		if candidate, ok := lock.Get(library.Name); ok && !update && library.matches(candidate) {
+			previous = &candidate
+		}
+		locked, err := library.vendor(root, vendorDir, previous)
+		if err != nil {
+			return LibrariesLock{}, err
+		}
+		result.Libraries = append(result.Libraries, locked)
+	}
+
+	if err := instance.prune(vendorDir); err != nil {
+		return LibrariesLock{}, err
+	}
+	if err := result.Save(root); err != nil {
+		return LibrariesLock{}, err
+	}
+	return result, nil
+}
+
+func (instance Library) vendor(root string, vendorDir string, previous *LockedLibrary) (LockedLibrary, error) {
+	tmp, err := ioutil.TempDir(vendorDir, ".fetch-")
+	if err != nil {
+		return LockedLibrary{}, fmt.Errorf("cannot fetch library %v: %w", instance, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(tmp)
+	fetched := filepath.Join(tmp, instance.Name)
+
+	result := LockedLibrary{
+		Name:    instance.Name,
+		Git:     instance.Git,
+		Path:    instance.Path,
+		Version: instance.Version,
+	}
+	if instance.Git != "" {
+		revision := instance.Version
+		if previous != nil && previous.Commit != "" {
+			revision = previous.Commit
+		}
+		if result.Commit, err = fetchGitLibrary(resolveLibrarySource(root, instance.Git), revision, fetched); err != nil {
+			return LockedLibrary{}, fmt.Errorf("cannot fetch library %v: %w", instance, err)
+		}
+	} else if err := copyDirectory(resolveLibrarySource(root, instance.Path), fetched); err != nil {
+		return LockedLibrary{}, fmt.Errorf("cannot fetch library %v: %w", instance, err)
+	}
+
+	if result.Checksum, err = LibraryChecksum(fetched); err != nil {
+		return LockedLibrary{}, err
+	}
+	if instance.Checksum != "" && instance.Checksum != result.Checksum {
+		return LockedLibrary{}, fmt.Errorf("library %v has checksum %s but %s is expected", instance, result.Checksum, instance.Checksum)
+	}
+	if previous != nil && previous.Commit == "" && previous.Checksum != result.Checksum {
+		log.WithField("library", instance.Name).
+			WithField("checksum", result.Checksum).
+			Info("Content of library %v changed since it was locked.", instance)
+	}
+
+	target := LibraryDirectory(root, instance.Name)
+	if err := os.RemoveAll(target); err != nil {
+		return LockedLibrary{}, fmt.Errorf("cannot replace vendored library %s: %w", instance.Name, err)
+	}
+	if err := os.Rename(fetched, target); err != nil {
+		return LockedLibrary{}, fmt.Errorf("cannot replace vendored library %s: %w", instance.Name, err)
+	}
+	return result, nil
+}
+
+func (instance Libraries) prune(vendorDir string) error {
+	entries, err := ioutil.ReadDir(vendorDir)
+	if err != nil {
+		return fmt.Errorf("cannot read vendor directory '%s': %w", vendorDir, err)
+	}
+	for _, entry := range entries {
+		if _, ok := instance.Get(entry.Name()); !ok && !strings.HasPrefix(entry.Name(), ".fetch-") {
+			if err := os.RemoveAll(filepath.Join(vendorDir, entry.Name())); err != nil {
+				return fmt.Errorf("cannot remove no longer defined library %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveLibrarySource resolves local paths relative to the project root.
+// URLs (like https://... or git@host:...) are returned as they are.
+func resolveLibrarySource(root string, source string) string {
+	if strings.Contains(source, "://") || libraryScpUrlRegexp.MatchString(source) || filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(root, source)
+}
+
+func fetchGitLibrary(url string, revision string, target string) (string, error) {
+	if strings.HasPrefix(revision, "-") {
+		return "", fmt.Errorf("illegal revision '%s'", revision)
+	}
+	if _, err := runGit("", "clone", "--quiet", "--no-checkout", "--", url, target); err != nil {
+		return "", err
+	}
+	if revision == "" {
+		revision = "HEAD"
+	}
+	// After cloning only the default branch exists locally; other branches
+	// are only available as remote branches.
+	commit, err := runGit(target, "rev-parse", "--verify", "--quiet", "origin/"+revision+"^{commit}")
+	if err != nil {
+		if commit, err = runGit(target, "rev-parse", "--verify", "--quiet", revision+"^{commit}"); err != nil {
+			return "", fmt.Errorf("cannot resolve revision '%s': %w", revision, err)
+		}
+	}
+	if _, err := runGit(target, "checkout", "--quiet", "--detach", commit); err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(filepath.Join(target, ".git")); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func copyDirectory(source string, target string) error {
+	if fi, err := os.Stat(source); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", source)
+	}
+	var files []string
+	if err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(target, rel), 0755)
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(filepath.Join(source, file))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(target, file), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
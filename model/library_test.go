@@ -0,0 +1,144 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Libraries_Vendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-library")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib", "partials"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "project"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", "labels.tpl"), []byte("app: {{ .ArtifactId }}\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", "partials", "extra.tpl"), []byte("extra: true\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "project", ".kubor.yml"), []byte("artifactId: demo\n"), 0644))
+	root := filepath.Join(dir, "project")
+	libraries := Libraries{{Name: "common", Path: "../lib"}}
+	require.NoError(t, libraries.Validate())
+
+	resolver := libraries.PathResolver(root)
+	_, _, err = resolver("@common/labels.tpl")
+	assert.EqualError(t, err, "library common (../lib) is not vendored; run 'kubor vendor'")
+
+	lock, err := libraries.Vendor(root, false)
+	require.NoError(t, err)
+	require.Len(t, lock.Libraries, 1)
+	checksum, err := LibraryChecksum(filepath.Join(dir, "lib"))
+	require.NoError(t, err)
+	assert.Equal(t, checksum, lock.Libraries[0].Checksum)
+
+	loaded, err := LoadLibrariesLock(root)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	resolver = libraries.PathResolver(root)
+	resolved, ok, err := resolver("@common/partials/extra.tpl")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(LibraryDirectory(root, "common"), "partials", "extra.tpl"), resolved)
+
+	_, ok, err = resolver("other.tpl")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = resolver("@common/../../.kubor.yml")
+	assert.EqualError(t, err, "@common/../../.kubor.yml points outside of library common")
+
+	_, _, err = resolver("@unknown/labels.tpl")
+	assert.EqualError(t, err, "@unknown/labels.tpl references unknown library unknown")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(LibraryDirectory(root, "common"), "labels.tpl"), []byte("modified"), 0644))
+	_, _, err = libraries.PathResolver(root)("@common/labels.tpl")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "vendored library common was modified")
+
+	_, err = Libraries{{Name: "common", Path: "../lib", Checksum: "sha256:0000"}}.Vendor(root, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "but sha256:0000 is expected")
+}
+
+func Test_Libraries_Vendor_git(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-library")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	repo := filepath.Join(dir, "lib")
+	root := filepath.Join(dir, "project")
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	require.NoError(t, os.MkdirAll(root, 0755))
+	git := func(args ...string) string {
+		out, err := runGit(repo, append([]string{"-c", "user.name=test", "-c", "user.email=test@example.org"}, args...)...)
+		require.NoError(t, err)
+		return out
+	}
+	commit := func(content string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repo, "version.tpl"), []byte(content), 0644))
+		git("add", "version.tpl")
+		git("commit", "--quiet", "-m", content)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "--quiet")
+	first := commit("first")
+	git("tag", "v1")
+	git("checkout", "--quiet", "-b", "feature")
+	feature := commit("feature")
+	git("checkout", "--quiet", "-")
+	main := commit("main")
+
+	cases := []struct {
+		version  string
+		commit   string
+		expected string
+	}{
+		{"", main, "main"},
+		{"feature", feature, "feature"},
+		{"v1", first, "first"},
+		{first, first, "first"},
+	}
+	for _, c := range cases {
+		libraries := Libraries{{Name: "common", Git: "../lib", Version: c.version}}
+		lock, err := libraries.Vendor(root, true)
+		require.NoError(t, err, c.version)
+		require.Len(t, lock.Libraries, 1, c.version)
+		assert.Equal(t, c.commit, lock.Libraries[0].Commit, c.version)
+		content, err := ioutil.ReadFile(filepath.Join(LibraryDirectory(root, "common"), "version.tpl"))
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, string(content), c.version)
+	}
+
+	_, err = Libraries{{Name: "common", Git: "../lib", Version: "unknown"}}.Vendor(root, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot resolve revision 'unknown'")
+}
+
+func Test_Libraries_Validate(t *testing.T) {
+	assert.EqualError(t, Libraries{{Name: "a"}}.Validate(), "libraries[0] requires either git or path")
+	assert.EqualError(t, Libraries{{Name: "a", Path: "x"}, {Name: "a", Path: "y"}}.Validate(), "library a is defined more than once")
+	assert.EqualError(t, Libraries{{Name: "a/b", Path: "x"}}.Validate(), "libraries[0].name 'a/b' is not a valid name")
+	assert.EqualError(t, Libraries{{Name: "a", Path: "x", Version: "v1"}}.Validate(), "libraries[0].version is only supported for git")
+}
+
+func Test_resolveLibrarySource(t *testing.T) {
+	root := filepath.Join("project", "root")
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"https://example.org/lib.git", "https://example.org/lib.git"},
+		{"ssh://git@example.org/lib.git", "ssh://git@example.org/lib.git"},
+		{"git@example.org:team/lib.git", "git@example.org:team/lib.git"},
+		{"libs/common", filepath.Join(root, "libs", "common")},
+		{"./foo@bar/lib", filepath.Join(root, "foo@bar", "lib")},
+		{"foo@bar/lib", filepath.Join(root, "foo@bar", "lib")},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, resolveLibrarySource(root, c.source), c.source)
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type OnDuplicate uint8
+
+const (
+	OnDuplicateFail  = OnDuplicate(0)
+	OnDuplicateMerge = OnDuplicate(1)
+)
+
+var (
+	ErrIllegalOnDuplicate = errors.New("illegal on-duplicate")
+)
+
+func (instance *OnDuplicate) Set(plain string) error {
+	return instance.UnmarshalText([]byte(plain))
+}
+
+func (instance OnDuplicate) String() string {
+	v, _ := instance.MarshalText()
+	return string(v)
+}
+
+func (instance OnDuplicate) MarshalText() (text []byte, err error) {
+	switch instance {
+	case OnDuplicateFail:
+		return []byte("fail"), nil
+	case OnDuplicateMerge:
+		return []byte("merge"), nil
+	default:
+		return []byte(fmt.Sprintf("illegal-on-duplicate-%d", instance)),
+			fmt.Errorf("%w: %d", ErrIllegalOnDuplicate, instance)
+	}
+}
+
+func (instance *OnDuplicate) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "fail", "", "default":
+		*instance = OnDuplicateFail
+		return nil
+	case "merge":
+		*instance = OnDuplicateMerge
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrIllegalOnDuplicate, string(text))
+	}
+}
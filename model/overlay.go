@@ -0,0 +1,266 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/kubor/kubernetes/overlay"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Overlay combines the plain manifests of a base directory with patches and
+// transformers like kustomize does.
+type Overlay struct {
+	// Base is a pattern (like templateFilePattern) of the directory which
+	// contains the plain manifests (*.yml and *.yaml; kustomization files are
+	// ignored). The manifests are not rendered as templates.
+	Base string `yaml:"base" json:"base"`
+	// PatchesStrategicMerge contains either patterns of patch files or inline
+	// patches. Patch files are rendered as templates.
+	PatchesStrategicMerge []string `yaml:"patchesStrategicMerge,omitempty" json:"patchesStrategicMerge,omitempty"`
+	// PatchesJson6902 are RFC 6902 patches applied to the selected resources.
+	PatchesJson6902 []OverlayJsonPatch `yaml:"patchesJson6902,omitempty" json:"patchesJson6902,omitempty"`
+
+	overlay.Transformers `yaml:",inline" json:",inline"`
+}
+
+type OverlayJsonPatch struct {
+	Target overlay.Target `yaml:"target" json:"target"`
+	// Path is a pattern of a file (YAML or JSON) which contains the
+	// operations. The file is rendered as template.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Patch contains the operations inline (YAML or JSON).
+	Patch string `yaml:"patch,omitempty" json:"patch,omitempty"`
+}
+
+type Overlays []Overlay
+
+func (instance Overlays) Validate() error {
+	for i, o := range instance {
+		if o.Base == "" {
+			return fmt.Errorf("templating.overlays[%d].base should not be empty", i)
+		}
+		for j, p := range o.PatchesJson6902 {
+			if (p.Path == "") == (p.Patch == "") {
+				return fmt.Errorf("templating.overlays[%d].patchesJson6902[%d] requires either path or patch", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// RenderedProvider provides for every file of every base one document which
+// contains all of its (patched and transformed) resources.
+func (instance Overlays) RenderedProvider(project Project) (ContentProvider, error) {
+	var resources []overlay.Resource
+	for _, o := range instance {
+		r, err := o.resources(project)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r...)
+	}
+
+	var sources []string
+	bySource := map[string][]overlay.Resource{}
+	for _, resource := range resources {
+		if _, ok := bySource[resource.Source]; !ok {
+			sources = append(sources, resource.Source)
+		}
+		bySource[resource.Source] = append(bySource[resource.Source], resource)
+	}
+
+	i := 0
+	return func() (string, []byte, error) {
+		if i >= len(sources) {
+			return "", nil, io.EOF
+		}
+		source := sources[i]
+		i++
+		buf := new(bytes.Buffer)
+		for j, resource := range bySource[source] {
+			if j > 0 {
+				buf.WriteString("---\n")
+			}
+			b, err := yaml.Marshal(resource.Object)
+			if err != nil {
+				return source, nil, fmt.Errorf("cannot serialize %v: %w", resource, err)
+			}
+			buf.Write(b)
+		}
+		return source, buf.Bytes(), nil
+	}, nil
+}
+
+// PatchFiles returns all patch files of all overlays. Those are rendered as
+// templates.
+func (instance Overlays) PatchFiles(data interface{}) ([]string, error) {
+	var result []string
+	for _, o := range instance {
+		for _, plain := range o.PatchesStrategicMerge {
+			if strings.Contains(plain, "\n") {
+				continue
+			}
+			files, err := renderFilePatterns([]string{plain}, "overlay patch", data)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		}
+		for _, p := range o.PatchesJson6902 {
+			if p.Path == "" {
+				continue
+			}
+			files, err := renderFilePatterns([]string{p.Path}, "overlay JSON patch", data)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		}
+	}
+	return result, nil
+}
+
+func (instance Overlay) resources(project Project) ([]overlay.Resource, error) {
+	bases, err := renderFilePatterns([]string{instance.Base}, "overlay base", project)
+	if err != nil {
+		return nil, err
+	}
+	var resources []overlay.Resource
+	for _, base := range bases {
+		r, err := loadOverlayBase(base)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r...)
+	}
+
+	for _, plain := range instance.PatchesStrategicMerge {
+		patches, err := instance.loadPatches(plain, project)
+		if err != nil {
+			return nil, err
+		}
+		for _, patch := range patches {
+			if resources, err = overlay.ApplyStrategicMergePatch(resources, patch); err != nil {
+				return nil, fmt.Errorf("overlay of %s: %w", instance.Base, err)
+			}
+		}
+	}
+
+	for _, p := range instance.PatchesJson6902 {
+		operations, err := p.operations(project)
+		if err != nil {
+			return nil, err
+		}
+		if resources, err = overlay.ApplyJsonPatch(resources, p.Target, operations); err != nil {
+			return nil, fmt.Errorf("overlay of %s: %w", instance.Base, err)
+		}
+	}
+
+	instance.Transformers.Apply(resources)
+	return resources, nil
+}
+
+// loadPatches reads the patches of the given file pattern or - if it
+// contains a line break - parses it as inline patches.
+func (instance Overlay) loadPatches(plain string, project Project) ([]map[string]interface{}, error) {
+	if strings.Contains(plain, "\n") {
+		return decodeOverlayDocuments([]byte(plain), "inline patch")
+	}
+	files, err := renderFilePatterns([]string{plain}, "overlay patch", project)
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, file := range files {
+		buf := new(bytes.Buffer)
+		if err := project.Templating.RenderTemplateFile(file, project, buf); err != nil {
+			return nil, err
+		}
+		documents, err := decodeOverlayDocuments(buf.Bytes(), file)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, documents...)
+	}
+	return result, nil
+}
+
+func (instance OverlayJsonPatch) operations(project Project) ([]byte, error) {
+	plain := []byte(instance.Patch)
+	source := "inline JSON patch"
+	if instance.Path != "" {
+		files, err := renderFilePatterns([]string{instance.Path}, "overlay JSON patch", project)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) != 1 {
+			return nil, fmt.Errorf("overlay JSON patch '%s' has to match exactly one file but matches %d", instance.Path, len(files))
+		}
+		buf := new(bytes.Buffer)
+		if err := project.Templating.RenderTemplateFile(files[0], project, buf); err != nil {
+			return nil, err
+		}
+		plain, source = buf.Bytes(), files[0]
+	}
+	var operations []interface{}
+	if err := yaml.Unmarshal(plain, &operations); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", source, err)
+	}
+	return json.Marshal(normalizeValue(operations))
+}
+
+func loadOverlayBase(dir string) ([]overlay.Resource, error) {
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			switch strings.ToLower(filepath.Base(match)) {
+			case "kustomization.yml", "kustomization.yaml":
+			default:
+				files = append(files, match)
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var result []overlay.Resource
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read overlay base file '%s': %w", file, err)
+		}
+		documents, err := decodeOverlayDocuments(b, file)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range documents {
+			result = append(result, overlay.Resource{Source: file, Object: document})
+		}
+	}
+	return result, nil
+}
+
+func decodeOverlayDocuments(content []byte, source string) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document map[string]interface{}
+		if err := decoder.Decode(&document); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot read '%s': %w", source, err)
+		}
+		if len(document) > 0 {
+			result = append(result, normalizeValue(document).(map[string]interface{}))
+		}
+	}
+}
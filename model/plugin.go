@@ -0,0 +1,359 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"github.com/echocat/kubor/template/functions"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PluginTimeoutDefault = 10 * time.Second
+)
+
+var (
+	pluginNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Plugin is a template function which is implemented by an external
+// executable. For every call the executable receives
+// {"function": <name>, "args": [<arguments>]} as JSON on stdin and has to
+// write the result as JSON to stdout. A non-zero exit code fails the call
+// with the content of stderr as message. Calls with the same arguments are
+// only executed once per render.
+type Plugin struct {
+	Name        string            `yaml:"name" json:"name"`
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Parameters  []PluginParameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	Returns     PluginReturn      `yaml:"returns,omitempty" json:"returns,omitempty"`
+	// Command is the executable with its arguments. Executables containing a
+	// path separator are resolved against the root of the project, all others
+	// are looked up in PATH. It runs inside the root of the project with the
+	// environment variables allowed by allowedEnv.
+	Command []string `yaml:"command" json:"command"`
+	// Timeout of each call; default is 10s.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+type PluginParameter struct {
+	Name        string     `yaml:"name" json:"name"`
+	Type        PluginType `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+type PluginReturn struct {
+	Type        PluginType `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// PluginType is one of string, int, float, bool, object, list or any (which
+// is the default).
+type PluginType string
+
+func (instance PluginType) Validate() error {
+	switch instance {
+	case "", "any", "string", "int", "float", "bool", "object", "list":
+		return nil
+	}
+	return fmt.Errorf("unsupported type '%s'; supported are string, int, float, bool, object, list and any", string(instance))
+}
+
+// String returns the type like it is displayed for the built-in functions.
+func (instance PluginType) String() string {
+	switch instance {
+	case "float":
+		return "float64"
+	case "object":
+		return "map[string]any"
+	case "list":
+		return "[]any"
+	case "":
+		return "any"
+	}
+	return string(instance)
+}
+
+// Convert converts the given value (which has to consist only of maps with
+// string keys, slices of interface{} and scalars) into this type.
+func (instance PluginType) Convert(value interface{}) (interface{}, error) {
+	switch instance {
+	case "string":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case "int":
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int32:
+			return int(v), nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v), nil
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case int32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	case "bool":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case "object":
+		if v, ok := value.(map[string]interface{}); ok {
+			return v, nil
+		}
+	case "list":
+		if v, ok := value.([]interface{}); ok {
+			return v, nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("%v (%T) is not of type %v", value, value, instance)
+}
+
+func (instance Plugin) timeout() time.Duration {
+	if d, err := time.ParseDuration(instance.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return PluginTimeoutDefault
+}
+
+func (instance Plugin) function(root string, env map[string]string, cache *pluginCache) functions.Function {
+	result := functions.Function{
+		Parameters: functions.Parameters{{Name: "args"}},
+	}.MustWithFunc(func(context template.ExecutionContext, args ...interface{}) (interface{}, error) {
+		if len(args) != len(instance.Parameters) {
+			return nil, fmt.Errorf("wrong number of args for %s: want %d got %d", instance.Name, len(instance.Parameters), len(args))
+		}
+		converted := make([]interface{}, len(args))
+		for i, arg := range args {
+			v, err := instance.Parameters[i].Type.Convert(pluginPlainOf(arg))
+			if err != nil {
+				return nil, fmt.Errorf("argument #%d (%s) of %s: %w", i, instance.Parameters[i].Name, instance.Name, err)
+			}
+			converted[i] = v
+		}
+		return cache.get(instance, converted, func() (interface{}, error) {
+			return instance.call(root, env, converted)
+		})
+	})
+	result.Description = instance.Description
+	result.Parameters = make(functions.Parameters, len(instance.Parameters))
+	for i, parameter := range instance.Parameters {
+		result.Parameters[i] = functions.Parameter{
+			Name:        parameter.Name,
+			Type:        parameter.Type.String(),
+			Description: parameter.Description,
+		}
+	}
+	result.Returns = functions.Return{
+		Type:        instance.Returns.Type.String(),
+		Description: instance.Returns.Description,
+	}
+	return result
+}
+
+func (instance Plugin) call(root string, env map[string]string, args []interface{}) (interface{}, error) {
+	input, err := json.Marshal(struct {
+		Function string        `json:"function"`
+		Args     []interface{} `json:"args"`
+	}{instance.Name, args})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode arguments of plugin %s: %w", instance.Name, err)
+	}
+
+	executable := instance.Command[0]
+	if strings.ContainsRune(executable, '/') || strings.ContainsRune(executable, filepath.Separator) {
+		if !filepath.IsAbs(executable) {
+			executable = filepath.Join(root, executable)
+		}
+	}
+	cmd := exec.Command(executable, instance.Command[1:]...)
+	configurePluginProcess(cmd)
+	cmd.Dir = root
+	if env != nil {
+		cmd.Env = make([]string, 0, len(env))
+		for name, value := range env {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+		sort.Strings(cmd.Env)
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %w", instance.Name, err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	timer := time.NewTimer(instance.timeout())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		// Kill the children of the plugin, too; otherwise they could still hold
+		// stdout or stderr open and cmd.Wait() would never return.
+		_ = killPluginProcess(cmd)
+		<-done
+		return nil, fmt.Errorf("plugin %s did not respond within %v", instance.Name, instance.timeout())
+	case err = <-done:
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", instance.Name, err, message)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", instance.Name, err)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("plugin %s returned illegal JSON: %w", instance.Name, err)
+	}
+	if result, err = instance.Returns.Type.Convert(result); err != nil {
+		return nil, fmt.Errorf("plugin %s returned illegal result: %w", instance.Name, err)
+	}
+	return result, nil
+}
+
+type Plugins []Plugin
+
+func (instance Plugins) Validate() error {
+	builtIn, err := functions.CategoriesDefault.GetFunctions()
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for i, p := range instance {
+		if !pluginNameRegexp.MatchString(p.Name) {
+			return fmt.Errorf("templating.plugins[%d].name '%s' is not a valid function name", i, p.Name)
+		}
+		if _, ok := builtIn[p.Name]; ok {
+			return fmt.Errorf("templating.plugins[%d].name '%s' conflicts with a built-in function", i, p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("templating.plugins[%d].name '%s' is defined more than once", i, p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Command) == 0 || p.Command[0] == "" {
+			return fmt.Errorf("templating.plugins[%d].command should not be empty", i)
+		}
+		for j, parameter := range p.Parameters {
+			if parameter.Name == "" {
+				return fmt.Errorf("templating.plugins[%d].parameters[%d].name should not be empty", i, j)
+			}
+			if err := parameter.Type.Validate(); err != nil {
+				return fmt.Errorf("templating.plugins[%d].parameters[%d].type: %w", i, j, err)
+			}
+		}
+		if err := p.Returns.Type.Validate(); err != nil {
+			return fmt.Errorf("templating.plugins[%d].returns.type: %w", i, err)
+		}
+		if p.Timeout != "" {
+			if d, err := time.ParseDuration(p.Timeout); err != nil {
+				return fmt.Errorf("templating.plugins[%d].timeout: %w", i, err)
+			} else if d <= 0 {
+				return fmt.Errorf("templating.plugins[%d].timeout should be positive", i)
+			}
+		}
+	}
+	return nil
+}
+
+// category returns the plugins as category of template functions.
+func (instance Plugins) category(root string, env map[string]string, cache *pluginCache) functions.Category {
+	result := functions.Category{Functions: functions.Functions{}}
+	for _, p := range instance {
+		result.Functions[p.Name] = p.function(root, env, cache)
+	}
+	return result
+}
+
+type pluginCacheEntry struct {
+	once   sync.Once
+	result interface{}
+	err    error
+}
+
+// pluginCache ensures that every plugin is called only once per combination
+// of arguments. It is created for every template factory (and so for every
+// render) because the results could differ between renders.
+type pluginCache struct {
+	mutex   sync.Mutex
+	entries map[string]*pluginCacheEntry
+}
+
+func newPluginCache() *pluginCache {
+	return &pluginCache{entries: map[string]*pluginCacheEntry{}}
+}
+
+func (instance *pluginCache) get(p Plugin, args []interface{}, call func() (interface{}, error)) (interface{}, error) {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return call()
+	}
+	key := p.Name + "\x00" + string(b)
+
+	instance.mutex.Lock()
+	entry, ok := instance.entries[key]
+	if !ok {
+		entry = &pluginCacheEntry{}
+		instance.entries[key] = entry
+	}
+	instance.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.result, entry.err = call()
+	})
+	return entry.result, entry.err
+}
+
+// pluginPlainOf converts maps of every key type (like the ones decoded from
+// YAML) into map[string]interface{} and slices into []interface{} - deeply.
+func pluginPlainOf(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			result[fmt.Sprint(key.Interface())] = pluginPlainOf(v.MapIndex(key).Interface())
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if _, ok := value.([]byte); ok {
+			return value
+		}
+		result := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = pluginPlainOf(v.Index(i).Interface())
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,18 @@
+//+build !windows
+
+package model
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configurePluginProcess starts the plugin in its own process group, so
+// killPluginProcess could kill its children, too.
+func configurePluginProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killPluginProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
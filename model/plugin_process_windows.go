@@ -0,0 +1,13 @@
+// +build windows
+
+package model
+
+import "os/exec"
+
+func configurePluginProcess(*exec.Cmd) {}
+
+// killPluginProcess kills only the plugin itself because there are no process
+// groups on Windows.
+func killPluginProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func Test_Plugins_Validate(t *testing.T) {
+	valid := Plugin{Name: "vaultPath", Command: []string{"vault-path"}, Parameters: []PluginParameter{{Name: "service", Type: "string"}}}
+	assert.NoError(t, Plugins{valid}.Validate())
+
+	assert.Error(t, Plugins{valid, valid}.Validate())
+	assert.Error(t, Plugins{{Name: "quote", Command: []string{"quote"}}}.Validate())
+	assert.Error(t, Plugins{{Name: "foo-bar", Command: []string{"foo"}}}.Validate())
+	assert.Error(t, Plugins{{Name: "foo"}}.Validate())
+	assert.Error(t, Plugins{{Name: "foo", Command: []string{"foo"}, Returns: PluginReturn{Type: "date"}}}.Validate())
+	assert.Error(t, Plugins{{Name: "foo", Command: []string{"foo"}, Timeout: "soon"}}.Validate())
+}
+
+func Test_PluginType_Convert(t *testing.T) {
+	actual, err := PluginType("int").Convert(float64(3))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, actual)
+
+	_, err = PluginType("int").Convert(3.5)
+	assert.Error(t, err)
+
+	actual, err = PluginType("object").Convert(pluginPlainOf(map[interface{}]interface{}{"a": []string{"b"}}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{"b"}}, actual)
+}
+
+func Test_Templating_plugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+	root, err := ioutil.TempDir("", "kubor-plugin")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	script := "#!/bin/sh\necho x >> calls\necho \"{\\\"in\\\": $(cat), \\\"env\\\": \\\"$FOO\\\"}\"\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "plugin.sh"), []byte(script), 0755))
+
+	templating := NewTemplating()
+	templating.root = root
+	templating.env = map[string]string{"FOO": "bar"}
+	templating.Plugins = Plugins{{
+		Name:       "describe",
+		Parameters: []PluginParameter{{Name: "a", Type: "int"}, {Name: "b"}},
+		Returns:    PluginReturn{Type: "object"},
+		Command:    []string{"./plugin.sh"},
+	}}
+
+	tmpl, err := templating.TemplateFactory().New("test", `{{ describe 1 "x" | toJson }}|{{ describe 1 "x" | toJson }}`)
+	require.NoError(t, err)
+	actual, err := tmpl.ExecuteToString(nil)
+	require.NoError(t, err)
+	expected := `{"env":"bar","in":{"args":[1,"x"],"function":"describe"}}`
+	assert.Equal(t, expected+"|"+expected, actual)
+
+	calls, err := ioutil.ReadFile(filepath.Join(root, "calls"))
+	require.NoError(t, err)
+	assert.Equal(t, "x\n", string(calls))
+
+	tmpl, err = templating.TemplateFactory().New("test", `{{ describe 1 "x" | toJson }}`)
+	require.NoError(t, err)
+	actual, err = tmpl.ExecuteToString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	calls, err = ioutil.ReadFile(filepath.Join(root, "calls"))
+	require.NoError(t, err)
+	assert.Equal(t, "x\nx\n", string(calls))
+}
+
+func Test_Plugin_call_killsChildrenOnTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+	root, err := ioutil.TempDir("", "kubor-plugin")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	script := "#!/bin/sh\n(sleep 1; echo x > alive) &\nsleep 5\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "plugin.sh"), []byte(script), 0755))
+	plugin := Plugin{Name: "slow", Command: []string{"./plugin.sh"}, Timeout: "100ms"}
+
+	start := time.Now()
+	_, err = plugin.call(root, nil, nil)
+	assert.EqualError(t, err, "plugin slow did not respond within 100ms")
+	assert.True(t, time.Since(start) < time.Second, "call took %v", time.Since(start))
+
+	time.Sleep(1500 * time.Millisecond)
+	_, err = os.Stat(filepath.Join(root, "alive"))
+	assert.True(t, os.IsNotExist(err), "children of the plugin are still running")
+}
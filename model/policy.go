@@ -0,0 +1,199 @@
+package model
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/common"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"strings"
+)
+
+type Policies struct {
+	Files []string    `yaml:"files,omitempty" json:"files,omitempty"`
+	Rules PolicyRules `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	// Values set using implicitly.
+	Resolved PolicyRules `yaml:"-" json:"-"`
+}
+
+func NewPolicies() Policies {
+	return Policies{
+		Files: []string{},
+		Rules: PolicyRules{},
+	}
+}
+
+func (instance Policies) evaluate(context interface{}) (Policies, error) {
+	result := instance
+	result.Resolved = PolicyRules{}
+
+	files, err := renderFilePatterns(instance.Files, "policy", context)
+	if err != nil {
+		return Policies{}, err
+	}
+	for _, file := range files {
+		if rules, err := readPolicyFile(file); err != nil {
+			return Policies{}, err
+		} else {
+			result.Resolved = append(result.Resolved, rules...)
+		}
+	}
+	for i, rule := range instance.Rules {
+		if err := rule.Validate(); err != nil {
+			return Policies{}, fmt.Errorf("illegal policies.rules[%d]: %w", i, err)
+		}
+		result.Resolved = append(result.Resolved, rule)
+	}
+	return result, nil
+}
+
+// Check evaluates all resolved rules against the given object. Every rule that
+// does not pass results in a PolicyViolation. An error is only returned if it
+// was not even possible to decide if a rule is applicable to the given object.
+func (instance Policies) Check(source string, object *unstructured.Unstructured) (PolicyViolations, error) {
+	var result PolicyViolations
+	for _, rule := range instance.Resolved {
+		if violation, err := rule.Check(source, object); err != nil {
+			return nil, err
+		} else if violation != nil {
+			result = append(result, *violation)
+		}
+	}
+	return result, nil
+}
+
+type policyFile struct {
+	Rules PolicyRules `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+func readPolicyFile(file string) (PolicyRules, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open policy file '%s': %w", file, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	var pf policyFile
+	if err := yaml.NewDecoder(f).Decode(&pf); err != nil {
+		return nil, fmt.Errorf("cannot read policy file '%s': %w", file, err)
+	}
+	for i, rule := range pf.Rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("illegal rules[%d] of policy file '%s': %w", i, file, err)
+		}
+	}
+	return pf.Rules, nil
+}
+
+type PolicyRule struct {
+	Name      string                     `yaml:"name" json:"name"`
+	On        common.EvaluatingPredicate `yaml:"on,omitempty" json:"on,omitempty"`
+	Predicate common.EvaluatingPredicate `yaml:"predicate" json:"predicate"`
+	Severity  PolicySeverity             `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Message   string                     `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+func (instance PolicyRule) Validate() error {
+	if instance.Name == "" {
+		return fmt.Errorf("name should not be empty")
+	}
+	if !instance.Predicate.IsRelevant() {
+		return fmt.Errorf("predicate of policy rule '%s' should not be empty", instance.Name)
+	}
+	if _, err := instance.Severity.MarshalText(); instance.Severity != "" && err != nil {
+		return fmt.Errorf("policy rule '%s': %w", instance.Name, err)
+	}
+	return nil
+}
+
+// Check evaluates this rule against the given object. If the object is in scope
+// of this rule (see On) and the Predicate does not match a PolicyViolation is
+// returned. If the Predicate cannot be evaluated for the object (for example
+// because a referenced field is absent) this is treated as a violation, too.
+func (instance PolicyRule) Check(source string, object *unstructured.Unstructured) (*PolicyViolation, error) {
+	if applicable, err := instance.On.Matches(object.Object); err != nil {
+		return nil, fmt.Errorf("%s: cannot evaluate scope of policy rule '%s': %w", source, instance.Name, err)
+	} else if !applicable {
+		return nil, nil
+	}
+
+	if matches, err := instance.Predicate.Matches(object.Object); err != nil {
+		return &PolicyViolation{
+			Rule:   instance,
+			Source: source,
+			Object: describeUnstructured(object),
+			Cause:  err,
+		}, nil
+	} else if !matches {
+		return &PolicyViolation{
+			Rule:   instance,
+			Source: source,
+			Object: describeUnstructured(object),
+		}, nil
+	}
+	return nil, nil
+}
+
+func (instance PolicyRule) GetSeverity() PolicySeverity {
+	if instance.Severity == "" {
+		return PolicySeverityError
+	}
+	return instance.Severity
+}
+
+func (instance PolicyRule) GetMessage() string {
+	if instance.Message == "" {
+		return fmt.Sprintf("does not match %v", instance.Predicate)
+	}
+	return instance.Message
+}
+
+type PolicyRules []PolicyRule
+
+type PolicyViolation struct {
+	Rule   PolicyRule
+	Source string
+	Object string
+	Cause  error
+}
+
+func (instance PolicyViolation) Message() string {
+	if instance.Cause != nil {
+		return fmt.Sprintf("%s (cannot be evaluated: %v)", instance.Rule.GetMessage(), instance.Cause)
+	}
+	return instance.Rule.GetMessage()
+}
+
+func (instance PolicyViolation) String() string {
+	return fmt.Sprintf("%s (source: %s) violates policy %s: %s", instance.Object, instance.Source, instance.Rule.Name, instance.Message())
+}
+
+type PolicyViolations []PolicyViolation
+
+func (instance PolicyViolations) Errors() PolicyViolations {
+	var result PolicyViolations
+	for _, candidate := range instance {
+		if candidate.Rule.GetSeverity().IsError() {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func (instance PolicyViolations) Error() string {
+	lines := make([]string, len(instance))
+	for i, violation := range instance {
+		lines[i] = violation.String()
+	}
+	return fmt.Sprintf("%d policy violation(s):\n\t%s", len(instance), strings.Join(lines, "\n\t"))
+}
+
+func describeUnstructured(object *unstructured.Unstructured) string {
+	gvk := GroupVersionKind(object.GroupVersionKind())
+	if ns := object.GetNamespace(); ns != "" {
+		return fmt.Sprintf("%v %s/%s", gvk, ns, object.GetName())
+	}
+	return fmt.Sprintf("%v %s", gvk, object.GetName())
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	PolicySeverityError   = PolicySeverity("error")
+	PolicySeverityWarning = PolicySeverity("warning")
+	PolicySeverityInfo    = PolicySeverity("info")
+)
+
+var (
+	ErrIllegalPolicySeverity = errors.New("illegal policy severity")
+
+	validPolicySeverityValues = map[PolicySeverity]bool{PolicySeverityError: true, PolicySeverityWarning: true, PolicySeverityInfo: true}
+)
+
+type PolicySeverity string
+
+func (instance *PolicySeverity) Set(plain string) error {
+	return instance.UnmarshalText([]byte(plain))
+}
+
+func (instance PolicySeverity) String() string {
+	if exist := validPolicySeverityValues[instance]; !exist {
+		return fmt.Sprintf("illegal-policy-severity-%s", string(instance))
+	}
+	return string(instance)
+}
+
+func (instance PolicySeverity) MarshalText() (text []byte, err error) {
+	if exist := validPolicySeverityValues[instance]; !exist {
+		return nil, fmt.Errorf("%w: %s", ErrIllegalPolicySeverity, string(instance))
+	}
+	return []byte(instance), nil
+}
+
+func (instance *PolicySeverity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "":
+		*instance = PolicySeverityError
+		return nil
+	case "warn":
+		*instance = PolicySeverityWarning
+		return nil
+	}
+
+	if exist := validPolicySeverityValues[PolicySeverity(strings.ToLower(string(text)))]; !exist {
+		return fmt.Errorf("%w: %s", ErrIllegalPolicySeverity, string(text))
+	}
+	*instance = PolicySeverity(strings.ToLower(string(text)))
+	return nil
+}
+
+func (instance PolicySeverity) IsError() bool {
+	return instance == PolicySeverityError || instance == ""
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_PolicyRule_Check(t *testing.T) {
+	var rule PolicyRule
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: replicas
+on: "{{ .kind }}=Deployment"
+predicate: "{{ .spec.replicas }}=[2-9]"
+`), &rule))
+	require.NoError(t, rule.Validate())
+
+	deployment := func(spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "app", "namespace": "ns"},
+			"spec":       spec,
+		}}
+	}
+
+	violation, err := rule.Check("a.yml", deployment(map[string]interface{}{"replicas": 3}))
+	require.NoError(t, err)
+	assert.Nil(t, violation)
+
+	violation, err = rule.Check("a.yml", deployment(map[string]interface{}{"replicas": 1}))
+	require.NoError(t, err)
+	require.NotNil(t, violation)
+	assert.Equal(t, "a.yml", violation.Source)
+	assert.Nil(t, violation.Cause)
+	assert.Contains(t, violation.String(), "ns/app")
+
+	violation, err = rule.Check("a.yml", deployment(map[string]interface{}{}))
+	require.NoError(t, err)
+	require.NotNil(t, violation)
+	assert.Error(t, violation.Cause)
+
+	violation, err = rule.Check("a.yml", &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "app"},
+	}})
+	require.NoError(t, err)
+	assert.Nil(t, violation)
+
+	assert.Error(t, PolicyRule{Predicate: rule.Predicate}.Validate())
+	assert.Error(t, PolicyRule{Name: "foo"}.Validate())
+	assert.Error(t, PolicyRule{Name: "foo", Predicate: rule.Predicate, Severity: "fatal"}.Validate())
+}
+
+func Test_PolicySeverity(t *testing.T) {
+	cases := []struct {
+		plain    string
+		expected PolicySeverity
+		isError  bool
+	}{
+		{"", PolicySeverityError, true},
+		{"error", PolicySeverityError, true},
+		{"Warning", PolicySeverityWarning, false},
+		{"warn", PolicySeverityWarning, false},
+		{"info", PolicySeverityInfo, false},
+	}
+	for _, c := range cases {
+		var actual PolicySeverity
+		require.NoError(t, actual.Set(c.plain), c.plain)
+		assert.Equal(t, c.expected, actual, c.plain)
+		assert.Equal(t, c.isError, actual.IsError(), c.plain)
+	}
+
+	var actual PolicySeverity
+	assert.Error(t, actual.Set("fatal"))
+
+	violations := PolicyViolations{
+		{Rule: PolicyRule{Name: "a", Severity: PolicySeverityWarning}},
+		{Rule: PolicyRule{Name: "b"}},
+		{Rule: PolicyRule{Name: "c", Severity: PolicySeverityInfo}},
+	}
+	assert.Equal(t, PolicyViolations{violations[1]}, violations.Errors())
+}
+
+func Test_Policies_evaluate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-policies")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte(`rules:
+- name: fromFile
+  predicate: "{{ .kind }}=Deployment"
+  severity: warning
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.yml"), []byte(`rules:
+- name: broken
+`), 0644))
+
+	var inline PolicyRule
+	require.NoError(t, yaml.Unmarshal([]byte("name: inline\npredicate: \"{{ .kind }}=Service\"\n"), &inline))
+
+	policies := Policies{
+		Files: []string{"{{ .Root }}/*.yml"},
+		Rules: PolicyRules{inline},
+	}
+	_, err = policies.evaluate(map[string]string{"Root": dir})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken.yml")
+
+	policies.Files = []string{"{{ .Root }}/a.yml", "?{{ .Root }}/missing-*.yml"}
+	actual, err := policies.evaluate(map[string]string{"Root": dir})
+	require.NoError(t, err)
+	require.Len(t, actual.Resolved, 2)
+	assert.Equal(t, "fromFile", actual.Resolved[0].Name)
+	assert.Equal(t, PolicySeverityWarning, actual.Resolved[0].GetSeverity())
+	assert.Equal(t, "inline", actual.Resolved[1].Name)
+
+	violations, err := actual.Check("x.yml", &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "x"},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, violations, 2)
+	assert.Len(t, violations.Errors(), 1)
+}
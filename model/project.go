@@ -2,35 +2,50 @@ package model
 
 import (
 	"fmt"
+	"github.com/alecthomas/kingpin"
 	"github.com/echocat/kubor/common"
 	"github.com/echocat/kubor/log"
+	"github.com/echocat/kubor/secret"
+	"github.com/echocat/kubor/template"
 	"gopkg.in/yaml.v2"
 	"io"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 type Project struct {
 	// Values set using Load() method.
-	GroupId           Name                `yaml:"groupId,omitempty" json:"groupId,omitempty"`
-	ArtifactId        Name                `yaml:"artifactId" json:"artifactId"`
-	Release           string              `yaml:"release,omitempty" json:"release,omitempty"`
-	Claim             Claim               `yaml:"claim,omitempty" json:"claim,omitempty"`
-	Stages            Stages              `yaml:"stages,omitempty" json:"stages,omitempty"`
-	Templating        Templating          `yaml:"templating,omitempty" json:"templating,omitempty"`
-	ConditionalValues []ConditionalValues `yaml:"values,omitempty" json:"values,omitempty"`
-	Labels            Labels              `yaml:"labels,omitempty" json:"labels,omitempty"`
-	Annotations       Annotations         `yaml:"annotations,omitempty" json:"annotations,omitempty"`
-	Transformations   Transformations     `yaml:"transformations,omitempty" json:"transformations,omitempty"`
-	Scheme            Scheme              `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	Extends            Extends             `yaml:"extends,omitempty" json:"extends,omitempty"`
+	GroupId            Name                `yaml:"groupId,omitempty" json:"groupId,omitempty"`
+	ArtifactId         Name                `yaml:"artifactId" json:"artifactId"`
+	Release            string              `yaml:"release,omitempty" json:"release,omitempty"`
+	Claim              Claim               `yaml:"claim,omitempty" json:"claim,omitempty"`
+	Stages             Stages              `yaml:"stages,omitempty" json:"stages,omitempty"`
+	Templating         Templating          `yaml:"templating,omitempty" json:"templating,omitempty"`
+	Libraries          Libraries           `yaml:"libraries,omitempty" json:"libraries,omitempty"`
+	ValueFiles         ValueFiles          `yaml:"valueFiles,omitempty" json:"valueFiles,omitempty"`
+	ConditionalValues  []ConditionalValues `yaml:"values,omitempty" json:"values,omitempty"`
+	ValuesListStrategy ValuesListStrategy  `yaml:"valuesListStrategy,omitempty" json:"valuesListStrategy,omitempty"`
+	ValuesSchema       string              `yaml:"valuesSchema,omitempty" json:"valuesSchema,omitempty"`
+	Labels             Labels              `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations        Annotations         `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Transformations    Transformations     `yaml:"transformations,omitempty" json:"transformations,omitempty"`
+	Scheme             Scheme              `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	Policies           Policies            `yaml:"policies,omitempty" json:"policies,omitempty"`
+	Secrets            Secrets             `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	Redaction          Redaction           `yaml:"redaction,omitempty" json:"redaction,omitempty"`
+	AllowedEnv         AllowedEnv          `yaml:"allowedEnv,omitempty" json:"allowedEnv,omitempty"`
 
 	// Values set using implicitly.
-	Source  string            `yaml:"-" json:"-"`
-	Root    string            `yaml:"-" json:"-"`
-	Values  Values            `yaml:"-" json:"-"`
-	Env     map[string]string `yaml:"-" json:"-"`
-	Context string            `yaml:"-" json:"-"`
+	Source string `yaml:"-" json:"-"`
+	Root   string `yaml:"-" json:"-"`
+	Values Values `yaml:"-" json:"-"`
+	// ValuesExplanation describes where Values are coming from.
+	ValuesExplanation ValuesExplanation `yaml:"-" json:"-"`
+	Env               map[string]string `yaml:"-" json:"-"`
+	Context           string            `yaml:"-" json:"-"`
 }
 
 func NewProject() Project {
@@ -42,6 +57,9 @@ func NewProject() Project {
 		Labels:            NewLabels(),
 		Annotations:       NewAnnotations(),
 		Transformations:   NewTransformations(),
+		Policies:          NewPolicies(),
+		Secrets:           NewSecrets(),
+		Redaction:         NewRedaction(),
 		Values:            NewValues(),
 		Env:               make(map[string]string),
 	}
@@ -51,9 +69,64 @@ func (instance Project) Validate() error {
 	if instance.ArtifactId == "" {
 		return fmt.Errorf("artifactId should not be empty")
 	}
+	if err := instance.Templating.Charts.Validate(); err != nil {
+		return err
+	}
+	if err := instance.Templating.Overlays.Validate(); err != nil {
+		return err
+	}
+	if err := instance.Templating.Plugins.Validate(); err != nil {
+		return err
+	}
+	if err := instance.Libraries.Validate(); err != nil {
+		return err
+	}
+	if err := instance.Redaction.Validate(); err != nil {
+		return err
+	}
+	if err := instance.AllowedEnv.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// Lint returns the problems of this project which do not prevent it from
+// being rendered but which are most likely mistakes. They are only reported by
+// kubor lint.
+func (instance Project) Lint() []error {
+	var result []error
+	if len(instance.Stages) == 0 {
+		result = append(result, fmt.Errorf("stages should not be empty"))
+	}
+	seenStages := map[Stage]bool{}
+	for _, stage := range instance.Stages {
+		if seenStages[stage] {
+			result = append(result, fmt.Errorf("stage %v is defined more than once", stage))
+		}
+		seenStages[stage] = true
+	}
+	if len(instance.Templating.TemplateFilePattern) == 0 && len(instance.Templating.Charts) == 0 && len(instance.Templating.Overlays) == 0 {
+		result = append(result, fmt.Errorf("templating.templateFilePattern should not be empty"))
+	}
+	annotations := instance.Annotations.All()
+	annotationNames := make([]string, 0, len(annotations))
+	for name := range annotations {
+		annotationNames = append(annotationNames, name)
+	}
+	sort.Strings(annotationNames)
+	for _, name := range annotationNames {
+		if annotations[name].Name == "" {
+			result = append(result, fmt.Errorf("annotations.%s.name should not be empty", name))
+		}
+	}
+	for name := range instance.Transformations {
+		if name == "" {
+			result = append(result, fmt.Errorf("transformations should not contain an empty name"))
+		}
+	}
+	return result
+}
+
 func (instance *Project) Save() error {
 	dir := filepath.Dir(instance.Source)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -72,11 +145,60 @@ func (instance *Project) Save() error {
 }
 
 func (instance Project) RenderedTemplatesProvider() (ContentProvider, error) {
-	return instance.Templating.RenderedTemplatesProvider(instance)
+	data, err := instance.WithDecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+	return data.RenderedTemplatesProviderAsIs()
+}
+
+// RenderedTemplatesProviderAsIs provides like RenderedTemplatesProvider the
+// rendered templates, charts and overlays but without decrypting the values
+// before.
+func (instance Project) RenderedTemplatesProviderAsIs() (ContentProvider, error) {
+	data := instance
+	templates, err := instance.Templating.RenderedTemplatesProvider(data)
+	if err != nil {
+		return nil, err
+	}
+	charts, err := instance.Templating.Charts.RenderedProvider(data)
+	if err != nil {
+		return nil, err
+	}
+	overlays, err := instance.Templating.Overlays.RenderedProvider(data)
+	if err != nil {
+		return nil, err
+	}
+	return concatContentProviders(templates, charts, overlays), nil
 }
 
 func (instance Project) RenderedTemplateFile(file string, writer io.Writer) error {
-	return instance.Templating.RenderTemplateFile(file, instance, writer)
+	data, err := instance.WithDecryptedValues()
+	if err != nil {
+		return err
+	}
+	return instance.Templating.RenderTemplateFile(file, data, writer)
+}
+
+// WithDecryptedValues returns a copy of this project with all encrypted values
+// decrypted. This copy should only be used for rendering and never be logged.
+func (instance Project) WithDecryptedValues() (Project, error) {
+	if !secret.ContainsEncrypted(map[string]interface{}(instance.Values)) {
+		return instance, nil
+	}
+	key, err := instance.Secrets.Key(instance)
+	if err != nil {
+		return Project{}, fmt.Errorf("cannot decrypt values: %w", err)
+	}
+	decrypted, err := key.DecryptAllReporting(map[string]interface{}(instance.Values), func(plain interface{}) {
+		addSensitiveValues(log.DefaultRedactor, plain)
+	})
+	if err != nil {
+		return Project{}, fmt.Errorf("cannot decrypt values: %w", err)
+	}
+	result := instance
+	result.Values = decrypted.(map[string]interface{})
+	return result, nil
 }
 
 func (instance Project) GetTransformation(v *unstructured.Unstructured, name TransformationName) (result Transformation, err error) {
@@ -94,12 +216,16 @@ func (instance Project) GetTransformation(v *unstructured.Unstructured, name Tra
 }
 
 type ProjectFactory struct {
-	source         string
-	sourceRequired bool
-	values         Values
-	artifactId     Name
-	groupId        Name
-	release        string
+	source             string
+	sourceRequired     bool
+	values             ValueAssignments
+	valueFiles         []string
+	valuesListStrategy ValuesListStrategy
+	secretKeyFile      string
+	artifactId         Name
+	groupId            Name
+	release            string
+	env                map[string]string
 }
 
 func NewProjectFactory() *ProjectFactory {
@@ -116,16 +242,21 @@ func (instance *ProjectFactory) Create(context string) (*Project, error) {
 		}
 	} else if err != nil {
 		return nil, fmt.Errorf("cannot open source file '%s': %w", instance.source, err)
-	} else if f, err := os.Open(source); err != nil {
-		return nil, fmt.Errorf("cannot open source file '%s': %w", source, err)
+	} else if raw, valuesSources, err := loadProjectSource(source); err != nil {
+		return nil, err
 	} else {
-		//noinspection GoUnhandledErrorResult
-		defer f.Close()
-		if err := yaml.NewDecoder(f).Decode(&result); err != nil {
+		if b, err := yaml.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("cannot read source file '%s': %w", source, err)
+		} else if err := yaml.Unmarshal(b, &result); err != nil {
 			return nil, fmt.Errorf("cannot read source file '%s': %w", source, err)
 		} else if err := result.Validate(); err != nil {
 			return nil, fmt.Errorf("cannot read source file '%s': %w", source, err)
 		}
+		if len(valuesSources) == len(result.ConditionalValues) {
+			for i, vs := range valuesSources {
+				result.ConditionalValues[i].source = vs
+			}
+		}
 
 		if result, err = instance.populateStage1(source, result); err != nil {
 			return nil, err
@@ -133,9 +264,15 @@ func (instance *ProjectFactory) Create(context string) (*Project, error) {
 		if result, err = instance.populateStage2(result); err != nil {
 			return nil, err
 		}
+		if result, err = instance.validateValues(result); err != nil {
+			return nil, err
+		}
 		if result, err = instance.populateStage3(result); err != nil {
 			return nil, err
 		}
+		if err := result.Redaction.configure(log.DefaultRedactor, result.Values); err != nil {
+			return nil, err
+		}
 	}
 
 	if log.IsDebugEnabled() {
@@ -154,7 +291,7 @@ func (instance *ProjectFactory) Create(context string) (*Project, error) {
 		}
 
 		for k, v := range result.Values {
-			l = l.WithField("value."+k, v)
+			l = l.WithField("value."+k, secret.Mask(v))
 		}
 
 		l.Debug("Project %s", name)
@@ -163,6 +300,51 @@ func (instance *ProjectFactory) Create(context string) (*Project, error) {
 	return &result, nil
 }
 
+// Source returns the source file this factory will read the project from.
+func (instance *ProjectFactory) Source() string {
+	if source, err := instance.resolveSource(); err == nil {
+		return source
+	}
+	return instance.source
+}
+
+// GroupId returns the groupId which overrides the one of the source file.
+func (instance *ProjectFactory) GroupId() Name {
+	return instance.groupId
+}
+
+// ArtifactId returns the artifactId which overrides the one of the source file.
+func (instance *ProjectFactory) ArtifactId() Name {
+	return instance.artifactId
+}
+
+// ForSource returns a copy of this factory which reads the project from the
+// given source file (which is required to exist). An overridden artifactId is
+// not inherited because it identifies only one project.
+func (instance *ProjectFactory) ForSource(source string) *ProjectFactory {
+	result := *instance
+	result.source = source
+	result.sourceRequired = true
+	result.artifactId = ""
+	return &result
+}
+
+// WithValues returns a copy of this factory which applies the given values
+// after all others (including the ones of flags like --value).
+func (instance *ProjectFactory) WithValues(values ValueAssignments) *ProjectFactory {
+	result := *instance
+	result.values = append(append(ValueAssignments{}, instance.values...), values...)
+	return &result
+}
+
+// WithEnv returns a copy of this factory which provides the given environment
+// variables (instead of the ones of the process) to the project.
+func (instance *ProjectFactory) WithEnv(env map[string]string) *ProjectFactory {
+	result := *instance
+	result.env = env
+	return &result
+}
+
 func (instance *ProjectFactory) resolveSource() (string, error) {
 	if _, err := os.Stat(instance.source); err == nil {
 		return instance.source, nil
@@ -198,10 +380,16 @@ func (instance *ProjectFactory) populateStage1(source string, input Project) (Pr
 	result := input
 	result.Source = source
 	result.Root = filepath.Dir(result.Source)
-	result.Values = Values{}
-	for k, v := range instance.values {
-		result.Values[k] = v
+	result.Templating.root = result.Root
+	result.Templating.pathResolver = result.Libraries.PathResolver(result.Root)
+	result.Templating.templateCache = template.NewCache()
+	if instance.valuesListStrategy != "" {
+		result.ValuesListStrategy = instance.valuesListStrategy
+	}
+	if instance.secretKeyFile != "" {
+		result.Secrets.KeyFile = instance.secretKeyFile
 	}
+	result.Values = instance.values.ApplyTo(Values{}, result.ValuesListStrategy)
 	if instance.groupId != "" {
 		result.GroupId = instance.groupId
 	}
@@ -214,20 +402,86 @@ func (instance *ProjectFactory) populateStage1(source string, input Project) (Pr
 	if result.Release == "" {
 		result.Release = "latest"
 	}
-	result.Env = common.Environ()
+	env := instance.env
+	if env == nil {
+		env = common.Environ()
+	}
+	result.Env = result.AllowedEnv.Filter(env)
+	result.Templating.env = result.Env
 	return result, nil
 }
 
 func (instance *ProjectFactory) populateStage2(input Project) (Project, error) {
 	result := input
-	for _, candidate := range input.ConditionalValues {
+	explanation := NewValuesExplanation()
+	values := Values{}
+	files, err := result.ValueFiles.Files(result)
+	if err != nil {
+		return Project{}, err
+	}
+	for _, file := range append(files, instance.valueFiles...) {
+		if fileValues, err := LoadValuesFile(file); err != nil {
+			return Project{}, err
+		} else {
+			values = values.MergeWithStrategy(result.ValuesListStrategy, fileValues)
+			explanation.Origins.record(fileValues, ValueOrigin{Kind: ValueOriginFile, Source: file})
+		}
+	}
+	result.Values = instance.values.applyTo(values, result.ValuesListStrategy, explanation.Origins)
+	for i, candidate := range input.ConditionalValues {
 		if ok, err := candidate.On.Matches(result); err != nil {
 			return Project{}, err
 		} else if ok {
-			result.Values = result.Values.MergeWith(candidate.Values)
-			result.Values = result.Values.MergeWith(instance.values)
+			source, index := candidate.location(result.Source, i)
+			origin := ValueOrigin{Kind: ValueOriginDefault, Source: source, Index: index}
+			if candidate.On.IsRelevant() {
+				origin.Kind = ValueOriginConditional
+				origin.Predicate = candidate.On.String()
+			}
+			values = values.MergeWithStrategy(result.ValuesListStrategy, candidate.Values)
+			explanation.Origins.record(candidate.Values, origin)
+			result.Values = instance.values.applyTo(values, result.ValuesListStrategy, explanation.Origins)
+		} else if reasons, err := candidate.On.Explain(result); err != nil {
+			return Project{}, err
+		} else {
+			source, index := candidate.location(result.Source, i)
+			explanation.Skipped = append(explanation.Skipped, SkippedConditionalValues{
+				Source:    source,
+				Index:     index,
+				Predicate: candidate.On.String(),
+				Reasons:   reasons,
+			})
+		}
+	}
+	explanation.Origins.retain(result.Values)
+	result.ValuesExplanation = explanation
+	return result, nil
+}
+
+func (instance *ProjectFactory) validateValues(input Project) (Project, error) {
+	result := input
+	if result.ValuesSchema == "" {
+		return result, nil
+	}
+	files, err := renderFilePatterns([]string{result.ValuesSchema}, "values schema", result)
+	if err != nil {
+		return Project{}, err
+	}
+	for _, file := range files {
+		schema, err := LoadValuesSchema(file)
+		if err != nil {
+			return Project{}, err
+		}
+		values, defaults, err := schema.Apply(result.Values)
+		if err != nil {
+			return Project{}, err
+		}
+		result.Values = values
+		for _, def := range defaults {
+			result.ValuesExplanation.Origins.record(def.AsValues(), ValueOrigin{Kind: ValueOriginSchema, Source: file})
 		}
 	}
+	result.ValuesExplanation.Origins.retain(result.Values)
 	return result, nil
 }
 
@@ -238,6 +492,11 @@ func (instance *ProjectFactory) populateStage3(input Project) (Project, error) {
 		return Project{}, err
 	}
 	result.Claim = c
+	p, err := input.Policies.evaluate(input)
+	if err != nil {
+		return Project{}, err
+	}
+	result.Policies = p
 	return result, nil
 }
 
@@ -263,8 +522,37 @@ func (instance *ProjectFactory) ConfigureFlags(hf common.HasFlags) {
 		Default(fmt.Sprint(instance.sourceRequired)).
 		Envar("KUBOR_SOURCE_REQUIRED").
 		BoolVar(&instance.sourceRequired)
-	hf.Flag("value", "Specifies values which should be provided to the runtime.").
-		Short('v').
-		PlaceHolder("<name>=[<value>]").
-		SetValue(&instance.values)
+	instance.configureValueAssignmentsFlag(hf, "value", "Specifies values which should be provided to the runtime. Nested values can be addressed using dots (image.tag=1.2.3).", "<name>=[<value>]", ValueAssignmentParserString).
+		Short('v')
+	// Like every other flag of kubor these are camelCase (--valueJson instead
+	// of --value-json).
+	instance.configureValueAssignmentsFlag(hf, "valueJson", "Like --value but the value is parsed as JSON. null removes the value.", "<name>=<json>", ValueAssignmentParserJson)
+	instance.configureValueAssignmentsFlag(hf, "valueYaml", "Like --value but the value is parsed as YAML. null removes the value.", "<name>=<yaml>", ValueAssignmentParserYaml)
+	instance.configureValueAssignmentsFlag(hf, "valueFile", "Like --value but the value is the content of the given file.", "<name>=<file>", ValueAssignmentParserFile)
+	hf.Flag("values", "Specifies a YAML or JSON file containing values. Files are merged in the given order after the valueFiles of the source file.").
+		PlaceHolder("<file>").
+		StringsVar(&instance.valueFiles)
+	hf.Flag("valuesListStrategy", "If set it will overrides valuesListStrategy from source file. Can be replace or append.").
+		Envar("KUBOR_VALUES_LIST_STRATEGY").
+		PlaceHolder("<strategy>").
+		SetValue(&instance.valuesListStrategy)
+	hf.Flag("secretKeyFile", "If set it will overrides secrets.keyFile from source file. The environment variable "+SecretKeyEnvar+" has precedence over both.").
+		Envar("KUBOR_SECRET_KEY_FILE").
+		PlaceHolder("<file>").
+		StringVar(&instance.secretKeyFile)
+}
+
+// configureValueAssignmentsFlag registers a flag which appends its assignments
+// to the values of the project. On purpose it could not be set using an
+// environment variable; otherwise a stray variable would silently change the
+// values.
+func (instance *ProjectFactory) configureValueAssignmentsFlag(hf common.HasFlags, name, help, placeHolder string, parser ValueAssignmentParser) *kingpin.FlagClause {
+	result := hf.Flag(name, help).
+		PlaceHolder(placeHolder)
+	result.SetValue(&ValueAssignmentsFlag{
+		Target: &instance.values,
+		Parser: parser,
+		Name:   name,
+	})
+	return result
 }
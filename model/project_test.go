@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Project_Validate_acceptsWithoutStagesAndTemplates(t *testing.T) {
+	project := NewProject()
+	project.ArtifactId = "app"
+	project.Stages = Stages{}
+	project.Templating.TemplateFilePattern = nil
+
+	assert.NoError(t, project.Validate())
+	problems := project.Lint()
+	require.Len(t, problems, 2)
+	assert.EqualError(t, problems[0], "stages should not be empty")
+	assert.EqualError(t, problems[1], "templating.templateFilePattern should not be empty")
+}
+
+func Test_Project_Lint(t *testing.T) {
+	project := NewProject()
+	project.ArtifactId = "app"
+	assert.Empty(t, project.Lint())
+
+	project.Stages = Stages{StageDefault, StageDefault}
+	problems := project.Lint()
+	require.Len(t, problems, 1)
+	assert.EqualError(t, problems[0], "stage "+string(StageDefault)+" is defined more than once")
+}
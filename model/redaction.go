@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/log"
+	"regexp"
+)
+
+// Redaction configures which values should never appear in logs or outputs
+// like evaluate --redact (see log.Redactor). Decrypted values are always
+// treated as sensitive.
+type Redaction struct {
+	// NamePatterns are regular expressions; every string value with a
+	// matching name is redacted.
+	NamePatterns []string `yaml:"namePatterns,omitempty" json:"namePatterns,omitempty"`
+	// SensitiveValues are paths (like db.password) of values; every occurrence
+	// of these values is redacted.
+	SensitiveValues []string `yaml:"sensitiveValues,omitempty" json:"sensitiveValues,omitempty"`
+}
+
+func NewRedaction() Redaction {
+	return Redaction{}
+}
+
+func (instance Redaction) Validate() error {
+	for _, pattern := range instance.NamePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("illegal redaction.namePatterns '%s': %w", pattern, err)
+		}
+	}
+	for _, path := range instance.SensitiveValues {
+		if _, err := ParseValuePath(path); err != nil {
+			return fmt.Errorf("illegal redaction.sensitiveValues: %w", err)
+		}
+	}
+	return nil
+}
+
+func (instance Redaction) configure(redactor *log.Redactor, values Values) error {
+	for _, pattern := range instance.NamePatterns {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("illegal redaction.namePatterns '%s': %w", pattern, err)
+		}
+		redactor.AddNamePattern(r)
+	}
+	for _, plainPath := range instance.SensitiveValues {
+		path, err := ParseValuePath(plainPath)
+		if err != nil {
+			return fmt.Errorf("illegal redaction.sensitiveValues: %w", err)
+		}
+		var current interface{} = map[string]interface{}(values)
+		for _, key := range path {
+			if m, ok := normalizeValue(current).(map[string]interface{}); ok {
+				current = m[key]
+			} else {
+				current = nil
+			}
+		}
+		addSensitiveValues(redactor, current)
+	}
+	return nil
+}
+
+func addSensitiveValues(redactor *log.Redactor, v interface{}) {
+	switch x := normalizeValue(v).(type) {
+	case nil:
+	case map[string]interface{}:
+		for _, value := range x {
+			addSensitiveValues(redactor, value)
+		}
+	case []interface{}:
+		for _, value := range x {
+			addSensitiveValues(redactor, value)
+		}
+	default:
+		redactor.AddSensitiveValue(fmt.Sprint(x))
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/secret"
+	"os"
+)
+
+const (
+	SecretKeyEnvar = secret.KeyEnvar
+)
+
+// Secrets configures how encrypted values (see secret.Key) are decrypted.
+// The key is taken from the environment variable KUBOR_SECRET_KEY or if not
+// present from KeyFile.
+type Secrets struct {
+	KeyFile string `yaml:"keyFile,omitempty" json:"keyFile,omitempty"`
+}
+
+func NewSecrets() Secrets {
+	return Secrets{}
+}
+
+// Key resolves the key. Its KeyFile is rendered as template using project.
+func (instance Secrets) Key(project Project) (secret.Key, error) {
+	if plain := os.Getenv(SecretKeyEnvar); plain != "" {
+		if key, err := secret.ParseKey(plain); err != nil {
+			return nil, fmt.Errorf("cannot read secret key from environment variable %s: %w", SecretKeyEnvar, err)
+		} else {
+			return key, nil
+		}
+	}
+	if instance.KeyFile == "" {
+		return nil, secret.ErrNoKey
+	}
+	files, err := renderFilePatterns([]string{instance.KeyFile}, "secret key", project)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, secret.ErrNoKey
+	}
+	return secret.LoadKeyFile(files[0])
+}
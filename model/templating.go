@@ -3,14 +3,27 @@ package model
 import (
 	"bytes"
 	"fmt"
+	"github.com/echocat/kubor/template"
 	"github.com/echocat/kubor/template/functions"
 	"io"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 )
 
 type Templating struct {
 	TemplateFilePattern []string `yaml:"templateFilePattern" json:"templateFilePattern"`
+	Charts              Charts   `yaml:"charts,omitempty" json:"charts,omitempty"`
+	Overlays            Overlays `yaml:"overlays,omitempty" json:"overlays,omitempty"`
+	TestFilePattern     []string `yaml:"testFilePattern,omitempty" json:"testFilePattern,omitempty"`
+	Plugins             Plugins  `yaml:"plugins,omitempty" json:"plugins,omitempty"`
+
+	root          string
+	pathResolver  template.PathResolver
+	objectLookup  template.ObjectLookup
+	env           map[string]string
+	templateCache *template.Cache
 }
 
 func NewTemplating() Templating {
@@ -19,35 +32,110 @@ func NewTemplating() Templating {
 			"?{{ .Root }}/kubernetes/templates/*.yml",
 			"?{{ .Root }}/kubernetes/templates/*.yaml",
 		},
+		TestFilePattern: []string{
+			"?{{ .Root }}/kubernetes/tests/*.yml",
+			"?{{ .Root }}/kubernetes/tests/*.yaml",
+		},
 	}
 }
 
 func (instance Templating) TemplateFiles(data interface{}) ([]string, error) {
-	return instance.renderFiles(instance.TemplateFilePattern, "template", data)
+	return renderFilePatterns(instance.TemplateFilePattern, "template", data)
+}
+
+func (instance Templating) TestFiles(data interface{}) ([]string, error) {
+	return renderFilePatterns(instance.TestFilePattern, "test", data)
 }
 
+type renderedTemplate struct {
+	content []byte
+	err     error
+	done    chan struct{}
+}
+
+// RenderedTemplatesProvider renders all template files in parallel but
+// provides them in the order of TemplateFiles. All files are rendered by the
+// same factory. After a file could not be rendered no further files are
+// started until the next file is requested; so consumers which stop at the
+// first error do not wait for the rendering of all other files.
 func (instance Templating) RenderedTemplatesProvider(data interface{}) (ContentProvider, error) {
 	if files, err := instance.TemplateFiles(data); err != nil {
 		return nil, err
 	} else {
+		factory := instance.TemplateFactory()
+		rendered := make([]renderedTemplate, len(files))
+		indexes := make(chan int, len(files))
+		for i := range files {
+			rendered[i].done = make(chan struct{})
+			indexes <- i
+		}
+		close(indexes)
+
+		work := func(stop chan struct{}, stopOnce *sync.Once) {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				index, ok := <-indexes
+				if !ok {
+					return
+				}
+				buf := new(bytes.Buffer)
+				rendered[index].err = renderTemplateFile(factory, files[index], data, buf)
+				rendered[index].content = buf.Bytes()
+				if rendered[index].err != nil {
+					stopOnce.Do(func() { close(stop) })
+				}
+				close(rendered[index].done)
+			}
+		}
+		var stop chan struct{}
+		start := func() {
+			stop = make(chan struct{})
+			stopOnce := new(sync.Once)
+			workers := runtime.GOMAXPROCS(0)
+			if workers > len(files) {
+				workers = len(files)
+			}
+			for w := 0; w < workers; w++ {
+				go work(stop, stopOnce)
+			}
+		}
+
 		i := 0
 		return func() (string, []byte, error) {
 			if i >= len(files) {
 				return "", nil, io.EOF
 			}
-			buf := new(bytes.Buffer)
-			file := files[i]
+			if stop == nil {
+				start()
+			} else {
+				select {
+				case <-stop:
+					// The consumer continues after an error; so do we.
+					start()
+				default:
+				}
+			}
+			file, r := files[i], &rendered[i]
 			i++
-			if err := instance.RenderTemplateFile(file, data, buf); err != nil {
-				return file, nil, err
+			<-r.done
+			if r.err != nil {
+				return file, nil, r.err
 			}
-			return file, buf.Bytes(), nil
+			return file, r.content, nil
 		}, nil
 	}
 }
 
 func (instance Templating) RenderTemplateFile(file string, data interface{}, writer io.Writer) error {
-	if tmpl, err := functions.DefaultTemplateFactory().NewFromFile(file); err != nil {
+	return renderTemplateFile(instance.TemplateFactory(), file, data, writer)
+}
+
+func renderTemplateFile(factory template.Factory, file string, data interface{}, writer io.Writer) error {
+	if tmpl, err := factory.NewFromFile(file); err != nil {
 		return fmt.Errorf("cannot parse template file '%s': %w", file, err)
 	} else if err := tmpl.Execute(data, writer); err != nil {
 		return fmt.Errorf("cannot render template file '%s': %w", file, err)
@@ -56,7 +144,42 @@ func (instance Templating) RenderTemplateFile(file string, data interface{}, wri
 	}
 }
 
-func (instance Templating) renderFiles(patterns []string, name string, data interface{}) ([]string, error) {
+// TemplateFactory returns the factory used to create templates of the project
+// files. These templates could reference files of libraries.
+func (instance Templating) TemplateFactory() template.Factory {
+	return &template.FactoryImpl{
+		FunctionProvider: instance.FunctionCategories(),
+		PathResolver:     instance.pathResolver,
+		ObjectLookup:     instance.objectLookup,
+		Environment:      instance.env,
+		Cache:            instance.templateCache,
+	}
+}
+
+// FunctionCategories returns the built-in template functions together with
+// the ones of the plugins (as category plugins). Every call caches the results
+// of the plugins separately.
+func (instance Templating) FunctionCategories() functions.Categories {
+	if len(instance.Plugins) == 0 {
+		return functions.CategoriesDefault
+	}
+	result := make(functions.Categories, len(functions.CategoriesDefault)+1)
+	for name, category := range functions.CategoriesDefault {
+		result[name] = category
+	}
+	result["plugins"] = instance.Plugins.category(instance.root, instance.env, newPluginCache())
+	return result
+}
+
+// WithObjectLookup returns a copy of this templating which templates could
+// use the given lookup of live objects.
+func (instance Templating) WithObjectLookup(lookup template.ObjectLookup) Templating {
+	result := instance
+	result.objectLookup = lookup
+	return result
+}
+
+func renderFilePatterns(patterns []string, name string, data interface{}) ([]string, error) {
 	var result []string
 	for _, pattern := range patterns {
 		atLeastOneMatchExpected := true
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Templating_RenderedTemplatesProvider(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-templating")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "_partial.tpl"), []byte(`{{ . }}`), 0644))
+	var expected []string
+	for i := 0; i < 50; i++ {
+		file := filepath.Join(root, fmt.Sprintf("t%02d.yml", i))
+		require.NoError(t, ioutil.WriteFile(file, []byte(fmt.Sprintf(`i: {{ include %q %d }}`, filepath.Join(root, "_partial.tpl"), i)), 0644))
+		expected = append(expected, file)
+	}
+
+	templating := NewTemplating()
+	templating.TemplateFilePattern = []string{filepath.Join(root, "*.yml")}
+	templating.templateCache = template.NewCache()
+
+	for run := 0; run < 2; run++ {
+		provider, err := templating.RenderedTemplatesProvider(nil)
+		require.NoError(t, err)
+		var actual []string
+		for {
+			file, content, err := provider()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("i: %d", len(actual)), string(content))
+			actual = append(actual, file)
+		}
+		assert.Equal(t, expected, actual)
+	}
+
+	factory := templating.TemplateFactory()
+	first, err := factory.NewFromFile(expected[0])
+	require.NoError(t, err)
+	second, err := factory.NewFromFile(expected[0])
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "_partial.tpl"), []byte(`changed {{ . }}`), 0644))
+	buf := new(bytes.Buffer)
+	require.NoError(t, templating.RenderTemplateFile(expected[3], nil, buf))
+	assert.Equal(t, "i: changed 3", buf.String())
+}
+
+type countingLookup struct {
+	count int32
+}
+
+func (instance *countingLookup) Lookup(_, _, _, name string) (map[string]interface{}, error) {
+	if name == "fail" {
+		return nil, fmt.Errorf("expected")
+	}
+	atomic.AddInt32(&instance.count, 1)
+	return nil, nil
+}
+
+func (instance *countingLookup) LookupList(string, string, string, string) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func Test_Templating_RenderedTemplatesProvider_pausesAfterError(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-templating")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	files := 4*runtime.GOMAXPROCS(0) + 100
+	for i := 0; i < files; i++ {
+		name := "ok"
+		if i == 0 {
+			name = "fail"
+		}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, fmt.Sprintf("t%04d.yml", i)), []byte(fmt.Sprintf(`{{ lookup "v1" "ConfigMap" "a" %q }}`, name)), 0644))
+	}
+
+	lookup := &countingLookup{}
+	templating := NewTemplating().WithObjectLookup(lookup)
+	templating.TemplateFilePattern = []string{filepath.Join(root, "*.yml")}
+
+	provider, err := templating.RenderedTemplatesProvider(nil)
+	require.NoError(t, err)
+	file, _, err := provider()
+	assert.Equal(t, filepath.Join(root, "t0000.yml"), file)
+	assert.Contains(t, err.Error(), "expected")
+
+	// Give workers which are still running the chance to take further files.
+	time.Sleep(100 * time.Millisecond)
+	assert.Less(t, int(atomic.LoadInt32(&lookup.count)), files-1)
+
+	provided := 1
+	for {
+		if _, _, err := provider(); err == io.EOF {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		provided++
+	}
+	assert.Equal(t, files, provided)
+	assert.Equal(t, int32(files-1), atomic.LoadInt32(&lookup.count))
+}
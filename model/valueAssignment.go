@@ -0,0 +1,175 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"strings"
+)
+
+// ValueAssignment sets the given Value at the given Path of values. A nil Value
+// removes the addressed key.
+type ValueAssignment struct {
+	Path   []string
+	Value  interface{}
+	Origin ValueOrigin
+}
+
+func (instance ValueAssignment) AsValues() Values {
+	value := instance.Value
+	for i := len(instance.Path) - 1; i > 0; i-- {
+		value = map[string]interface{}{instance.Path[i]: value}
+	}
+	return Values{instance.Path[0]: value}
+}
+
+func (instance ValueAssignment) String() string {
+	return fmt.Sprintf("%s=%v", FormatValuePath(instance.Path), instance.Value)
+}
+
+// ValueAssignments are applied in the order they were defined.
+type ValueAssignments []ValueAssignment
+
+func (instance ValueAssignments) ApplyTo(values Values, strategy ValuesListStrategy) Values {
+	return instance.applyTo(values, strategy, nil)
+}
+
+func (instance ValueAssignments) applyTo(values Values, strategy ValuesListStrategy, origins ValueOrigins) Values {
+	result := values.MergeWithStrategy(strategy)
+	for _, assignment := range instance {
+		assignmentValues := assignment.AsValues()
+		result = result.MergeWithStrategy(strategy, assignmentValues)
+		if origins != nil {
+			origins.record(assignmentValues, assignment.Origin)
+		}
+	}
+	return result
+}
+
+func (instance ValueAssignments) String() string {
+	parts := make([]string, len(instance))
+	for i, assignment := range instance {
+		parts[i] = assignment.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseValuePath splits the given name at every dot. Dots which are part of a
+// key can be escaped using a backslash (foo\.bar).
+func ParseValuePath(name string) ([]string, error) {
+	var result []string
+	var current strings.Builder
+	escaped := false
+	for _, c := range name {
+		if escaped {
+			current.WriteRune(c)
+			escaped = false
+		} else if c == '\\' {
+			escaped = true
+		} else if c == '.' {
+			result = append(result, current.String())
+			current.Reset()
+		} else {
+			current.WriteRune(c)
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	result = append(result, current.String())
+	for _, part := range result {
+		if part == "" {
+			return nil, fmt.Errorf("illegal value path '%s': empty key", name)
+		}
+	}
+	return result, nil
+}
+
+// ValueAssignmentParser converts the plain value part of <name>=<value> into
+// the actual value.
+type ValueAssignmentParser func(plain string, present bool) (interface{}, error)
+
+var (
+	ValueAssignmentParserString = ValueAssignmentParser(func(plain string, _ bool) (interface{}, error) {
+		return plain, nil
+	})
+	ValueAssignmentParserJson = ValueAssignmentParser(func(plain string, present bool) (interface{}, error) {
+		if !present {
+			return nil, fmt.Errorf("missing json value")
+		}
+		var result interface{}
+		if err := json.Unmarshal([]byte(plain), &result); err != nil {
+			return nil, fmt.Errorf("illegal json value: %w", err)
+		}
+		return result, nil
+	})
+	ValueAssignmentParserYaml = ValueAssignmentParser(func(plain string, present bool) (interface{}, error) {
+		if !present {
+			return nil, fmt.Errorf("missing yaml value")
+		}
+		var result interface{}
+		if err := yaml.Unmarshal([]byte(plain), &result); err != nil {
+			return nil, fmt.Errorf("illegal yaml value: %w", err)
+		}
+		return normalizeValue(result), nil
+	})
+	ValueAssignmentParserFile = ValueAssignmentParser(func(plain string, present bool) (interface{}, error) {
+		if !present || plain == "" {
+			return nil, fmt.Errorf("missing file")
+		}
+		content, err := ioutil.ReadFile(plain)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read value file: %w", err)
+		}
+		return string(content), nil
+	})
+)
+
+// ValueAssignmentsFlag appends every <name>=<value> it receives to Target using
+// Parser. This allows several flags to share the same ValueAssignments while
+// their order is retained.
+type ValueAssignmentsFlag struct {
+	Target *ValueAssignments
+	Parser ValueAssignmentParser
+	// Name of the flag which is used to record the origin of the assignments.
+	Name string
+}
+
+func (instance *ValueAssignmentsFlag) IsCumulative() bool {
+	return true
+}
+
+func (instance *ValueAssignmentsFlag) Set(plain string) error {
+	parts := strings.SplitN(plain, "=", 2)
+	path, err := ParseValuePath(parts[0])
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if len(parts) > 1 {
+		value, err = instance.Parser(parts[1], true)
+	} else {
+		value, err = instance.Parser("", false)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set value '%s': %w", parts[0], err)
+	}
+	*instance.Target = append(*instance.Target, ValueAssignment{
+		Path:  path,
+		Value: value,
+		Origin: ValueOrigin{
+			Kind:     ValueOriginFlag,
+			Source:   instance.Name,
+			Argument: plain,
+		},
+	})
+	return nil
+}
+
+func (instance *ValueAssignmentsFlag) String() string {
+	if instance.Target == nil {
+		return ""
+	}
+	return instance.Target.String()
+}
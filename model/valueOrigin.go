@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ValueOriginKind string
+
+const (
+	ValueOriginDefault     = ValueOriginKind("default")
+	ValueOriginConditional = ValueOriginKind("conditional")
+	ValueOriginFile        = ValueOriginKind("file")
+	ValueOriginFlag        = ValueOriginKind("flag")
+	ValueOriginSchema      = ValueOriginKind("schema")
+	ValueOriginTest        = ValueOriginKind("test")
+)
+
+// ValueOrigin describes where a value was defined.
+type ValueOrigin struct {
+	Kind ValueOriginKind `json:"kind"`
+	// Source is the file or the flag the value was defined in.
+	Source string `json:"source,omitempty"`
+	// Line is only set if known.
+	Line int `json:"line,omitempty"`
+	// Index of the conditional values of the project.
+	Index int `json:"index"`
+	// Predicate of the conditional values of the project.
+	Predicate string `json:"predicate,omitempty"`
+	// Argument is the plain argument of a flag.
+	Argument string `json:"argument,omitempty"`
+}
+
+func (instance ValueOrigin) String() string {
+	location := instance.Source
+	if instance.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, instance.Line)
+	}
+	switch instance.Kind {
+	case ValueOriginDefault:
+		return fmt.Sprintf("default values[%d] (%s)", instance.Index, location)
+	case ValueOriginConditional:
+		return fmt.Sprintf("values[%d] on %s (%s)", instance.Index, instance.Predicate, location)
+	case ValueOriginFile:
+		return fmt.Sprintf("file %s", location)
+	case ValueOriginFlag:
+		return fmt.Sprintf("flag --%s %s", instance.Source, instance.Argument)
+	case ValueOriginSchema:
+		return fmt.Sprintf("default of schema %s", location)
+	default:
+		return fmt.Sprintf("%s %s", instance.Kind, location)
+	}
+}
+
+// ValueOrigins maps every path of a leaf value (see ParseValuePath) to its
+// origin.
+type ValueOrigins map[string]ValueOrigin
+
+func (instance ValueOrigins) record(values Values, origin ValueOrigin) {
+	instance.recordMap(nil, values, origin)
+}
+
+func (instance ValueOrigins) recordMap(prefix []string, values map[string]interface{}, origin ValueOrigin) {
+	for key, value := range values {
+		path := append(append([]string{}, prefix...), key)
+		if value == nil {
+			instance.removeAll(path)
+		} else if m, ok := normalizeValue(value).(map[string]interface{}); ok && len(m) > 0 {
+			instance.recordMap(path, m, origin)
+		} else {
+			instance[FormatValuePath(path)] = origin
+		}
+	}
+}
+
+func (instance ValueOrigins) removeAll(path []string) {
+	plain := FormatValuePath(path)
+	for candidate := range instance {
+		if candidate == plain || strings.HasPrefix(candidate, plain+".") {
+			delete(instance, candidate)
+		}
+	}
+}
+
+// retain removes all origins which do not belong to a leaf of the given values.
+func (instance ValueOrigins) retain(values Values) {
+	leaves := map[string]bool{}
+	collectValueLeaves(nil, values, leaves)
+	for candidate := range instance {
+		if !leaves[candidate] {
+			delete(instance, candidate)
+		}
+	}
+}
+
+func collectValueLeaves(prefix []string, values map[string]interface{}, to map[string]bool) {
+	for key, value := range values {
+		path := append(append([]string{}, prefix...), key)
+		if m, ok := normalizeValue(value).(map[string]interface{}); ok && len(m) > 0 {
+			collectValueLeaves(path, m, to)
+		} else {
+			to[FormatValuePath(path)] = true
+		}
+	}
+}
+
+// Paths returns all paths sorted.
+func (instance ValueOrigins) Paths() []string {
+	result := make([]string, 0, len(instance))
+	for path := range instance {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// SkippedConditionalValues describes conditional values of a project which
+// were not applied because their predicate did not match.
+type SkippedConditionalValues struct {
+	Source    string   `json:"source"`
+	Index     int      `json:"index"`
+	Predicate string   `json:"predicate"`
+	Reasons   []string `json:"reasons"`
+}
+
+// ValuesExplanation describes how the values of a project were created.
+type ValuesExplanation struct {
+	Origins ValueOrigins               `json:"origins"`
+	Skipped []SkippedConditionalValues `json:"skipped,omitempty"`
+}
+
+func NewValuesExplanation() ValuesExplanation {
+	return ValuesExplanation{
+		Origins: ValueOrigins{},
+	}
+}
+
+// FormatValuePath is the opposite of ParseValuePath.
+func FormatValuePath(path []string) string {
+	escaped := make([]string, len(path))
+	for i, part := range path {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(part, `\`, `\\`), ".", `\.`)
+	}
+	return strings.Join(escaped, ".")
+}
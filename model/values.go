@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"github.com/echocat/kubor/common"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"strings"
 )
 
@@ -12,21 +14,88 @@ func NewValues() Values {
 	return Values{}
 }
 
+// MergeWith deep merges the given input into a copy of this instance. Lists
+// are replaced. See MergeWithStrategy for more details.
 func (instance Values) MergeWith(input ...Values) Values {
-	result := Values{}
-	if instance != nil {
-		for k, v := range instance {
-			result[k] = v
-		}
-	}
+	return instance.MergeWithStrategy(ValuesListStrategyReplace, input...)
+}
+
+// MergeWithStrategy deep merges the given input into a copy of this instance.
+// Maps are merged recursively, lists are handled using the given strategy and
+// all other values are replaced. A nil value removes the key.
+func (instance Values) MergeWithStrategy(strategy ValuesListStrategy, input ...Values) Values {
+	result := mergeValueMaps(map[string]interface{}{}, instance, strategy)
 	for _, values := range input {
-		for key, value := range values {
-			result[key] = value
+		result = mergeValueMaps(result, values, strategy)
+	}
+	return result
+}
+
+func mergeValueMaps(target map[string]interface{}, source map[string]interface{}, strategy ValuesListStrategy) map[string]interface{} {
+	result := make(map[string]interface{}, len(target)+len(source))
+	for key, value := range target {
+		result[key] = value
+	}
+	for key, value := range source {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergeValue(result[key], value, strategy)
 		}
 	}
 	return result
 }
 
+func mergeValue(target interface{}, source interface{}, strategy ValuesListStrategy) interface{} {
+	source = normalizeValue(source)
+	switch s := source.(type) {
+	case map[string]interface{}:
+		t, ok := normalizeValue(target).(map[string]interface{})
+		if !ok {
+			t = map[string]interface{}{}
+		}
+		return mergeValueMaps(t, s, strategy)
+	case []interface{}:
+		if t, ok := target.([]interface{}); ok && strategy == ValuesListStrategyAppend {
+			result := make([]interface{}, 0, len(t)+len(s))
+			result = append(result, t...)
+			return append(result, s...)
+		}
+		return s
+	default:
+		return s
+	}
+}
+
+// normalizeValue returns a copy of the given value where all maps are of type
+// map[string]interface{} (yaml.v2 decodes maps as map[interface{}]interface{}).
+func normalizeValue(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = normalizeValue(value)
+		}
+		return result
+	case Values:
+		return normalizeValue(map[string]interface{}(v))
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprint(key)] = normalizeValue(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = normalizeValue(value)
+		}
+		return result
+	default:
+		return in
+	}
+}
+
 func (instance *Values) IsCumulative() bool {
 	return true
 }
@@ -57,8 +126,40 @@ func (instance *Values) Get() interface{} {
 type ConditionalValues struct {
 	On     common.EvaluatingPredicate `yaml:"on,omitempty" json:"on,omitempty"`
 	Values Values                     `yaml:",inline" json:",inline"`
+
+	source valuesSource
+}
+
+// location returns the project file and the index inside of its values these
+// values were defined at. If unknown the given fallbacks are returned.
+func (instance ConditionalValues) location(fallbackFile string, fallbackIndex int) (string, int) {
+	if instance.source.file == "" {
+		return fallbackFile, fallbackIndex
+	}
+	return instance.source.file, instance.source.index
 }
 
 func NewConditionalValuesSlice() []ConditionalValues {
 	return []ConditionalValues{}
 }
+
+// ValueFiles contains patterns of files containing values. Each pattern is
+// rendered as template; patterns prefixed with ? are optional.
+type ValueFiles []string
+
+func (instance ValueFiles) Files(data interface{}) ([]string, error) {
+	return renderFilePatterns(instance, "values", data)
+}
+
+// LoadValuesFile reads the values of the given YAML or JSON file.
+func LoadValuesFile(file string) (Values, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read values file '%s': %w", file, err)
+	}
+	result := Values{}
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("cannot read values file '%s': %w", file, err)
+	}
+	return result, nil
+}
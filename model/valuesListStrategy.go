@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ValuesListStrategyReplace = ValuesListStrategy("replace")
+	ValuesListStrategyAppend  = ValuesListStrategy("append")
+)
+
+var (
+	ErrIllegalValuesListStrategy = errors.New("illegal values list strategy")
+
+	validValuesListStrategyValues = map[ValuesListStrategy]bool{ValuesListStrategyReplace: true, ValuesListStrategyAppend: true}
+)
+
+// ValuesListStrategy defines how lists are handled if values are merged.
+type ValuesListStrategy string
+
+func (instance *ValuesListStrategy) Set(plain string) error {
+	return instance.UnmarshalText([]byte(plain))
+}
+
+func (instance ValuesListStrategy) String() string {
+	if instance == "" {
+		return string(ValuesListStrategyReplace)
+	}
+	if exist := validValuesListStrategyValues[instance]; !exist {
+		return fmt.Sprintf("illegal-values-list-strategy-%s", string(instance))
+	}
+	return string(instance)
+}
+
+func (instance ValuesListStrategy) MarshalText() (text []byte, err error) {
+	if instance == "" {
+		return []byte(ValuesListStrategyReplace), nil
+	}
+	if exist := validValuesListStrategyValues[instance]; !exist {
+		return nil, fmt.Errorf("%w: %s", ErrIllegalValuesListStrategy, string(instance))
+	}
+	return []byte(instance), nil
+}
+
+func (instance *ValuesListStrategy) UnmarshalText(text []byte) error {
+	candidate := ValuesListStrategy(strings.ToLower(string(text)))
+	if candidate == "" {
+		*instance = ValuesListStrategyReplace
+		return nil
+	}
+	if exist := validValuesListStrategyValues[candidate]; !exist {
+		return fmt.Errorf("%w: %s", ErrIllegalValuesListStrategy, string(text))
+	}
+	*instance = candidate
+	return nil
+}
@@ -0,0 +1,476 @@
+package model
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/secret"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValuesSchema validates values against a JSON Schema. Supported are the
+// keywords type, enum, const, properties, required, additionalProperties,
+// items, minItems, maxItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, allOf, anyOf, oneOf, not, default and
+// local $ref (#/...). Besides the annotations (like title or description) and
+// definitions every other keyword is rejected, because a schema which uses it
+// would otherwise silently check less than expected.
+type ValuesSchema struct {
+	Source string
+	root   interface{}
+}
+
+func LoadValuesSchema(file string) (*ValuesSchema, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read values schema '%s': %w", file, err)
+	}
+	var root interface{}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("cannot read values schema '%s': %w", file, err)
+	}
+	return &ValuesSchema{
+		Source: file,
+		root:   normalizeValue(root),
+	}, nil
+}
+
+// Apply validates the given values. The result is a copy of values with all
+// defaults of the schema filled in. These defaults are returned, too.
+func (instance ValuesSchema) Apply(values Values) (Values, []ValueAssignment, error) {
+	if err := checkValuesSchemaKeywords(instance.root, "#"); err != nil {
+		return nil, nil, fmt.Errorf("cannot use values schema '%s': %w", instance.Source, err)
+	}
+	result := normalizeValue(map[string]interface{}(values)).(map[string]interface{})
+	v := &valuesSchemaValidator{
+		root:         instance.root,
+		fillDefaults: true,
+	}
+	v.validate(instance.root, result, nil)
+	if len(v.violations) > 0 {
+		return nil, nil, ValuesSchemaViolations{
+			Source:     instance.Source,
+			Violations: v.violations,
+		}
+	}
+	return result, v.defaults, nil
+}
+
+var (
+	valuesSchemaKeywords = map[string]bool{
+		"type": true, "enum": true, "const": true, "properties": true, "required": true,
+		"additionalProperties": true, "items": true, "minItems": true, "maxItems": true,
+		"minLength": true, "maxLength": true, "pattern": true, "minimum": true, "maximum": true,
+		"exclusiveMinimum": true, "exclusiveMaximum": true, "allOf": true, "anyOf": true,
+		"oneOf": true, "not": true, "default": true, "$ref": true,
+		// Annotations and definitions which do not change the validation.
+		"$schema": true, "$id": true, "id": true, "$comment": true, "title": true,
+		"description": true, "examples": true, "readOnly": true, "writeOnly": true,
+		"deprecated": true, "definitions": true, "$defs": true,
+	}
+	valuesSchemaNumberKeywords = []string{
+		"minItems", "maxItems", "minLength", "maxLength",
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	}
+)
+
+// checkValuesSchemaKeywords fails if the given schema or one of its sub schemas
+// uses a keyword which is not supported by the validator.
+func checkValuesSchemaKeywords(schema interface{}, location string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, keyword := range sortedKeys(s) {
+		if !valuesSchemaKeywords[keyword] {
+			return fmt.Errorf("%s: keyword %s is not supported", location, keyword)
+		}
+	}
+	for _, keyword := range valuesSchemaNumberKeywords {
+		if value, ok := s[keyword]; ok {
+			if _, isNumber := toFloat(value); !isNumber {
+				return fmt.Errorf("%s: keyword %s has to be a number", location, keyword)
+			}
+		}
+	}
+	for _, keyword := range []string{"properties", "definitions", "$defs"} {
+		children, _ := s[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(children) {
+			if err := checkValuesSchemaKeywords(children[name], location+"/"+keyword+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "allOf", "anyOf", "oneOf"} {
+		if children, ok := s[keyword].([]interface{}); ok {
+			for i, child := range children {
+				if err := checkValuesSchemaKeywords(child, fmt.Sprintf("%s/%s/%d", location, keyword, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties", "not"} {
+		if err := checkValuesSchemaKeywords(s[keyword], location+"/"+keyword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ValuesSchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (instance ValuesSchemaViolation) String() string {
+	return fmt.Sprintf("%s: %s", instance.Path, instance.Message)
+}
+
+type ValuesSchemaViolations struct {
+	Source     string                  `json:"source"`
+	Violations []ValuesSchemaViolation `json:"violations"`
+}
+
+func (instance ValuesSchemaViolations) Error() string {
+	result := fmt.Sprintf("values do not match schema '%s':", instance.Source)
+	for _, violation := range instance.Violations {
+		result += "\n\t" + violation.String()
+	}
+	return result
+}
+
+type valuesSchemaValidator struct {
+	root         interface{}
+	fillDefaults bool
+	violations   []ValuesSchemaViolation
+	defaults     []ValueAssignment
+}
+
+func (instance *valuesSchemaValidator) report(path []interface{}, format string, args ...interface{}) {
+	instance.violations = append(instance.violations, ValuesSchemaViolation{
+		Path:    formatJsonPath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// matches validates value against schema without reporting anything.
+func (instance *valuesSchemaValidator) matches(schema interface{}, value interface{}, path []interface{}) bool {
+	sub := &valuesSchemaValidator{root: instance.root}
+	sub.validate(schema, value, path)
+	return len(sub.violations) == 0
+}
+
+func (instance *valuesSchemaValidator) validate(schema interface{}, value interface{}, path []interface{}) {
+	if secret.IsEncrypted(value) {
+		// Cannot be validated before rendering.
+		return
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			instance.report(path, "is not allowed")
+		}
+		return
+	case map[string]interface{}:
+		if ref, ok := s["$ref"].(string); ok {
+			if resolved, err := instance.resolve(ref); err != nil {
+				instance.report(path, "%v", err)
+			} else {
+				instance.validate(resolved, value, path)
+			}
+			return
+		}
+		if !instance.validateType(s, value, path) {
+			return
+		}
+		instance.validateGeneric(s, value, path)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			instance.validateObject(s, v, path)
+		case []interface{}:
+			instance.validateArray(s, v, path)
+		case string:
+			instance.validateString(s, v, path)
+		default:
+			if number, ok := toFloat(value); ok {
+				instance.validateNumber(s, number, path)
+			}
+		}
+	}
+}
+
+func (instance *valuesSchemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local references are supported: %s", ref)
+	}
+	current := instance.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve reference: %s", ref)
+		}
+		if current, ok = m[part]; !ok {
+			return nil, fmt.Errorf("cannot resolve reference: %s", ref)
+		}
+	}
+	return current, nil
+}
+
+func (instance *valuesSchemaValidator) validateType(schema map[string]interface{}, value interface{}, path []interface{}) bool {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, candidate := range t {
+			types = append(types, fmt.Sprint(candidate))
+		}
+	default:
+		return true
+	}
+	actual := jsonTypeOf(value)
+	for _, candidate := range types {
+		if candidate == actual || (candidate == "number" && actual == "integer") {
+			return true
+		}
+	}
+	instance.report(path, "expected %s but got %s", strings.Join(types, " or "), actual)
+	return false
+}
+
+func (instance *valuesSchemaValidator) validateGeneric(schema map[string]interface{}, value interface{}, path []interface{}) {
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			instance.report(path, "must be one of %v", enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		instance.report(path, "must be %v", c)
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, candidate := range allOf {
+			instance.validate(candidate, value, path)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		found := false
+		for _, candidate := range anyOf {
+			if instance.matches(candidate, value, path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			instance.report(path, "does not match any of the schemas of anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, candidate := range oneOf {
+			if instance.matches(candidate, value, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			instance.report(path, "matches %d instead of exactly one of the schemas of oneOf", count)
+		}
+	}
+	if not, ok := schema["not"]; ok && instance.matches(not, value, path) {
+		instance.report(path, "must not match the schema of not")
+	}
+}
+
+func (instance *valuesSchemaValidator) validateObject(schema map[string]interface{}, value map[string]interface{}, path []interface{}) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if instance.fillDefaults {
+		for _, name := range sortedKeys(properties) {
+			if _, exists := value[name]; exists {
+				continue
+			}
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if def, ok := property["default"]; ok {
+					value[name] = normalizeValue(def)
+					instance.defaults = append(instance.defaults, ValueAssignment{
+						Path:  jsonPathToValuePath(append(append([]interface{}{}, path...), name)),
+						Value: value[name],
+					})
+				}
+			}
+		}
+	}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, exists := value[fmt.Sprint(name)]; !exists {
+				instance.report(append(append([]interface{}{}, path...), fmt.Sprint(name)), "is required")
+			}
+		}
+	}
+	for _, name := range sortedKeys(value) {
+		childPath := append(append([]interface{}{}, path...), name)
+		if property, ok := properties[name]; ok {
+			instance.validate(property, value[name], childPath)
+		} else if additional, ok := schema["additionalProperties"]; ok {
+			if b, isBool := additional.(bool); isBool && !b {
+				instance.report(childPath, "is not a known property")
+			} else {
+				instance.validate(additional, value[name], childPath)
+			}
+		}
+	}
+}
+
+func (instance *valuesSchemaValidator) validateArray(schema map[string]interface{}, value []interface{}, path []interface{}) {
+	if min, ok := toFloat(schema["minItems"]); ok && float64(len(value)) < min {
+		instance.report(path, "must contain at least %v items", min)
+	}
+	if max, ok := toFloat(schema["maxItems"]); ok && float64(len(value)) > max {
+		instance.report(path, "must contain at most %v items", max)
+	}
+	switch items := schema["items"].(type) {
+	case []interface{}:
+		for i, item := range value {
+			if i < len(items) {
+				instance.validate(items[i], item, append(append([]interface{}{}, path...), i))
+			}
+		}
+	case nil:
+	default:
+		for i, item := range value {
+			instance.validate(items, item, append(append([]interface{}{}, path...), i))
+		}
+	}
+}
+
+func (instance *valuesSchemaValidator) validateString(schema map[string]interface{}, value string, path []interface{}) {
+	length := float64(len([]rune(value)))
+	if min, ok := toFloat(schema["minLength"]); ok && length < min {
+		instance.report(path, "must be at least %v characters long", min)
+	}
+	if max, ok := toFloat(schema["maxLength"]); ok && length > max {
+		instance.report(path, "must be at most %v characters long", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if r, err := regexp.Compile(pattern); err != nil {
+			instance.report(path, "illegal pattern %s in schema: %v", pattern, err)
+		} else if !r.MatchString(value) {
+			instance.report(path, "must match pattern %s", pattern)
+		}
+	}
+}
+
+func (instance *valuesSchemaValidator) validateNumber(schema map[string]interface{}, value float64, path []interface{}) {
+	if min, ok := toFloat(schema["minimum"]); ok && value < min {
+		instance.report(path, "must be >= %v", min)
+	}
+	if max, ok := toFloat(schema["maximum"]); ok && value > max {
+		instance.report(path, "must be <= %v", max)
+	}
+	if min, ok := toFloat(schema["exclusiveMinimum"]); ok && value <= min {
+		instance.report(path, "must be > %v", min)
+	}
+	if max, ok := toFloat(schema["exclusiveMaximum"]); ok && value >= max {
+		instance.report(path, "must be < %v", max)
+	}
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if f, ok := toFloat(v); ok {
+			if f == math.Trunc(f) {
+				return "integer"
+			}
+			return "number"
+		}
+		return reflect.TypeOf(value).String()
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+var simpleJsonPathKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func formatJsonPath(path []interface{}) string {
+	result := "$"
+	for _, element := range path {
+		switch e := element.(type) {
+		case int:
+			result += "[" + strconv.Itoa(e) + "]"
+		default:
+			key := fmt.Sprint(e)
+			if simpleJsonPathKeyRegexp.MatchString(key) {
+				result += "." + key
+			} else {
+				result += "['" + strings.ReplaceAll(key, "'", `\'`) + "']"
+			}
+		}
+	}
+	return result
+}
+
+func jsonPathToValuePath(path []interface{}) []string {
+	result := make([]string, len(path))
+	for i, element := range path {
+		result[i] = fmt.Sprint(element)
+	}
+	return result
+}
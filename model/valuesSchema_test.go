@@ -0,0 +1,122 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ValuesSchema_Apply_fills_defaults(t *testing.T) {
+	instance := ValuesSchema{Source: "schema.json", root: normalizeValue(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"image": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string"},
+					"tag":  map[string]interface{}{"type": "string", "default": "latest"},
+				},
+			},
+			"replicas": map[string]interface{}{"type": "integer", "default": 1},
+		},
+	})}
+
+	actual, defaults, err := instance.Apply(Values{"image": map[interface{}]interface{}{"name": "foo"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Values{
+		"image":    map[string]interface{}{"name": "foo", "tag": "latest"},
+		"replicas": 1,
+	}, actual)
+	assert.Len(t, defaults, 2)
+}
+
+func Test_ValuesSchema_Apply_reports_violations(t *testing.T) {
+	instance := ValuesSchema{Source: "schema.json", root: normalizeValue(map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "values",
+		"type":                 "object",
+		"required":             []interface{}{"name"},
+		"additionalProperties": false,
+		"definitions": map[string]interface{}{
+			"port": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 65535},
+		},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "pattern": "^[a-z]+$"},
+			"ports": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/definitions/port"}},
+			"mode":  map[string]interface{}{"enum": []interface{}{"a", "b"}},
+			"a": map[string]interface{}{"properties": map[string]interface{}{
+				"b": map[string]interface{}{"properties": map[string]interface{}{
+					"c": map[string]interface{}{"required": []interface{}{"x", "y", "z"}},
+				}},
+			}},
+		},
+	})}
+
+	_, _, err := instance.Apply(Values{
+		"ports": []interface{}{80, "443", 70000},
+		"mode":  "c",
+		"typo":  true,
+		"a":     map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{}}},
+	})
+
+	assert.Equal(t, ValuesSchemaViolations{
+		Source: "schema.json",
+		Violations: []ValuesSchemaViolation{
+			{Path: "$.name", Message: "is required"},
+			{Path: "$.a.b.c.x", Message: "is required"},
+			{Path: "$.a.b.c.y", Message: "is required"},
+			{Path: "$.a.b.c.z", Message: "is required"},
+			{Path: "$.mode", Message: "must be one of [a b]"},
+			{Path: "$.ports[1]", Message: "expected integer but got string"},
+			{Path: "$.ports[2]", Message: "must be <= 65535"},
+			{Path: "$.typo", Message: "is not a known property"},
+		},
+	}, err)
+}
+
+func Test_ValuesSchema_Apply_rejects_unsupported_keywords(t *testing.T) {
+	cases := []struct {
+		name     string
+		schema   map[string]interface{}
+		expected string
+	}{{
+		name:     "patternProperties",
+		schema:   map[string]interface{}{"patternProperties": map[string]interface{}{"^a": map[string]interface{}{"type": "string"}}},
+		expected: "#: keyword patternProperties is not supported",
+	}, {
+		name:     "format",
+		schema:   map[string]interface{}{"properties": map[string]interface{}{"mail": map[string]interface{}{"type": "string", "format": "email"}}},
+		expected: "#/properties/mail: keyword format is not supported",
+	}, {
+		name:     "uniqueItems",
+		schema:   map[string]interface{}{"properties": map[string]interface{}{"ports": map[string]interface{}{"items": map[string]interface{}{"uniqueItems": true}}}},
+		expected: "#/properties/ports/items: keyword uniqueItems is not supported",
+	}, {
+		name:     "ifThenElse",
+		schema:   map[string]interface{}{"allOf": []interface{}{map[string]interface{}{"if": true, "then": true}}},
+		expected: "#/allOf/0: keyword if is not supported",
+	}, {
+		name:     "insideDefinitions",
+		schema:   map[string]interface{}{"definitions": map[string]interface{}{"a": map[string]interface{}{"minProperties": 1}}},
+		expected: "#/definitions/a: keyword minProperties is not supported",
+	}, {
+		name:     "insideNot",
+		schema:   map[string]interface{}{"not": map[string]interface{}{"dependencies": map[string]interface{}{}}},
+		expected: "#/not: keyword dependencies is not supported",
+	}, {
+		name:     "booleanExclusiveMinimum",
+		schema:   map[string]interface{}{"minimum": 1, "exclusiveMinimum": true},
+		expected: "#: keyword exclusiveMinimum has to be a number",
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			instance := ValuesSchema{Source: "schema.json", root: normalizeValue(c.schema)}
+
+			_, _, err := instance.Apply(Values{})
+
+			require.Error(t, err)
+			assert.Equal(t, "cannot use values schema 'schema.json': "+c.expected, err.Error())
+		})
+	}
+}
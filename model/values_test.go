@@ -0,0 +1,171 @@
+package model
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Values_MergeWith_deep_merges_maps(t *testing.T) {
+	base := Values{
+		"image": map[interface{}]interface{}{"name": "foo", "tag": "1.0.0"},
+		"list":  []interface{}{"a"},
+	}
+	actual := base.MergeWith(Values{
+		"image": map[string]interface{}{"tag": "1.2.3"},
+		"list":  []interface{}{"b"},
+	})
+
+	assert.Equal(t, Values{
+		"image": map[string]interface{}{"name": "foo", "tag": "1.2.3"},
+		"list":  []interface{}{"b"},
+	}, actual)
+	assert.Equal(t, map[interface{}]interface{}{"name": "foo", "tag": "1.0.0"}, base["image"])
+}
+
+func Test_Values_MergeWithStrategy_appends_lists(t *testing.T) {
+	actual := Values{"list": []interface{}{"a"}}.MergeWithStrategy(ValuesListStrategyAppend, Values{"list": []interface{}{"b"}})
+
+	assert.Equal(t, Values{"list": []interface{}{"a", "b"}}, actual)
+}
+
+func Test_Values_MergeWith_removes_nil(t *testing.T) {
+	actual := Values{
+		"a": "1",
+		"b": map[string]interface{}{"c": "2", "d": "3"},
+	}.MergeWith(Values{
+		"a": nil,
+		"b": map[string]interface{}{"c": nil},
+	})
+
+	assert.Equal(t, Values{"b": map[string]interface{}{"d": "3"}}, actual)
+}
+
+func Test_ParseValuePath(t *testing.T) {
+	actual, err := ParseValuePath(`image.tag`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"image", "tag"}, actual)
+
+	actual, err = ParseValuePath(`annotations.foo\.bar/baz`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"annotations", "foo.bar/baz"}, actual)
+
+	_, err = ParseValuePath(`image..tag`)
+	assert.Error(t, err)
+}
+
+func Test_ValueAssignments_ApplyTo(t *testing.T) {
+	var assignments ValueAssignments
+	assert.NoError(t, (&ValueAssignmentsFlag{Target: &assignments, Parser: ValueAssignmentParserString}).Set("image.tag=1.2.3"))
+	assert.NoError(t, (&ValueAssignmentsFlag{Target: &assignments, Parser: ValueAssignmentParserJson}).Set(`ports=[80,443]`))
+	assert.NoError(t, (&ValueAssignmentsFlag{Target: &assignments, Parser: ValueAssignmentParserYaml}).Set("resources={limits: {cpu: 1}}"))
+	assert.NoError(t, (&ValueAssignmentsFlag{Target: &assignments, Parser: ValueAssignmentParserJson}).Set("debug=null"))
+
+	actual := assignments.ApplyTo(Values{
+		"image": map[string]interface{}{"name": "foo"},
+		"debug": true,
+	}, ValuesListStrategyReplace)
+
+	assert.Equal(t, Values{
+		"image":     map[string]interface{}{"name": "foo", "tag": "1.2.3"},
+		"ports":     []interface{}{float64(80), float64(443)},
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": 1}},
+	}, actual)
+}
+
+func Test_ValueOrigins_tracks_last_definition(t *testing.T) {
+	instance := ValueOrigins{}
+	first := ValueOrigin{Kind: ValueOriginFile, Source: "a.yml"}
+	second := ValueOrigin{Kind: ValueOriginFlag, Source: "value"}
+	instance.record(Values{"image": map[string]interface{}{"name": "foo", "tag": "1"}, "a": "b"}, first)
+	instance.record(Values{"image": map[string]interface{}{"tag": "2"}, "a": nil}, second)
+	instance.retain(Values{"image": map[string]interface{}{"name": "foo", "tag": "2"}})
+
+	assert.Equal(t, ValueOrigins{
+		"image.name": first,
+		"image.tag":  second,
+	}, instance)
+}
+
+func Test_ProjectFactory_Create_layers_values(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-values")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, ".kubor.yml"), []byte(`
+groupId: a
+artifactId: app
+valueFiles:
+- "{{ .Root }}/file.yml"
+- "?{{ .Root }}/missing.yml"
+values:
+- c: block
+  d: block
+- "on": "{{ .Values.d }}=cli"
+  e: conditional
+- "on": "{{ .Values.d }}=block"
+  e: never
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "file.yml"), []byte("{a: file, b: file, c: file, d: file, e: file}"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "values.yml"), []byte("{b: values, c: values, d: values, e: values}"), 0644))
+
+	factory := NewProjectFactory().ForSource(filepath.Join(root, ".kubor.yml"))
+	factory.valueFiles = []string{filepath.Join(root, "values.yml")}
+	require.NoError(t, (&ValueAssignmentsFlag{Target: &factory.values, Parser: ValueAssignmentParserString}).Set("d=cli"))
+
+	actual, err := factory.Create("")
+	require.NoError(t, err)
+
+	assert.Equal(t, Values{
+		"a": "file",
+		"b": "values",
+		"c": "block",
+		"d": "cli",
+		"e": "conditional",
+	}, actual.Values)
+	assert.Equal(t, ValueOriginFile, actual.ValuesExplanation.Origins["a"].Kind)
+	assert.Equal(t, filepath.Join(root, "values.yml"), actual.ValuesExplanation.Origins["b"].Source)
+	assert.Equal(t, ValueOriginDefault, actual.ValuesExplanation.Origins["c"].Kind)
+	assert.Equal(t, ValueOriginFlag, actual.ValuesExplanation.Origins["d"].Kind)
+	assert.Equal(t, ValueOriginConditional, actual.ValuesExplanation.Origins["e"].Kind)
+	require.Len(t, actual.ValuesExplanation.Skipped, 1)
+	assert.Equal(t, 2, actual.ValuesExplanation.Skipped[0].Index)
+}
+
+func Test_ValueFiles_Files(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-values")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "a.yml"), []byte("a: 1"), 0644))
+	data := map[string]string{"Root": root}
+
+	actual, err := ValueFiles{"{{ .Root }}/a.yml", "?{{ .Root }}/missing.yml", "?{{ .Root }}/*.json"}.Files(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a.yml")}, actual)
+
+	_, err = ValueFiles{"{{ .Root }}/missing.yml"}.Files(data)
+	assert.Error(t, err)
+}
+
+func Test_ProjectFactory_ConfigureFlags_valuesOnlyFromCommandLine(t *testing.T) {
+	for _, envar := range []string{"KUBOR_VALUE", "KUBOR_VALUE_JSON", "KUBOR_VALUE_YAML", "KUBOR_VALUE_FILE"} {
+		require.NoError(t, os.Setenv(envar, "a=env"))
+		//noinspection GoUnhandledErrorResult
+		defer os.Unsetenv(envar)
+	}
+	factory := NewProjectFactory()
+	app := kingpin.New("test", "")
+	factory.ConfigureFlags(app)
+
+	_, err := app.Parse([]string{"--valueJson", "b=1"})
+	require.NoError(t, err)
+
+	require.Len(t, factory.values, 1)
+	assert.Equal(t, []string{"b"}, factory.values[0].Path)
+	assert.Equal(t, ValueOrigin{Kind: ValueOriginFlag, Source: "valueJson", Argument: "b=1"}, factory.values[0].Origin)
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultWorkspaceSource = ".kubor-workspace.yml"
+)
+
+// Workspace combines several projects (members) which are deployed together.
+type Workspace struct {
+	Members WorkspaceMembers `yaml:"members" json:"members"`
+
+	// Values set using LoadWorkspace() method.
+	Source string `yaml:"-" json:"-"`
+	Root   string `yaml:"-" json:"-"`
+}
+
+type WorkspaceMember struct {
+	// Name identifies the member inside of the workspace. If empty Path is used.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Path is either the directory of the member project or its source file.
+	// It is relative to the directory of the workspace file.
+	Path string `yaml:"path" json:"path"`
+	// DependsOn contains names of members which have to be deployed before
+	// this one.
+	DependsOn []string `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+}
+
+type WorkspaceMembers []WorkspaceMember
+
+func LoadWorkspace(file string) (Workspace, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("cannot open workspace file '%s': %w", file, err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	var result Workspace
+	if err := yaml.NewDecoder(f).Decode(&result); err != nil {
+		return Workspace{}, fmt.Errorf("cannot read workspace file '%s': %w", file, err)
+	}
+	result.Source = file
+	result.Root = filepath.Dir(file)
+	for i, member := range result.Members {
+		if member.Name == "" {
+			result.Members[i].Name = member.Path
+		}
+	}
+	if err := result.Validate(); err != nil {
+		return Workspace{}, fmt.Errorf("cannot read workspace file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+func (instance Workspace) Validate() error {
+	if len(instance.Members) == 0 {
+		return fmt.Errorf("no members defined")
+	}
+	names := map[string]bool{}
+	for i, member := range instance.Members {
+		if member.Path == "" {
+			return fmt.Errorf("members[%d]: no path defined", i)
+		}
+		if names[member.Name] {
+			return fmt.Errorf("members[%d]: duplicate name '%s'", i, member.Name)
+		}
+		names[member.Name] = true
+	}
+	for _, member := range instance.Members {
+		for _, dependency := range member.DependsOn {
+			if !names[dependency] {
+				return fmt.Errorf("member '%s' depends on unknown member '%s'", member.Name, dependency)
+			}
+		}
+	}
+	return nil
+}
+
+// Ordered returns the members in the order they have to be deployed. Every
+// member comes after all of its dependencies; members without dependencies
+// between each other keep their defined order.
+func (instance Workspace) Ordered() (WorkspaceMembers, error) {
+	byName := make(map[string]WorkspaceMember, len(instance.Members))
+	for _, member := range instance.Members {
+		byName[member.Name] = member
+	}
+
+	result := make(WorkspaceMembers, 0, len(instance.Members))
+	done := map[string]bool{}
+	var visit func(member WorkspaceMember, chain []string) error
+	visit = func(member WorkspaceMember, chain []string) error {
+		if done[member.Name] {
+			return nil
+		}
+		for i, candidate := range chain {
+			if candidate == member.Name {
+				return fmt.Errorf("cyclic dependencies between workspace members: %s", strings.Join(append(chain[i:], member.Name), " -> "))
+			}
+		}
+		chain = append(chain, member.Name)
+		for _, dependency := range member.DependsOn {
+			dm, ok := byName[dependency]
+			if !ok {
+				return fmt.Errorf("member '%s' depends on unknown member '%s'", member.Name, dependency)
+			}
+			if err := visit(dm, chain); err != nil {
+				return err
+			}
+		}
+		done[member.Name] = true
+		result = append(result, member)
+		return nil
+	}
+
+	for _, member := range instance.Members {
+		if err := visit(member, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// SourceOf returns the project source file of the given member.
+func (instance Workspace) SourceOf(member WorkspaceMember) string {
+	path := member.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(instance.Root, path)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return filepath.Join(path, ".kubor.yml")
+	}
+	return path
+}
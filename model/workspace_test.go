@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Workspace_Ordered_respectsDependencies(t *testing.T) {
+	instance := Workspace{Members: WorkspaceMembers{
+		{Name: "app", DependsOn: []string{"db", "queue"}},
+		{Name: "db", DependsOn: []string{"base"}},
+		{Name: "queue"},
+		{Name: "base"},
+	}}
+
+	actual, err := instance.Ordered()
+	require.NoError(t, err)
+
+	var names []string
+	for _, member := range actual {
+		names = append(names, member.Name)
+	}
+	assert.Equal(t, []string{"base", "db", "queue", "app"}, names)
+}
+
+func Test_Workspace_Ordered_detectsCycles(t *testing.T) {
+	instance := Workspace{Members: WorkspaceMembers{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}}
+
+	_, err := instance.Ordered()
+	assert.EqualError(t, err, "cyclic dependencies between workspace members: a -> b -> a")
+}
+
+func Test_Workspace_Validate_rejectsUnknownDependencies(t *testing.T) {
+	instance := Workspace{Members: WorkspaceMembers{
+		{Name: "a", Path: "a", DependsOn: []string{"b"}},
+	}}
+
+	assert.EqualError(t, instance.Validate(), "member 'a' depends on unknown member 'b'")
+}
@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	KeySize = 32
+	// KeyEnvar is the environment variable which could contain the key. It is
+	// never visible for templates.
+	KeyEnvar = "KUBOR_SECRET_KEY"
+)
+
+var (
+	ErrNoKey      = errors.New("no secret key configured")
+	ErrIllegalKey = errors.New("illegal secret key")
+)
+
+// Key is an AES-256 key used to encrypt and decrypt values.
+type Key []byte
+
+func GenerateKey() (Key, error) {
+	result := make(Key, KeySize)
+	if _, err := rand.Read(result); err != nil {
+		return nil, fmt.Errorf("cannot generate secret key: %w", err)
+	}
+	return result, nil
+}
+
+// ParseKey parses the base64 encoded representation of a Key.
+func ParseKey(plain string) (Key, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(plain))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIllegalKey, err)
+	}
+	if len(b) != KeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes but got %d", ErrIllegalKey, KeySize, len(b))
+	}
+	return b, nil
+}
+
+func LoadKeyFile(file string) (Key, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret key file '%s': %w", file, err)
+	}
+	result, err := ParseKey(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret key file '%s': %w", file, err)
+	}
+	return result, nil
+}
+
+// Save writes the key to the given file which will be only readable by the
+// current user.
+func (instance Key) Save(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("cannot create parent directory for secret key file '%s': %w", file, err)
+	}
+	if err := ioutil.WriteFile(file, []byte(instance.String()+"\n"), 0600); err != nil {
+		return fmt.Errorf("cannot save secret key file '%s': %w", file, err)
+	}
+	return nil
+}
+
+func (instance Key) String() string {
+	return base64.StdEncoding.EncodeToString(instance)
+}
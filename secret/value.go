@@ -0,0 +1,189 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+const (
+	encryptedPrefix = "ENC[AES256_GCM,"
+	encryptedSuffix = "]"
+	// Masked is used instead of encrypted values for logging.
+	Masked = "ENC[***]"
+)
+
+var (
+	ErrIllegalEncryptedValue = errors.New("illegal encrypted value")
+)
+
+// IsEncrypted returns true if the given value is a string which was produced
+// by Encrypt.
+func IsEncrypted(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, encryptedPrefix) && strings.HasSuffix(s, encryptedSuffix)
+}
+
+// Encrypt encrypts the given scalar. The type of the value is retained, which
+// means Decrypt will return a value of the same type.
+func (instance Key) Encrypt(v interface{}) (string, error) {
+	plain, err := yaml.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("cannot encrypt value: %w", err)
+	}
+	gcm, err := instance.gcm()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("cannot encrypt value: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+func (instance Key) Decrypt(encrypted string) (interface{}, error) {
+	if !IsEncrypted(encrypted) {
+		return nil, ErrIllegalEncryptedValue
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted[len(encryptedPrefix) : len(encrypted)-len(encryptedSuffix)])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIllegalEncryptedValue, err)
+	}
+	gcm, err := instance.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: too short", ErrIllegalEncryptedValue)
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt value (wrong key?): %w", err)
+	}
+	var result interface{}
+	if err := yaml.Unmarshal(plain, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIllegalEncryptedValue, err)
+	}
+	return result, nil
+}
+
+func (instance Key) gcm() (cipher.AEAD, error) {
+	if len(instance) == 0 {
+		return nil, ErrNoKey
+	}
+	block, err := aes.NewCipher(instance)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIllegalKey, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptAll returns a copy of the given value where every scalar which is
+// not yet encrypted is encrypted.
+func (instance Key) EncryptAll(v interface{}) (interface{}, error) {
+	return walk(v, func(scalar interface{}) (interface{}, error) {
+		if scalar == nil || IsEncrypted(scalar) {
+			return scalar, nil
+		}
+		return instance.Encrypt(scalar)
+	})
+}
+
+// DecryptAll returns a copy of the given value where every encrypted scalar is
+// decrypted.
+func (instance Key) DecryptAll(v interface{}) (interface{}, error) {
+	return instance.DecryptAllReporting(v, nil)
+}
+
+// DecryptAllReporting is like DecryptAll but calls onDecrypted (if not nil)
+// with every decrypted scalar.
+func (instance Key) DecryptAllReporting(v interface{}, onDecrypted func(plain interface{})) (interface{}, error) {
+	return walk(v, func(scalar interface{}) (interface{}, error) {
+		s, ok := scalar.(string)
+		if !ok || !IsEncrypted(s) {
+			return scalar, nil
+		}
+		plain, err := instance.Decrypt(s)
+		if err == nil && onDecrypted != nil {
+			onDecrypted(plain)
+		}
+		return plain, err
+	})
+}
+
+// ContainsEncrypted returns true if at least one scalar of the given value is
+// encrypted.
+func ContainsEncrypted(v interface{}) bool {
+	found := false
+	_, _ = walk(v, func(scalar interface{}) (interface{}, error) {
+		found = found || IsEncrypted(scalar)
+		return scalar, nil
+	})
+	return found
+}
+
+// Mask returns a copy of the given value where every encrypted scalar is
+// replaced with Masked.
+func Mask(v interface{}) interface{} {
+	result, _ := walk(v, func(scalar interface{}) (interface{}, error) {
+		if IsEncrypted(scalar) {
+			return Masked, nil
+		}
+		return scalar, nil
+	})
+	return result
+}
+
+func walk(v interface{}, f func(scalar interface{}) (interface{}, error)) (interface{}, error) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			if converted, err := walk(value, f); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			} else {
+				result[key] = converted
+			}
+		}
+		return result, nil
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(x))
+		for key, value := range x {
+			if converted, err := walk(value, f); err != nil {
+				return nil, fmt.Errorf("%v: %w", key, err)
+			} else {
+				result[key] = converted
+			}
+		}
+		return result, nil
+	case yaml.MapSlice:
+		result := make(yaml.MapSlice, len(x))
+		for i, item := range x {
+			if converted, err := walk(item.Value, f); err != nil {
+				return nil, fmt.Errorf("%v: %w", item.Key, err)
+			} else {
+				result[i] = yaml.MapItem{Key: item.Key, Value: converted}
+			}
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(x))
+		for i, value := range x {
+			if converted, err := walk(value, f); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			} else {
+				result[i] = converted
+			}
+		}
+		return result, nil
+	default:
+		return f(v)
+	}
+}
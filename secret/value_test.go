@@ -0,0 +1,43 @@
+package secret
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Key_EncryptAll_and_DecryptAll(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+	plain := map[string]interface{}{
+		"password": "hunter2",
+		"nested":   map[interface{}]interface{}{"port": 5432},
+		"list":     []interface{}{true},
+	}
+
+	encrypted, err := key.EncryptAll(plain)
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted.(map[string]interface{})["password"]))
+	assert.True(t, ContainsEncrypted(encrypted))
+	assert.Equal(t, Masked, Mask(encrypted).(map[string]interface{})["password"])
+
+	decrypted, err := key.DecryptAll(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, decrypted)
+
+	otherKey, err := GenerateKey()
+	assert.NoError(t, err)
+	_, err = otherKey.DecryptAll(encrypted)
+	assert.Error(t, err)
+}
+
+func Test_ParseKey(t *testing.T) {
+	key, err := GenerateKey()
+	assert.NoError(t, err)
+
+	actual, err := ParseKey(key.String() + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, key, actual)
+
+	_, err = ParseKey("Zm9v")
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package template
+
+import (
+	"os"
+	"sync"
+	nt "text/template"
+	"time"
+)
+
+// Cache contains parsed templates of files by their name, modification time
+// and size. All factories sharing one Cache have to provide the same
+// functions. It is safe for concurrent usage.
+type Cache struct {
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	modTime  time.Time
+	size     int64
+	code     string
+	delegate *nt.Template
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func (instance *Cache) get(file string, fi os.FileInfo) (cacheEntry, bool) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	entry, ok := instance.entries[file]
+	if !ok || !entry.modTime.Equal(fi.ModTime()) || entry.size != fi.Size() {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (instance *Cache) put(file string, fi os.FileInfo, code string, delegate *nt.Template) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	instance.entries[file] = cacheEntry{
+		modTime:  fi.ModTime(),
+		size:     fi.Size(),
+		code:     code,
+		delegate: delegate,
+	}
+}
@@ -0,0 +1,387 @@
+package chart
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	gt "text/template"
+)
+
+const (
+	// maxIncludeDepth prevents endless recursions of include and tpl.
+	maxIncludeDepth = 1000
+)
+
+var (
+	// chartMetadataNames maps the properties of Chart.yaml to the ones Helm
+	// provides inside the .Chart object; unknown properties are capitalized.
+	chartMetadataNames = map[string]string{
+		"apiVersion":  "APIVersion",
+		"appVersion":  "AppVersion",
+		"kubeVersion": "KubeVersion",
+	}
+)
+
+// Chart is a Helm chart loaded from a directory or a .tgz archive. Only the
+// chart itself is supported; dependencies (charts/) are ignored.
+type Chart struct {
+	Source   string
+	Metadata map[string]interface{}
+	Values   map[string]interface{}
+
+	// files contains every file of the chart by its slash separated path
+	// relative to the root of the chart.
+	files map[string][]byte
+}
+
+type Release struct {
+	Name      string
+	Namespace string
+	Service   string
+	Revision  int
+	IsInstall bool
+	IsUpgrade bool
+}
+
+type KubeVersion struct {
+	Version    string
+	Major      string
+	Minor      string
+	GitVersion string
+}
+
+func (instance KubeVersion) String() string {
+	return instance.Version
+}
+
+type VersionSet []string
+
+func (instance VersionSet) Has(apiVersion string) bool {
+	for _, candidate := range instance {
+		if candidate == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+type Capabilities struct {
+	KubeVersion KubeVersion
+	APIVersions VersionSet
+}
+
+func DefaultCapabilities() Capabilities {
+	return Capabilities{
+		KubeVersion: KubeVersion{
+			Version:    "v1.19.0",
+			Major:      "1",
+			Minor:      "19",
+			GitVersion: "v1.19.0",
+		},
+		APIVersions: VersionSet{"v1", "apps/v1", "batch/v1", "batch/v1beta1", "networking.k8s.io/v1", "networking.k8s.io/v1beta1", "policy/v1beta1", "rbac.authorization.k8s.io/v1"},
+	}
+}
+
+// Files provides access to the non template files of the chart like .Files
+// of Helm does.
+type Files map[string][]byte
+
+func (instance Files) Get(name string) string {
+	return string(instance[name])
+}
+
+func (instance Files) GetBytes(name string) []byte {
+	return instance[name]
+}
+
+func (instance Files) Glob(pattern string) Files {
+	result := Files{}
+	for name, content := range instance {
+		if matches, _ := path.Match(pattern, name); matches {
+			result[name] = content
+		}
+	}
+	return result
+}
+
+func (instance Files) AsConfig() (string, error) {
+	return instance.asMap(false)
+}
+
+func (instance Files) AsSecrets() (string, error) {
+	return instance.asMap(true)
+}
+
+func (instance Files) asMap(encode bool) (string, error) {
+	result := map[string]string{}
+	for name, content := range instance {
+		if encode {
+			result[path.Base(name)] = base64.StdEncoding.EncodeToString(content)
+		} else {
+			result[path.Base(name)] = string(content)
+		}
+	}
+	b, err := yaml.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// Document is one rendered template file of a chart.
+type Document struct {
+	// Name is the name of the template like Helm provides it as .Template.Name.
+	Name string
+	// File is the slash separated path relative to the root of the chart.
+	File    string
+	Content []byte
+}
+
+// Load reads the chart from the given directory or .tgz archive.
+func Load(source string) (*Chart, error) {
+	fi, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load chart '%s': %w", source, err)
+	}
+	var files map[string][]byte
+	if fi.IsDir() {
+		files, err = loadDirectory(source)
+	} else {
+		files, err = loadArchive(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load chart '%s': %w", source, err)
+	}
+
+	result := &Chart{
+		Source: source,
+		files:  files,
+	}
+	metadata, ok := files["Chart.yaml"]
+	if !ok {
+		return nil, fmt.Errorf("cannot load chart '%s': Chart.yaml is missing", source)
+	}
+	var plainMetadata map[string]interface{}
+	if err := yaml.Unmarshal(metadata, &plainMetadata); err != nil {
+		return nil, fmt.Errorf("cannot load chart '%s': illegal Chart.yaml: %w", source, err)
+	}
+	result.Metadata = map[string]interface{}{}
+	for key, value := range plainMetadata {
+		name, ok := chartMetadataNames[key]
+		if !ok {
+			name = strings.ToUpper(key[:1]) + key[1:]
+		}
+		result.Metadata[name] = normalize(value)
+	}
+	if name, _ := result.Metadata["Name"].(string); name == "" {
+		return nil, fmt.Errorf("cannot load chart '%s': Chart.yaml does not contain a name", source)
+	}
+
+	result.Values = map[string]interface{}{}
+	if values, ok := files["values.yaml"]; ok {
+		var plainValues map[string]interface{}
+		if err := yaml.Unmarshal(values, &plainValues); err != nil {
+			return nil, fmt.Errorf("cannot load chart '%s': illegal values.yaml: %w", source, err)
+		}
+		if plainValues != nil {
+			result.Values = normalize(plainValues).(map[string]interface{})
+		}
+	}
+
+	return result, nil
+}
+
+func (instance *Chart) Name() string {
+	name, _ := instance.Metadata["Name"].(string)
+	return name
+}
+
+// Render renders every template of the chart. The given values overlay the
+// values.yaml of the chart. Partials (files starting with _) and NOTES.txt
+// are not rendered; documents which are empty after rendering are omitted.
+func (instance *Chart) Render(release Release, values map[string]interface{}, capabilities Capabilities) ([]Document, error) {
+	overlay, _ := normalize(values).(map[string]interface{})
+	mergedValues := mergeValues(instance.Values, overlay)
+
+	root := gt.New(instance.Name()).Option("missingkey=zero")
+	funcs, err := funcMap(root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create functions of chart '%s': %w", instance.Source, err)
+	}
+	root.Funcs(funcs)
+
+	var names []string
+	files := Files{}
+	for name, content := range instance.files {
+		if !strings.HasPrefix(name, "templates/") {
+			if !strings.HasPrefix(name, "charts/") && name != "Chart.yaml" && name != "values.yaml" {
+				files[name] = content
+			}
+			continue
+		}
+		if _, err := root.New(instance.templateName(name)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("cannot parse template '%s' of chart '%s': %w", name, instance.Source, err)
+		}
+		base := path.Base(name)
+		if !strings.HasPrefix(base, "_") && base != "NOTES.txt" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var result []Document
+	for _, name := range names {
+		templateName := instance.templateName(name)
+		data := map[string]interface{}{
+			"Values":       mergedValues,
+			"Release":      release,
+			"Chart":        instance.Metadata,
+			"Capabilities": capabilities,
+			"Files":        files,
+			"Template": map[string]interface{}{
+				"Name":     templateName,
+				"BasePath": instance.Name() + "/templates",
+			},
+		}
+		buf := new(bytes.Buffer)
+		if err := root.ExecuteTemplate(buf, templateName, data); err != nil {
+			return nil, fmt.Errorf("cannot render template '%s' of chart '%s': %w", name, instance.Source, err)
+		}
+		content := strings.Replace(buf.String(), "<no value>", "", -1)
+		if strings.TrimSpace(strings.Replace(content, "---", "", -1)) == "" {
+			continue
+		}
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		result = append(result, Document{
+			Name:    templateName,
+			File:    name,
+			Content: []byte(content),
+		})
+	}
+	return result, nil
+}
+
+func (instance *Chart) templateName(file string) string {
+	return instance.Name() + "/" + file
+}
+
+func loadDirectory(dir string) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		result[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return result, err
+}
+
+// loadArchive reads a .tgz file like it is created by helm package. The
+// top level directory (the name of the chart) is removed from every path.
+func loadArchive(file string) (map[string][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer gr.Close()
+
+	result := map[string][]byte{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) != 2 || strings.HasPrefix(parts[1], "../") {
+			continue
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		result[parts[1]] = content
+	}
+}
+
+// mergeValues returns a copy of base overlaid by override. Maps are merged
+// recursively and a nil value of override removes the value of base.
+func mergeValues(base, override map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range override {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		if o, ok := value.(map[string]interface{}); ok {
+			if b, ok := result[key].(map[string]interface{}); ok {
+				result[key] = mergeValues(b, o)
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func normalize(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = normalize(value)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprint(key)] = normalize(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = normalize(value)
+		}
+		return result
+	default:
+		return in
+	}
+}
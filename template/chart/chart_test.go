@@ -0,0 +1,98 @@
+package chart
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	gt "text/template"
+)
+
+func Test_Chart_Render(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-chart")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"Chart.yaml":  "name: foo\nversion: 1.0.0\nappVersion: \"2.0\"\n",
+		"values.yaml": "replicas: 1\nimage: {name: foo, tag: \"\"}\nenabled: false\n",
+		"templates/_helpers.tpl": `{{- define "foo.name" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end -}}`,
+		"templates/a.yaml": `name: {{ include "foo.name" . | quote }}
+replicas: {{ .Values.replicas }}
+image: {{ .Values.image.name }}:{{ .Values.image.tag | default .Chart.AppVersion }}
+labels: {{- dict "a" "b" | toYaml | nindent 2 }}`,
+		"templates/b.yaml": `{{- if .Values.enabled }}
+enabled: true
+{{- end }}`,
+	} {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	c, err := Load(dir)
+	require.NoError(t, err)
+
+	actual, err := c.Render(Release{Name: "rel"}, map[string]interface{}{"replicas": 3}, DefaultCapabilities())
+	require.NoError(t, err)
+
+	assert.Equal(t, []Document{{
+		Name:    "foo/templates/a.yaml",
+		File:    "templates/a.yaml",
+		Content: []byte("name: \"rel-foo\"\nreplicas: 3\nimage: foo:2.0\nlabels:\n  a: b\n"),
+	}}, actual)
+}
+
+func Test_funcMap(t *testing.T) {
+	require.NoError(t, os.Setenv("KUBOR_TEST_CHART", "secret"))
+	//noinspection GoUnhandledErrorResult
+	defer os.Unsetenv("KUBOR_TEST_CHART")
+	cases := []struct {
+		template string
+		expected string
+	}{
+		{`{{ append (list 1 2) 3 }}|{{ prepend (list 1 2) 0 }}`, "[1 2 3]|[0 1 2]"},
+		{`{{ hasKey .m "a" }}|{{ get .m "a" }}|{{ set (dict) "b" 2 }}|{{ unset (dict "a" 1 "b" 2) "a" }}`, "true|1|map[b:2]|map[b:2]"},
+		{`{{ substr 1 3 "abcd" }}|{{ trunc -2 "abc" }}|{{ regexReplaceAll "b" "abcb" "x" }}|{{ regexFindAll "b" "abcb" -1 }}`, "bc|bc|axcx|[b b]"},
+		{`{{ quote "a" .nil }}|{{ cat "a" .nil "b" }}|{{ toString .nil }}|{{ b64enc "abc" }}|{{ snakecase "FooBar" }}`, `"a"|a b||YWJj|foo_bar`},
+		{`{{ merge (dict "a" 1) (dict "a" 2 "b" 3) }}|{{ mergeOverwrite (dict "a" 1) (dict "a" 2) }}`, "map[a:1 b:3]|map[a:2]"},
+		{`{{ toYaml .m }}|{{ uuidv4 | len }}|{{ add 1 2 "3" }}|{{ max 1 5 3 }}`, "a: 1|36|6|5"},
+		{`{{ normalizeLabelValue "a b" }}|{{ semverBumpMinor "1.2.3" }}|{{ env "KUBOR_TEST_CHART" }}`, "a_b|1.3.0|"},
+	}
+	for _, c := range cases {
+		root := gt.New("test")
+		funcs, err := funcMap(root)
+		require.NoError(t, err)
+		_, err = root.Funcs(funcs).Parse(c.template)
+		require.NoError(t, err, c.template)
+		buf := new(bytes.Buffer)
+		require.NoError(t, root.Execute(buf, map[string]interface{}{"m": map[string]interface{}{"a": 1}}), c.template)
+		assert.Equal(t, c.expected, buf.String(), c.template)
+	}
+}
+
+func Test_semverCompare(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=1.16-0", "v1.19.0", true},
+		{">=1.16-0", "1.16.3-gke.1", true},
+		{"<1.16", "1.19.0", false},
+		{">=1.10, <1.20", "1.19.4", true},
+		{"~1.19.0", "1.20.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"<1.0 || >=1.19", "1.19.0", true},
+	}
+	for _, c := range cases {
+		actual, err := semverCompare(c.constraint, c.version)
+		assert.NoError(t, err, "%s %s", c.constraint, c.version)
+		assert.Equal(t, c.expected, actual, "%s %s", c.constraint, c.version)
+	}
+}
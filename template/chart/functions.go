@@ -0,0 +1,464 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"github.com/echocat/kubor/template/functions"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"strconv"
+	"strings"
+	gt "text/template"
+)
+
+var (
+	// helmAliases maps the names of Helm functions to the kubor functions
+	// which are doing the same with the same arguments.
+	helmAliases = map[string]string{
+		"b64enc":     "encodeBase64",
+		"b64dec":     "decodeBase64",
+		"camelcase":  "camelCase",
+		"snakecase":  "snakeCase",
+		"kebabcase":  "kebabCase",
+		"untitle":    "uncapitalize",
+		"squote":     "sQuote",
+		"list":       "array",
+		"int":        "toInt",
+		"int64":      "toInt64",
+		"float64":    "toFloat64",
+		"regexMatch": "regexpMatch",
+		"regexFind":  "regexpFind",
+	}
+	// kuborOnlyFunctions require a kubor template to be executed against and
+	// are not available inside of charts.
+	kuborOnlyFunctions = []string{"render", "sourceFile", "sourceName"}
+)
+
+// delegate is a kubor function as it is provided by template.Functions.CreateFuncMap.
+type delegate = func(args ...interface{}) (interface{}, error)
+
+// funcMap returns the functions which are supported inside of charts: the
+// default functions of kubor overlaid by the ones of Helm which kubor does not
+// provide or which have another name, argument order or behavior in Helm.
+// include and tpl are executed against root.
+func funcMap(root *gt.Template) (gt.FuncMap, error) {
+	fns, err := functions.CategoriesDefault.GetFunctions()
+	if err != nil {
+		return nil, err
+	}
+	result, err := fns.CreateFuncMap(&template.ExecutionContextImpl{
+		Factory: &template.FactoryImpl{
+			FunctionProvider: functions.CategoriesDefault,
+			// Charts are not allowed to access the environment of kubor.
+			Environment: map[string]string{},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range kuborOnlyFunctions {
+		delete(result, name)
+	}
+	kubor := make(map[string]delegate, len(result))
+	for name, f := range result {
+		kubor[name] = f.(delegate)
+	}
+	for alias, name := range helmAliases {
+		result[alias] = result[name]
+	}
+	for name, f := range helmFunctions(root, func(name string) delegate { return kubor[name] }) {
+		result[name] = f
+	}
+	return result, nil
+}
+
+func helmFunctions(root *gt.Template, kubor func(name string) delegate) gt.FuncMap {
+	depth := 0
+	include := func(name string, data interface{}) (string, error) {
+		if depth > maxIncludeDepth {
+			return "", fmt.Errorf("rendering template has a nested reference name: %s", name)
+		}
+		depth++
+		defer func() { depth-- }()
+		buf := new(bytes.Buffer)
+		if err := root.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	tpl := func(code string, data interface{}) (string, error) {
+		if depth > maxIncludeDepth {
+			return "", fmt.Errorf("rendering template has too many nested tpl calls")
+		}
+		depth++
+		defer func() { depth-- }()
+		clone, err := root.Clone()
+		if err != nil {
+			return "", err
+		}
+		if _, err := clone.New("tpl").Parse(code); err != nil {
+			return "", fmt.Errorf("cannot parse tpl: %w", err)
+		}
+		buf := new(bytes.Buffer)
+		if err := clone.ExecuteTemplate(buf, "tpl", data); err != nil {
+			return "", err
+		}
+		return strings.Replace(buf.String(), "<no value>", "", -1), nil
+	}
+	toInt64 := func(v interface{}) int64 {
+		result, _ := kubor("toInt64")(v)
+		i, _ := result.(int64)
+		return i
+	}
+	reduce := func(f func(a, b int64) int64) func(in ...interface{}) int64 {
+		return func(in ...interface{}) int64 {
+			if len(in) == 0 {
+				return 0
+			}
+			result := toInt64(in[0])
+			for _, v := range in[1:] {
+				result = f(result, toInt64(v))
+			}
+			return result
+		}
+	}
+	divide := func(f func(a, b int64) int64) func(a, b interface{}) (int64, error) {
+		return func(a, b interface{}) (int64, error) {
+			if toInt64(b) == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return f(toInt64(a), toInt64(b)), nil
+		}
+	}
+	isEmpty := func(v interface{}) bool {
+		result, _ := kubor("empty")(v)
+		return result == true
+	}
+	// merge merges the sources into dst (which will be modified). If overwrite
+	// is false existing values of dst are kept.
+	var merge func(overwrite bool, dst map[string]interface{}, sources ...map[string]interface{}) map[string]interface{}
+	merge = func(overwrite bool, dst map[string]interface{}, sources ...map[string]interface{}) map[string]interface{} {
+		for _, source := range sources {
+			for key, value := range source {
+				existing, exists := dst[key]
+				em, eok := existing.(map[string]interface{})
+				sm, sok := value.(map[string]interface{})
+				if eok && sok {
+					dst[key] = merge(overwrite, em, sm)
+				} else if !exists || overwrite || isEmpty(existing) {
+					dst[key], _ = kubor("deepCopy")(value)
+				}
+			}
+		}
+		return dst
+	}
+
+	return gt.FuncMap{
+		// Templating
+		"include":  include,
+		"tpl":      tpl,
+		"required": required,
+		"fail":     fail,
+		"lookup":   lookup,
+
+		// Strings; Helm skips nil values and supports negative lengths of trunc.
+		"trimPrefix": func(prefix, in string) string { return strings.TrimPrefix(in, prefix) },
+		"title":      strings.Title,
+		"substr":     substr,
+		"nospace":    func(in string) string { return strings.Join(strings.Fields(in), "") },
+		"trunc":      trunc,
+		"quote":      quote,
+		"cat":        func(in ...interface{}) string { return strings.Join(toStrings(in), " ") },
+		"nindent":    func(spaces int, in string) string { return "\n" + indent(spaces, in) },
+		"plural":     plural,
+		"split":      split,
+		"splitList":  func(sep, in string) []string { return strings.Split(in, sep) },
+		"join":       func(sep string, v interface{}) string { return strings.Join(toStrings(v), sep) },
+		"toString":   toString,
+		"toStrings":  toStrings,
+		"uuidv4": func() (string, error) {
+			result, err := kubor("uuid")()
+			return fmt.Sprint(result), err
+		},
+
+		// Defaults and logic
+		"coalesce": func(in ...interface{}) interface{} {
+			for _, v := range in {
+				if !isEmpty(v) {
+					return v
+				}
+			}
+			return nil
+		},
+		"ternary": func(whenTrue, whenFalse interface{}, condition bool) interface{} {
+			if condition {
+				return whenTrue
+			}
+			return whenFalse
+		},
+
+		// Types; Helm ignores errors of the conversions.
+		"toYaml":   toYaml,
+		"fromYaml": fromYaml,
+		"fromJson": fromJson,
+		"atoi":     func(in string) int { v, _ := strconv.Atoi(in); return v },
+		"kindOf":   kindOf,
+		"kindIs":   func(kind string, v interface{}) bool { return kindOf(v) == kind },
+		"typeOf":   func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"typeIs":   func(t string, v interface{}) bool { return fmt.Sprintf("%T", v) == t },
+
+		// Lists; Helm expects the list first.
+		"initial": func(v interface{}) []interface{} {
+			list := toList(v)
+			if len(list) == 0 {
+				return list
+			}
+			return list[:len(list)-1]
+		},
+		"append":  func(list interface{}, v interface{}) (interface{}, error) { return kubor("append")(v, toList(list)) },
+		"prepend": func(list interface{}, v interface{}) (interface{}, error) { return kubor("prepend")(v, toList(list)) },
+		"concat": func(lists ...interface{}) []interface{} {
+			var result []interface{}
+			for _, list := range lists {
+				result = append(result, toList(list)...)
+			}
+			return result
+		},
+		"has": func(needle interface{}, haystack interface{}) bool {
+			for _, item := range toList(haystack) {
+				if reflect.DeepEqual(item, needle) {
+					return true
+				}
+			}
+			return false
+		},
+		"reverse": func(v interface{}) []interface{} {
+			list := toList(v)
+			result := make([]interface{}, len(list))
+			for i, item := range list {
+				result[len(list)-1-i] = item
+			}
+			return result
+		},
+
+		// Dictionaries; Helm expects the dictionary first.
+		"dict": dict,
+		"get":  func(d map[string]interface{}, key string) interface{} { return d[key] },
+		"set": func(d map[string]interface{}, key string, v interface{}) (interface{}, error) {
+			return kubor("set")(key, v, d)
+		},
+		"unset":  func(d map[string]interface{}, key string) (interface{}, error) { return kubor("unset")(key, d) },
+		"hasKey": func(d map[string]interface{}, key string) (interface{}, error) { return kubor("hasKey")(key, d) },
+		"keys": func(dicts ...map[string]interface{}) []string {
+			var result []string
+			for _, d := range dicts {
+				for key := range d {
+					result = append(result, key)
+				}
+			}
+			return result
+		},
+		"merge": func(dst map[string]interface{}, sources ...map[string]interface{}) map[string]interface{} {
+			return merge(false, dst, sources...)
+		},
+		"mergeOverwrite": func(dst map[string]interface{}, sources ...map[string]interface{}) map[string]interface{} {
+			return merge(true, dst, sources...)
+		},
+
+		// Math
+		"add":  reduce(func(a, b int64) int64 { return a + b }),
+		"add1": func(v interface{}) int64 { return toInt64(v) + 1 },
+		"sub":  func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
+		"mul":  reduce(func(a, b int64) int64 { return a * b }),
+		"div":  divide(func(a, b int64) int64 { return a / b }),
+		"mod":  divide(func(a, b int64) int64 { return a % b }),
+		"max": reduce(func(a, b int64) int64 {
+			if a > b {
+				return a
+			}
+			return b
+		}),
+		"min": reduce(func(a, b int64) int64 {
+			if a < b {
+				return a
+			}
+			return b
+		}),
+
+		// Regular expressions; Helm expects the input before the other arguments.
+		"regexFindAll": func(expression, in string, n int) (interface{}, error) {
+			return kubor("regexpFindAll")(expression, n, in)
+		},
+		"regexReplaceAll": func(expression, in, replacement string) (interface{}, error) {
+			return kubor("regexpReplaceAll")(expression, replacement, in)
+		},
+
+		// Versions; Helm ignores pre releases of the version.
+		"semverCompare": semverCompare,
+	}
+}
+
+func required(message string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.New(message)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.New(message)
+	}
+	return v, nil
+}
+
+func fail(message string) (string, error) {
+	return "", errors.New(message)
+}
+
+// lookup always returns nothing; like helm template does, charts are rendered
+// without asking the cluster.
+func lookup(string, string, string, string) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func substr(start, end int, in string) string {
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 || end > len(in) {
+		end = len(in)
+	}
+	if start > end {
+		return ""
+	}
+	return in[start:end]
+}
+
+func trunc(length int, in string) string {
+	if length < 0 && len(in)+length > 0 {
+		return in[len(in)+length:]
+	}
+	if length >= 0 && len(in) > length {
+		return in[:length]
+	}
+	return in
+}
+
+func quote(in ...interface{}) string {
+	result := toStrings(in)
+	for i, s := range result {
+		result[i] = strconv.Quote(s)
+	}
+	return strings.Join(result, " ")
+}
+
+func indent(spaces int, in string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(in, "\n", "\n"+pad, -1)
+}
+
+func plural(one, many string, count int) string {
+	if count == 1 {
+		return one
+	}
+	return many
+}
+
+func split(sep, in string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for i, part := range strings.Split(in, sep) {
+		result["_"+strconv.Itoa(i)] = part
+	}
+	return result
+}
+
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toStrings(v interface{}) []string {
+	list := toList(v)
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != nil {
+			result = append(result, toString(item))
+		}
+	}
+	return result
+}
+
+func toYaml(v interface{}) string {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(b), "\n")
+}
+
+func fromYaml(in string) map[string]interface{} {
+	var result map[string]interface{}
+	if err := yaml.Unmarshal([]byte(in), &result); err != nil {
+		return map[string]interface{}{"Error": err.Error()}
+	}
+	if result == nil {
+		return map[string]interface{}{}
+	}
+	return normalize(result).(map[string]interface{})
+}
+
+func fromJson(in string) map[string]interface{} {
+	result := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(in), &result); err != nil {
+		return map[string]interface{}{"Error": err.Error()}
+	}
+	return result
+}
+
+func kindOf(v interface{}) string {
+	if v == nil {
+		return "invalid"
+	}
+	return reflect.ValueOf(v).Kind().String()
+}
+
+func toList(v interface{}) []interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return append([]interface{}{}, x...)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	result := make([]interface{}, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
+	}
+	return result
+}
+
+func dict(in ...interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for i := 0; i+1 < len(in); i += 2 {
+		result[toString(in[i])] = in[i+1]
+	}
+	if len(in)%2 == 1 {
+		result[toString(in[len(in)-1])] = ""
+	}
+	return result
+}
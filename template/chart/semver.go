@@ -0,0 +1,18 @@
+package chart
+
+import (
+	"github.com/echocat/kubor/template/semver"
+)
+
+// semverCompare checks if the given version matches the given constraint.
+// See semver.MatchesConstraint for the supported syntax.
+func semverCompare(constraint string, plainVersion string) (bool, error) {
+	version, err := semver.Parse(plainVersion)
+	if err != nil {
+		return false, err
+	}
+	// Like Helm does we compare without pre releases of the version, to
+	// allow checks like >=1.16-0 against versions like 1.16.3-gke.1.
+	version.Prerelease = ""
+	return semver.MatchesConstraint(constraint, version)
+}
@@ -0,0 +1,192 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	errorSnippetContext = 2
+)
+
+var (
+	errorRegexp = regexp.MustCompile(`(?s)^template: (.+?):(\d+):(?:(\d+):)? (?:executing ".*?" at <(.*?)>: )?(.*)$`)
+)
+
+// Location points to a position inside of a template. Line and Column are
+// 1-based; Column is 0 if unknown.
+type Location struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+}
+
+func (instance Location) String() string {
+	result := instance.Source
+	if instance.Line > 0 {
+		result += fmt.Sprintf(":%d", instance.Line)
+		if instance.Column > 0 {
+			result += fmt.Sprintf(":%d", instance.Column)
+		}
+	}
+	return result
+}
+
+// Error describes a failure while parsing or executing a template. If the
+// failure happened inside of a template which was included (using functions
+// like include or render) the Location points to the included template and
+// IncludedFrom contains the locations of the include calls, outermost first.
+type Error struct {
+	Location
+	// Expression is the expression which failed, like .Values.foo; it is empty
+	// for parse errors.
+	Expression   string     `json:"expression,omitempty"`
+	Message      string     `json:"message"`
+	IncludedFrom []Location `json:"includedFrom,omitempty"`
+	// Snippet contains the lines around the failing line with a caret pointing
+	// to the failing column.
+	Snippet string `json:"snippet,omitempty"`
+
+	cause error
+}
+
+// NewError creates an Error out of the given error returned by text/template
+// while parsing or executing the template of the given source with the given
+// code. If err could not be interpreted it is returned as it is.
+func NewError(source string, name string, code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	match := errorRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	location := Location{Source: source}
+	location.Line, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		column, _ := strconv.Atoi(match[3])
+		location.Column = column + 1
+	}
+
+	var included *Error
+	if errors.As(err, &included) {
+		result := *included
+		result.IncludedFrom = append([]Location{location}, included.IncludedFrom...)
+		result.cause = err
+		return &result
+	}
+
+	result := &Error{
+		Location:   location,
+		Expression: match[4],
+		Message:    match[5],
+		cause:      err,
+	}
+	// Errors inside of templates defined using {{ define }} refer to other
+	// names; for them we do not know the lines.
+	if match[1] == name {
+		result.Snippet = errorSnippetOf(code, location)
+	}
+	return result
+}
+
+func (instance *Error) Error() string {
+	result := instance.Location.String() + ": "
+	if instance.Expression != "" {
+		result += "at <" + instance.Expression + ">: "
+	}
+	result += instance.Message
+	if len(instance.IncludedFrom) > 0 {
+		froms := make([]string, len(instance.IncludedFrom))
+		for i, from := range instance.IncludedFrom {
+			froms[len(froms)-1-i] = from.String()
+		}
+		result += " (included from " + strings.Join(froms, " <- ") + ")"
+	}
+	return result
+}
+
+func (instance *Error) Unwrap() error {
+	return instance.cause
+}
+
+// Details returns the details of this error (expression, include chain and
+// snippet) which are not part of Error() as multiple lines.
+func (instance *Error) Details() string {
+	buf := new(strings.Builder)
+	if instance.Expression != "" {
+		_, _ = fmt.Fprintf(buf, "expression:    %s\n", instance.Expression)
+	}
+	for i := len(instance.IncludedFrom) - 1; i >= 0; i-- {
+		_, _ = fmt.Fprintf(buf, "included from: %v\n", instance.IncludedFrom[i])
+	}
+	if instance.Snippet != "" {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(instance.Snippet)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// LogFields returns the details of this error as fields for structured
+// logging.
+func (instance *Error) LogFields() map[string]interface{} {
+	result := map[string]interface{}{
+		"template.source":  instance.Source,
+		"template.line":    instance.Line,
+		"template.message": instance.Message,
+	}
+	if instance.Column > 0 {
+		result["template.column"] = instance.Column
+	}
+	if instance.Expression != "" {
+		result["template.expression"] = instance.Expression
+	}
+	if len(instance.IncludedFrom) > 0 {
+		froms := make([]string, len(instance.IncludedFrom))
+		for i, from := range instance.IncludedFrom {
+			froms[i] = from.String()
+		}
+		result["template.includedFrom"] = froms
+	}
+	if instance.Snippet != "" {
+		result["template.snippet"] = instance.Snippet
+	}
+	return result
+}
+
+func errorSnippetOf(code string, location Location) string {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	if location.Line < 1 || location.Line > len(lines) {
+		return ""
+	}
+	from, to := location.Line-errorSnippetContext, location.Line+errorSnippetContext
+	if from < 1 {
+		from = 1
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	width := len(strconv.Itoa(to))
+	var result []string
+	for number := from; number <= to; number++ {
+		line := strings.TrimRight(lines[number-1], "\r")
+		result = append(result, strings.TrimRight(fmt.Sprintf("%*d | %s", width, number, line), " "))
+		if number == location.Line && location.Column > 0 && location.Column <= len(line)+1 {
+			// Keep tabs to ensure that the caret is at the same position.
+			pad := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, line[:location.Column-1])
+			result = append(result, fmt.Sprintf("%*s | %s^", width, "", pad))
+		}
+	}
+	return strings.Join(result, "\n")
+}
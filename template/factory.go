@@ -5,6 +5,8 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sync"
+	nt "text/template"
 )
 
 type Factory interface {
@@ -15,36 +17,118 @@ type Factory interface {
 	Must(name string, code string) Template
 	MustFromReader(name string, reader io.Reader) Template
 	MustFromFile(file string) Template
+
+	// ResolvePath resolves the given path if it is handled by the configured
+	// PathResolver. If not, ok is false.
+	ResolvePath(path string) (resolved string, ok bool, err error)
+
+	// GetObjectLookup returns the lookup of live objects; it is nil if there is
+	// no access to a cluster.
+	GetObjectLookup() ObjectLookup
+
+	// LookupEnv returns the value of the environment variable with the given
+	// name which is available for templates.
+	LookupEnv(name string) (value string, ok bool)
+}
+
+// PathResolver resolves special paths (like references to libraries) used by
+// functions like include or readFile. If a path is not handled ok is false.
+type PathResolver func(path string) (resolved string, ok bool, err error)
+
+// ObjectLookup looks up live objects of the cluster. If an object does not
+// exist, nil is returned without error.
+type ObjectLookup interface {
+	Lookup(apiVersion, kind, namespace, name string) (map[string]interface{}, error)
+	LookupList(apiVersion, kind, namespace, labelSelector string) ([]map[string]interface{}, error)
 }
 
 type FactoryImpl struct {
 	FunctionProvider FunctionProvider
+	PathResolver     PathResolver
+	ObjectLookup     ObjectLookup
+	// Environment contains the environment variables available for
+	// templates. If nil no environment variables are available; templates
+	// never read the environment of the process directly.
+	Environment map[string]string
+	// Cache contains the parsed templates of files; if nil every file is
+	// parsed again.
+	Cache *Cache
+
+	functionsOnce sync.Once
+	functions     Functions
+	functionsErr  error
+
+	// files contains the templates of cached files created by this factory;
+	// so their functions are only bound once.
+	filesMutex sync.Mutex
+	files      map[string]*Impl
 }
 
-func (instance *FactoryImpl) new(name string, file *string, code string) (Template, error) {
-	if delegate, err := newDelegate(name, code, instance.FunctionProvider); err != nil {
+// getFunctions returns the functions of FunctionProvider which are only
+// resolved once per factory.
+func (instance *FactoryImpl) getFunctions() (Functions, error) {
+	instance.functionsOnce.Do(func() {
+		instance.functions, instance.functionsErr = instance.FunctionProvider.GetFunctions()
+	})
+	return instance.functions, instance.functionsErr
+}
+
+func (instance *FactoryImpl) new(name string, file *string, code string) (*Impl, error) {
+	functions, err := instance.getFunctions()
+	if err != nil {
 		return nil, err
+	}
+	if delegate, err := newDelegate(name, code, functions); err != nil {
+		source := name
+		if file != nil {
+			source = *file
+		}
+		return nil, NewError(source, name, code, err)
 	} else {
-		return &Impl{
-			sourceName:       name,
-			sourceFile:       file,
-			sourceCode:       code,
-			functionProvider: instance.FunctionProvider,
-			factory:          instance,
-			delegate:         delegate,
-		}, nil
+		return instance.newImpl(name, file, code, functions, delegate), nil
 	}
 }
 
+func (instance *FactoryImpl) newImpl(name string, file *string, code string, functions Functions, delegate *nt.Template) *Impl {
+	return &Impl{
+		sourceName: name,
+		sourceFile: file,
+		sourceCode: code,
+		functions:  functions,
+		factory:    instance,
+		delegate:   delegate,
+	}
+}
+
+// fileImpl returns the template of the given cached file which was already
+// created by this factory for the same delegate or a new one.
+func (instance *FactoryImpl) fileImpl(file string, code string, functions Functions, delegate *nt.Template) *Impl {
+	instance.filesMutex.Lock()
+	defer instance.filesMutex.Unlock()
+	if result, ok := instance.files[file]; ok && result.delegate == delegate {
+		return result
+	}
+	if instance.files == nil {
+		instance.files = map[string]*Impl{}
+	}
+	result := instance.newImpl(file, &file, code, functions, delegate)
+	instance.files[file] = result
+	return result
+}
+
 func (instance *FactoryImpl) New(name string, code string) (Template, error) {
-	return instance.new(name, nil, code)
+	if result, err := instance.new(name, nil, code); err != nil {
+		return nil, err
+	} else {
+		return result, nil
+	}
 }
 
 func (instance *FactoryImpl) NewFromReader(name string, reader io.Reader) (Template, error) {
 	if content, err := ioutil.ReadAll(reader); err != nil {
 		return nil, err
 	} else {
-		return instance.new(name, nil, string(content))
+		return instance.New(name, string(content))
 	}
 }
 
@@ -56,10 +140,31 @@ func (instance *FactoryImpl) NewFromFile(file string) (Template, error) {
 	} else {
 		//noinspection GoUnhandledErrorResult
 		defer f.Close()
+		// Stat before reading to ensure that a modification while reading
+		// leads to a cache miss next time.
+		var fi os.FileInfo
+		if instance.Cache != nil {
+			if fi, err = f.Stat(); err != nil {
+				return nil, fmt.Errorf("cannot read template from %s: %w", file, err)
+			}
+			if entry, ok := instance.Cache.get(file, fi); ok {
+				if functions, err := instance.getFunctions(); err != nil {
+					return nil, err
+				} else {
+					return instance.fileImpl(file, entry.code, functions, entry.delegate), nil
+				}
+			}
+		}
 		if content, err := ioutil.ReadAll(f); err != nil {
 			return nil, fmt.Errorf("cannot read template from %s: %w", file, err)
+		} else if result, err := instance.new(file, &file, string(content)); err != nil {
+			return nil, err
 		} else {
-			return instance.new(file, &file, string(content))
+			if instance.Cache != nil {
+				instance.Cache.put(file, fi, result.sourceCode, result.delegate)
+				return instance.fileImpl(file, result.sourceCode, result.functions, result.delegate), nil
+			}
+			return result, nil
 		}
 	}
 }
@@ -87,3 +192,19 @@ func (instance *FactoryImpl) MustFromFile(file string) Template {
 		return result
 	}
 }
+
+func (instance *FactoryImpl) ResolvePath(path string) (string, bool, error) {
+	if instance.PathResolver == nil {
+		return "", false, nil
+	}
+	return instance.PathResolver(path)
+}
+
+func (instance *FactoryImpl) GetObjectLookup() ObjectLookup {
+	return instance.ObjectLookup
+}
+
+func (instance *FactoryImpl) LookupEnv(name string) (string, bool) {
+	value, ok := instance.Environment[name]
+	return value, ok
+}
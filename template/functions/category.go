@@ -105,14 +105,19 @@ func (instance Categories) FilterBy(predicate FunctionPredicate) (Categories, er
 
 var CategoriesDefault = Categories{
 	"codecs":        CategoryCodecs,
+	"collections":   CategoryCollections,
 	"conversations": CategoryConversations,
+	"crypto":        CategoryCrypto,
+	"env":           CategoryEnv,
 	"general":       CategoryGeneral,
 	"kubernetes":    CategoryKubernetes,
 	"ids":           CategoryIds,
 	"math":          CategoryMath,
 	"path":          CategoryPath,
 	"regexp":        CategoryRegexp,
+	"semver":        CategorySemver,
 	"serialization": CategorySerialization,
 	"strings":       CategoryStrings,
 	"templating":    CategoryTemplating,
+	"time":          CategoryTime,
 }
@@ -0,0 +1,574 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var FuncSet = Function{
+	Description: "Sets <key> of <map> to <value>. <map> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "key",
+	}, {
+		Name: "value",
+	}, {
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "Copy of <map> which contains <key>.",
+	},
+}.MustWithFunc(func(key string, value interface{}, m map[string]interface{}) map[string]interface{} {
+	result := copyMap(m)
+	result[key] = value
+	return result
+})
+
+var FuncUnset = Function{
+	Description: "Removes <key> from <map>. <map> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "key",
+	}, {
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "Copy of <map> without <key>.",
+	},
+}.MustWithFunc(func(key string, m map[string]interface{}) map[string]interface{} {
+	result := copyMap(m)
+	delete(result, key)
+	return result
+})
+
+var FuncHasKey = Function{
+	Parameters: Parameters{{
+		Name: "key",
+	}, {
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "<true> if <map> contains <key>.",
+	},
+}.MustWithFunc(func(key string, m map[string]interface{}) bool {
+	_, ok := m[key]
+	return ok
+})
+
+var FuncKeys = Function{
+	Parameters: Parameters{{
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "Sorted keys of <map>.",
+	},
+}.MustWithFunc(func(m map[string]interface{}) []string {
+	return sortedKeysOf(m)
+})
+
+var FuncValues = Function{
+	Parameters: Parameters{{
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "Values of <map> in the order of its sorted keys.",
+	},
+}.MustWithFunc(func(m map[string]interface{}) []interface{} {
+	result := make([]interface{}, 0, len(m))
+	for _, key := range sortedKeysOf(m) {
+		result = append(result, m[key])
+	}
+	return result
+})
+
+var FuncPick = Function{
+	Description: "Selects the given <keys> of <map>.",
+	Parameters: Parameters{{
+		Name: "map",
+	}, {
+		Name: "keys",
+	}},
+	Returns: Return{
+		Description: "New map which contains only the given <keys> (if present in <map>).",
+	},
+}.MustWithFunc(func(m map[string]interface{}, keys ...string) map[string]interface{} {
+	result := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := m[key]; ok {
+			result[key] = value
+		}
+	}
+	return result
+})
+
+var FuncOmit = Function{
+	Description: "Removes the given <keys> from <map>. <map> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "map",
+	}, {
+		Name: "keys",
+	}},
+	Returns: Return{
+		Description: "New map which contains all keys of <map> except the given <keys>.",
+	},
+}.MustWithFunc(func(m map[string]interface{}, keys ...string) map[string]interface{} {
+	result := copyMap(m)
+	for _, key := range keys {
+		delete(result, key)
+	}
+	return result
+})
+
+var FuncMerge = Function{
+	Description: "Merges all given <maps> deeply. Values of later maps override the ones of earlier maps; nested maps are merged, every other value (including lists) is replaced. None of the <maps> is modified.",
+	Parameters: Parameters{{
+		Name: "maps",
+	}},
+	Returns: Return{
+		Description: "New map which contains the merged content of all <maps>.",
+	},
+}.MustWithFunc(func(maps ...map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, m := range maps {
+		result = mergeMaps(result, m)
+	}
+	return result
+})
+
+var FuncDeepCopy = Function{
+	Description: "Copies <value> and all maps and lists it contains.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Copy of <value>.",
+	},
+}.MustWithFunc(func(value interface{}) interface{} {
+	return deepCopy(value)
+})
+
+var FuncDig = Function{
+	Description: "Gets the value at <path> of <map>. The <path> is either a string with keys separated by '.' (like 'a.b.c') or a list of keys. Elements of lists are addressed by their index.",
+	Parameters: Parameters{{
+		Name: "path",
+	}, {
+		Name: "default",
+	}, {
+		Name: "map",
+	}},
+	Returns: Return{
+		Description: "Value at <path> or <default> if the path does not exist or is null.",
+	},
+}.MustWithFunc(func(path interface{}, def interface{}, m map[string]interface{}) (interface{}, error) {
+	keys, err := collectionPathOf(path)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := valueAtPath(m, keys); ok && value != nil {
+		return value, nil
+	}
+	return def, nil
+})
+
+var FuncFirst = Function{
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "First element of <list> or null if it is empty.",
+	},
+}.MustWithFunc(func(list []interface{}) interface{} {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[0]
+})
+
+var FuncLast = Function{
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "Last element of <list> or null if it is empty.",
+	},
+}.MustWithFunc(func(list []interface{}) interface{} {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+})
+
+var FuncRest = Function{
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "All elements of <list> except the first one.",
+	},
+}.MustWithFunc(func(list []interface{}) []interface{} {
+	if len(list) == 0 {
+		return []interface{}{}
+	}
+	return append([]interface{}{}, list[1:]...)
+})
+
+var FuncAppend = Function{
+	Description: "Appends <value> to <list>. <list> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "value",
+	}, {
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list which contains all elements of <list> followed by <value>.",
+	},
+}.MustWithFunc(func(value interface{}, list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list)+1)
+	return append(append(result, list...), value)
+})
+
+var FuncPrepend = Function{
+	Description: "Prepends <value> to <list>. <list> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "value",
+	}, {
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list which contains <value> followed by all elements of <list>.",
+	},
+}.MustWithFunc(func(value interface{}, list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list)+1)
+	return append(append(result, value), list...)
+})
+
+var FuncUniq = Function{
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list which contains every element of <list> only once (first occurrence wins).",
+	},
+}.MustWithFunc(func(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, candidate := range list {
+		if !containsElement(result, candidate) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+})
+
+var FuncWithout = Function{
+	Description: "Removes all given <values> from <list>. <list> itself is not modified.",
+	Parameters: Parameters{{
+		Name: "list",
+	}, {
+		Name: "values",
+	}},
+	Returns: Return{
+		Description: "New list without the given <values>.",
+	},
+}.MustWithFunc(func(list []interface{}, values ...interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, candidate := range list {
+		if !containsElement(values, candidate) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+})
+
+var FuncSortAlpha = Function{
+	Description: "Sorts the elements of <list> by their string representation.",
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list with the sorted string representations of all elements.",
+	},
+}.MustWithFunc(func(list []interface{}) []string {
+	result := make([]string, len(list))
+	for i, element := range list {
+		result[i] = fmt.Sprint(element)
+	}
+	sort.Strings(result)
+	return result
+})
+
+var FuncSortBy = Function{
+	Description: "Sorts the maps of <list> by the value at <path> (see dig). Numbers are compared numerically, everything else by its string representation. Elements with the same value keep their order.",
+	Parameters: Parameters{{
+		Name: "path",
+	}, {
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New sorted list.",
+	},
+}.MustWithFunc(func(path interface{}, list []interface{}) ([]interface{}, error) {
+	keys, err := collectionPathOf(path)
+	if err != nil {
+		return nil, err
+	}
+	result := append([]interface{}{}, list...)
+	sortKeys := make([]interface{}, len(result))
+	for i, element := range result {
+		sortKeys[i], _ = valueAtPath(element, keys)
+	}
+	indexes := make([]int, len(result))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return lessValue(sortKeys[indexes[i]], sortKeys[indexes[j]])
+	})
+	sorted := make([]interface{}, len(result))
+	for i, index := range indexes {
+		sorted[i] = result[index]
+	}
+	return sorted, nil
+})
+
+var FuncCompact = Function{
+	Description: "Removes all empty elements (see empty) from <list>.",
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list without empty elements.",
+	},
+}.MustWithFunc(func(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, candidate := range list {
+		if !empty(candidate) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+})
+
+var FuncGroupBy = Function{
+	Description: "Groups the maps of <list> by the string representation of the value at <path> (see dig). Elements without this value are grouped by an empty string.",
+	Parameters: Parameters{{
+		Name: "path",
+	}, {
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "Map of every value to the list of elements which have this value.",
+	},
+}.MustWithFunc(func(path interface{}, list []interface{}) (map[string]interface{}, error) {
+	keys, err := collectionPathOf(path)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	for _, element := range list {
+		key := ""
+		if value, ok := valueAtPath(element, keys); ok && value != nil {
+			key = fmt.Sprint(value)
+		}
+		group, _ := result[key].([]interface{})
+		result[key] = append(group, element)
+	}
+	return result, nil
+})
+
+var FuncFlatten = Function{
+	Description: "Flattens all nested lists of <list> recursively.",
+	Parameters: Parameters{{
+		Name: "list",
+	}},
+	Returns: Return{
+		Description: "New list which does not contain lists anymore.",
+	},
+}.MustWithFunc(func(list []interface{}) []interface{} {
+	return flattenList(list, []interface{}{})
+})
+
+var FuncsCollections = Functions{
+	"set":       FuncSet,
+	"unset":     FuncUnset,
+	"hasKey":    FuncHasKey,
+	"keys":      FuncKeys,
+	"values":    FuncValues,
+	"pick":      FuncPick,
+	"omit":      FuncOmit,
+	"merge":     FuncMerge,
+	"deepCopy":  FuncDeepCopy,
+	"dig":       FuncDig,
+	"first":     FuncFirst,
+	"last":      FuncLast,
+	"rest":      FuncRest,
+	"append":    FuncAppend,
+	"prepend":   FuncPrepend,
+	"uniq":      FuncUniq,
+	"without":   FuncWithout,
+	"sortAlpha": FuncSortAlpha,
+	"sortBy":    FuncSortBy,
+	"compact":   FuncCompact,
+	"groupBy":   FuncGroupBy,
+	"flatten":   FuncFlatten,
+}
+var CategoryCollections = Category{
+	Functions: FuncsCollections,
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m)+1)
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+// mapOf returns the given value as map[string]interface{} if it is a map of any
+// key type.
+func mapOf(value interface{}) (map[string]interface{}, bool) {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m, true
+	}
+	if value == nil {
+		return nil, false
+	}
+	if coerced, ok := coerceArgument(mapOfStringsType, reflect.ValueOf(value)); ok {
+		return coerced.Interface().(map[string]interface{}), true
+	}
+	return nil, false
+}
+
+// listOf returns the given value as []interface{} if it is a slice or array of
+// any element type.
+func listOf(value interface{}) ([]interface{}, bool) {
+	if l, ok := value.([]interface{}); ok {
+		return l, true
+	}
+	if value == nil {
+		return nil, false
+	}
+	if _, ok := value.(string); ok {
+		return nil, false
+	}
+	if coerced, ok := coerceArgument(sliceOfAnyType, reflect.ValueOf(value)); ok {
+		return coerced.Interface().([]interface{}), true
+	}
+	return nil, false
+}
+
+func mergeMaps(base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	result := copyMap(base)
+	for key, value := range overlay {
+		if overlayMap, ok := mapOf(value); ok {
+			if baseMap, ok := mapOf(result[key]); ok {
+				result[key] = mergeMaps(baseMap, overlayMap)
+				continue
+			}
+			result[key] = deepCopy(overlayMap)
+			continue
+		}
+		result[key] = deepCopy(value)
+	}
+	return result
+}
+
+func deepCopy(value interface{}) interface{} {
+	if m, ok := mapOf(value); ok {
+		result := make(map[string]interface{}, len(m))
+		for key, v := range m {
+			result[key] = deepCopy(v)
+		}
+		return result
+	}
+	if l, ok := listOf(value); ok {
+		result := make([]interface{}, len(l))
+		for i, v := range l {
+			result[i] = deepCopy(v)
+		}
+		return result
+	}
+	return value
+}
+
+func collectionPathOf(path interface{}) ([]string, error) {
+	if s, ok := path.(string); ok {
+		if s == "" {
+			return []string{}, nil
+		}
+		return strings.Split(s, "."), nil
+	}
+	if l, ok := listOf(path); ok {
+		result := make([]string, len(l))
+		for i, element := range l {
+			result[i] = fmt.Sprint(element)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("path has to be either a string or a list but got: %v", reflect.TypeOf(path))
+}
+
+func valueAtPath(value interface{}, path []string) (interface{}, bool) {
+	current := value
+	for _, key := range path {
+		if m, ok := mapOf(current); ok {
+			if current, ok = m[key]; !ok {
+				return nil, false
+			}
+		} else if l, ok := listOf(current); ok {
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(l) {
+				return nil, false
+			}
+			current = l[index]
+		} else {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func containsElement(list []interface{}, candidate interface{}) bool {
+	for _, element := range list {
+		if reflect.DeepEqual(element, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func lessValue(a, b interface{}) bool {
+	if af, ok := floatOf(a); ok {
+		if bf, ok := floatOf(b); ok {
+			return af < bf
+		}
+	}
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func floatOf(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func flattenList(list []interface{}, result []interface{}) []interface{} {
+	for _, element := range list {
+		if l, ok := listOf(element); ok {
+			result = flattenList(l, result)
+		} else {
+			result = append(result, element)
+		}
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_FuncsCollections_maps(t *testing.T) {
+	data := map[string]interface{}{
+		"config": map[interface{}]interface{}{
+			"a": 1,
+			"b": map[interface{}]interface{}{"c": "x", "d": []interface{}{"y", "z"}},
+		},
+		"other": map[string]interface{}{
+			"b": map[string]interface{}{"c": "overridden", "e": true},
+		},
+	}
+	assert.Equal(t, "[a b]", mustExecuteTemplate(t, `{{ .config | keys }}`, data))
+	assert.Equal(t, "true false", mustExecuteTemplate(t, `{{ .config | hasKey "a" }} {{ .config | hasKey "x" }}`, data))
+	assert.Equal(t, "map[a:1 b:2]", mustExecuteTemplate(t, `{{ .config | set "b" 2 }}`, data))
+	assert.Equal(t, "map[a:1]", mustExecuteTemplate(t, `{{ pick .config "a" "x" }}`, data))
+	assert.Equal(t, "map[a:1]", mustExecuteTemplate(t, `{{ .config | unset "b" }}`, data))
+	assert.Equal(t, "map[a:1]", mustExecuteTemplate(t, `{{ omit .config "b" }}`, data))
+	assert.Equal(t, "2", mustExecuteTemplate(t, `{{ .config | keys | len }}`, data), "the original map has to be untouched")
+	assert.Equal(t, "map[a:1 b:map[c:overridden d:[y z] e:true]]", mustExecuteTemplate(t, `{{ merge .config .other }}`, data))
+	assert.Equal(t, "z|none|x", mustExecuteTemplate(t, `{{ dig "b.d.1" "none" .config }}|{{ dig "b.x" "none" .config }}|{{ dig (slice "b" "c") "none" .config }}`, data))
+	assert.Equal(t, "map[c:x d:[y z]]", mustExecuteTemplate(t, `{{ (deepCopy .config).b }}`, data))
+}
+
+func Test_FuncsCollections_lists(t *testing.T) {
+	data := map[string]interface{}{
+		"users": []interface{}{
+			map[interface{}]interface{}{"name": "c", "age": 30, "team": "ops"},
+			map[interface{}]interface{}{"name": "a", "age": 4, "team": "dev"},
+			map[interface{}]interface{}{"name": "b", "age": 30, "team": "dev"},
+		},
+		"tags": []string{"b", "a", "b", ""},
+	}
+	assert.Equal(t, "b||[a b ]", mustExecuteTemplate(t, `{{ first .tags }}|{{ last .tags }}|{{ rest .tags }}`, data))
+	assert.Equal(t, "[b a b  c]|[c b a b ]", mustExecuteTemplate(t, `{{ .tags | append "c" }}|{{ .tags | prepend "c" }}`, data))
+	assert.Equal(t, "[b a ]|[a]|[ a b b]|[b a b]", mustExecuteTemplate(t, `{{ uniq .tags }}|{{ without .tags "b" "" }}|{{ sortAlpha .tags }}|{{ compact .tags }}`, data))
+	assert.Equal(t, "a b c", mustExecuteTemplate(t, `{{ range $i, $u := sortBy "name" .users }}{{ if $i }} {{ end }}{{ $u.name }}{{ end }}`, data))
+	assert.Equal(t, "a c b", mustExecuteTemplate(t, `{{ range $i, $u := sortBy "age" .users }}{{ if $i }} {{ end }}{{ $u.name }}{{ end }}`, data))
+	assert.Equal(t, "dev=2 ops=1", mustExecuteTemplate(t, `{{ $g := groupBy "team" .users }}dev={{ len $g.dev }} ops={{ len $g.ops }}`, data))
+	assert.Equal(t, "[1 2 3 4]", mustExecuteTemplate(t, `{{ flatten (slice 1 (slice 2 (slice 3)) 4) }}`, nil))
+}
@@ -0,0 +1,657 @@
+package functions
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/hkdf"
+	"hash"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Certificate is a PEM encoded certificate together with its PEM encoded
+// private key.
+type Certificate struct {
+	Cert string `yaml:"cert" json:"cert"`
+	Key  string `yaml:"key" json:"key"`
+}
+
+var FuncSha1Sum = Function{
+	Parameters: Parameters{{
+		Name: "input",
+	}},
+	Returns: Return{
+		Description: "Hex encoded SHA-1 sum of <input>.",
+	},
+}.MustWithFunc(func(input string) string {
+	sum := sha1.Sum([]byte(input))
+	return hex.EncodeToString(sum[:])
+})
+
+var FuncSha256Sum = Function{
+	Parameters: Parameters{{
+		Name: "input",
+	}},
+	Returns: Return{
+		Description: "Hex encoded SHA-256 sum of <input>.",
+	},
+}.MustWithFunc(func(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+})
+
+var FuncSha512Sum = Function{
+	Parameters: Parameters{{
+		Name: "input",
+	}},
+	Returns: Return{
+		Description: "Hex encoded SHA-512 sum of <input>.",
+	},
+}.MustWithFunc(func(input string) string {
+	sum := sha512.Sum512([]byte(input))
+	return hex.EncodeToString(sum[:])
+})
+
+var FuncHmac = Function{
+	Description: "Calculates the HMAC of <input> using <key>.",
+	Parameters: Parameters{{
+		Name:        "algorithm",
+		Description: "Either sha1, sha256 or sha512.",
+	}, {
+		Name: "key",
+	}, {
+		Name: "input",
+	}},
+	Returns: Return{
+		Description: "Hex encoded HMAC.",
+	},
+}.MustWithFunc(func(algorithm string, key string, input string) (string, error) {
+	h, err := hashFor(algorithm)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(h, []byte(key))
+	_, _ = mac.Write([]byte(input))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+})
+
+var FuncBcrypt = Function{
+	Description: "Hashes <input> using bcrypt with the default cost. Every call results in another hash because of its random salt.",
+	Parameters: Parameters{{
+		Name: "input",
+	}},
+	Returns: Return{
+		Description: "bcrypt hash of <input>.",
+	},
+}.MustWithFunc(func(input string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(input), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash using bcrypt: %w", err)
+	}
+	return string(b), nil
+})
+
+var FuncHtpasswd = Function{
+	Description: "Creates an entry for a htpasswd file using bcrypt.",
+	Parameters: Parameters{{
+		Name: "username",
+	}, {
+		Name: "password",
+	}},
+	Returns: Return{
+		Description: "<username>:<bcrypt hash of password>",
+	},
+}.MustWithFunc(func(username string, password string) (string, error) {
+	if strings.Contains(username, ":") {
+		return "", fmt.Errorf("username of htpasswd entry must not contain ':' but got: %s", username)
+	}
+	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash using bcrypt: %w", err)
+	}
+	return username + ":" + string(b), nil
+})
+
+var FuncRandBytes = Function{
+	Description: "Generates <count> cryptographically secure random bytes. Every call results in other bytes; use deriveBytes to get stable ones.",
+	Parameters: Parameters{{
+		Name: "count",
+	}},
+	Returns: Return{
+		Description: "Base64 encoded random bytes.",
+	},
+}.MustWithFunc(func(count int) (string, error) {
+	if err := requirePositive("count", count); err != nil {
+		return "", err
+	}
+	b := make([]byte, count)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("cannot generate random bytes: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+})
+
+var FuncDeriveBytes = Function{
+	Description: "Derives <count> bytes from <secret> for <context> using HKDF-SHA256. The result is always the same for the same inputs, which keeps generated secrets stable across applies. <secret> should be a (encrypted) project value which is never part of the manifests itself.",
+	Parameters: Parameters{{
+		Name: "count",
+	}, {
+		Name:        "context",
+		Description: "Purpose of the derived value (like 'database-password'); different contexts result in independent values.",
+	}, {
+		Name: "secret",
+	}},
+	Returns: Return{
+		Description: "Base64 encoded derived bytes.",
+	},
+}.MustWithFunc(func(count int, context string, secret string) (string, error) {
+	b, err := deriveBytes(count, context, secret)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+})
+
+var FuncDerivePassword = Function{
+	Description: "Derives a password of <length> alphanumeric characters from <secret> for <context> (see deriveBytes).",
+	Parameters: Parameters{{
+		Name: "length",
+	}, {
+		Name: "context",
+	}, {
+		Name: "secret",
+	}},
+	Returns: Return{
+		Description: "Derived password.",
+	},
+}.MustWithFunc(func(length int, context string, secret string) (string, error) {
+	if err := requirePositive("length", length); err != nil {
+		return "", err
+	}
+	if err := requireSecret(secret); err != nil {
+		return "", err
+	}
+	reader := hkdf.New(sha256.New, []byte(secret), nil, []byte("password:"+context))
+	result := make([]byte, 0, length)
+	buf := make([]byte, 1)
+	// Reject bytes which would lead to a biased distribution.
+	limit := byte(256 - 256%len(passwordAlphabet))
+	for len(result) < length {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return "", fmt.Errorf("cannot derive password: %w", err)
+		}
+		if buf[0] < limit {
+			result = append(result, passwordAlphabet[int(buf[0])%len(passwordAlphabet)])
+		}
+	}
+	return string(result), nil
+})
+
+var FuncGenPrivateKey = Function{
+	Description: "Generates a new private key.",
+	Parameters: Parameters{{
+		Name:        "type",
+		Description: "Either rsa (2048 bits), rsa:<bits>, ecdsa (P-256), ecdsa:<p224|p256|p384|p521> or ed25519.",
+	}},
+	Returns: Return{
+		Description: "PEM encoded private key.",
+	},
+}.MustWithFunc(func(keyType string) (string, error) {
+	key, err := generatePrivateKey(keyType)
+	if err != nil {
+		return "", err
+	}
+	return encodePrivateKey(key)
+})
+
+var FuncDeriveEd25519Key = Function{
+	Description: "Derives an ed25519 private key from <secret> for <context> (see deriveBytes). The key is always the same for the same inputs.",
+	Parameters: Parameters{{
+		Name: "context",
+	}, {
+		Name: "secret",
+	}},
+	Returns: Return{
+		Description: "PEM encoded private key.",
+	},
+}.MustWithFunc(func(context string, secret string) (string, error) {
+	seed, err := deriveBytes(ed25519.SeedSize, "ed25519:"+context, secret)
+	if err != nil {
+		return "", err
+	}
+	return encodePrivateKey(ed25519.NewKeyFromSeed(seed))
+})
+
+var FuncGenCA = Function{
+	Description: "Generates a new certificate authority with an ECDSA P-256 key.",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "days",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, days int) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, days: days, ca: true}, "")
+})
+
+var FuncGenCAWithKey = Function{
+	Description: "Generates a new certificate authority for the given private key (see genPrivateKey).",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "days",
+	}, {
+		Name:        "key",
+		Description: "PEM encoded private key.",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, days int, key string) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, days: days, ca: true}, key)
+})
+
+var FuncGenSelfSignedCert = Function{
+	Description: "Generates a new self-signed certificate with an ECDSA P-256 key.",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "ips",
+	}, {
+		Name: "dnsNames",
+	}, {
+		Name: "days",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, ips []interface{}, dnsNames []interface{}, days int) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, ips: ips, dnsNames: dnsNames, days: days}, "")
+})
+
+var FuncGenSelfSignedCertWithKey = Function{
+	Description: "Generates a new self-signed certificate for the given private key (see genPrivateKey).",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "ips",
+	}, {
+		Name: "dnsNames",
+	}, {
+		Name: "days",
+	}, {
+		Name:        "key",
+		Description: "PEM encoded private key.",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, ips []interface{}, dnsNames []interface{}, days int, key string) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, ips: ips, dnsNames: dnsNames, days: days}, key)
+})
+
+var FuncGenSignedCert = Function{
+	Description: "Generates a new certificate with an ECDSA P-256 key which is signed by <ca> (see genCA).",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "ips",
+	}, {
+		Name: "dnsNames",
+	}, {
+		Name: "days",
+	}, {
+		Name: "ca",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, ips []interface{}, dnsNames []interface{}, days int, ca Certificate) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, ips: ips, dnsNames: dnsNames, days: days, parent: &ca}, "")
+})
+
+var FuncGenSignedCertWithKey = Function{
+	Description: "Generates a new certificate for the given private key (see genPrivateKey) which is signed by <ca> (see genCA).",
+	Parameters: Parameters{{
+		Name: "commonName",
+	}, {
+		Name: "ips",
+	}, {
+		Name: "dnsNames",
+	}, {
+		Name: "days",
+	}, {
+		Name: "ca",
+	}, {
+		Name:        "key",
+		Description: "PEM encoded private key.",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded).",
+	},
+}.MustWithFunc(func(commonName string, ips []interface{}, dnsNames []interface{}, days int, ca Certificate, key string) (Certificate, error) {
+	return generateCertificateWithKey(certificateRequest{commonName: commonName, ips: ips, dnsNames: dnsNames, days: days, parent: &ca}, key)
+})
+
+var FuncLookupCertificate = Function{
+	Description: "Looks up the certificate of the live TLS secret (with tls.crt and tls.key) <name> in <namespace>." +
+		" Together with genCA, genSelfSignedCert or genSignedCert this keeps certificates stable across applies:" +
+		" {{ $cert := lookupCertificate \"ns\" \"tls\" }}{{ if not $cert }}{{ $cert = genSelfSignedCert ... }}{{ end }}." +
+		" Without access to a cluster (like with --kubeconfig=mock or evaluate --offline) nothing is found.",
+	Parameters: Parameters{{
+		Name: "namespace",
+	}, {
+		Name: "name",
+	}},
+	Returns: Return{
+		Description: "Certificate with the fields Cert and Key (both PEM encoded) or nothing if the secret does not exist, is incomplete or its certificate is expired.",
+	},
+}.MustWithFunc(func(context template.ExecutionContext, namespace, name string) (*Certificate, error) {
+	lookup := context.GetFactory().GetObjectLookup()
+	if lookup == nil {
+		return nil, nil
+	}
+	secret, err := lookup.Lookup("v1", "Secret", namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lookup certificate %s/%s: %w", namespace, name, err)
+	}
+	data, _ := secret["data"].(map[string]interface{})
+	plainCert, _ := data["tls.crt"].(string)
+	plainKey, _ := data["tls.key"].(string)
+	if plainCert == "" || plainKey == "" {
+		return nil, nil
+	}
+	var result Certificate
+	if b, err := base64.StdEncoding.DecodeString(plainCert); err != nil {
+		return nil, fmt.Errorf("cannot decode tls.crt of secret %s/%s: %w", namespace, name, err)
+	} else {
+		result.Cert = string(b)
+	}
+	if b, err := base64.StdEncoding.DecodeString(plainKey); err != nil {
+		return nil, fmt.Errorf("cannot decode tls.key of secret %s/%s: %w", namespace, name, err)
+	} else {
+		result.Key = string(b)
+	}
+	cert, _, err := decodeCertificate(result)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use certificate of secret %s/%s: %w", namespace, name, err)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, nil
+	}
+	return &result, nil
+})
+
+var FuncsCrypto = Functions{
+	"sha1sum":                  FuncSha1Sum,
+	"sha256sum":                FuncSha256Sum,
+	"sha512sum":                FuncSha512Sum,
+	"hmac":                     FuncHmac,
+	"bcrypt":                   FuncBcrypt,
+	"htpasswd":                 FuncHtpasswd,
+	"randBytes":                FuncRandBytes,
+	"deriveBytes":              FuncDeriveBytes,
+	"derivePassword":           FuncDerivePassword,
+	"genPrivateKey":            FuncGenPrivateKey,
+	"deriveEd25519Key":         FuncDeriveEd25519Key,
+	"genCA":                    FuncGenCA,
+	"genCAWithKey":             FuncGenCAWithKey,
+	"genSelfSignedCert":        FuncGenSelfSignedCert,
+	"genSelfSignedCertWithKey": FuncGenSelfSignedCertWithKey,
+	"genSignedCert":            FuncGenSignedCert,
+	"genSignedCertWithKey":     FuncGenSignedCertWithKey,
+	"lookupCertificate":        FuncLookupCertificate,
+}
+var CategoryCrypto = Category{
+	Functions: FuncsCrypto,
+}
+
+func hashFor(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+}
+
+func requireSecret(secret string) error {
+	if secret == "" {
+		return fmt.Errorf("secret to derive from must not be empty")
+	}
+	return nil
+}
+
+func requirePositive(name string, value int) error {
+	if value <= 0 {
+		return fmt.Errorf("%s must be positive but is %d", name, value)
+	}
+	return nil
+}
+
+func deriveBytes(count int, context string, secret string) ([]byte, error) {
+	if err := requirePositive("count", count); err != nil {
+		return nil, err
+	}
+	if err := requireSecret(secret); err != nil {
+		return nil, err
+	}
+	result := make([]byte, count)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(context)), result); err != nil {
+		return nil, fmt.Errorf("cannot derive %d bytes: %w", count, err)
+	}
+	return result, nil
+}
+
+func generatePrivateKey(keyType string) (crypto.Signer, error) {
+	parts := strings.SplitN(strings.ToLower(keyType), ":", 2)
+	switch parts[0] {
+	case "rsa":
+		bits := 2048
+		if len(parts) > 1 {
+			var err error
+			if bits, err = strconv.Atoi(parts[1]); err != nil || bits < 1024 {
+				return nil, fmt.Errorf("illegal key type %s: bits has to be a number of at least 1024", keyType)
+			}
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	case "ecdsa":
+		curve := elliptic.P256()
+		if len(parts) > 1 {
+			switch parts[1] {
+			case "p224":
+				curve = elliptic.P224()
+			case "p256":
+			case "p384":
+				curve = elliptic.P384()
+			case "p521":
+				curve = elliptic.P521()
+			default:
+				return nil, fmt.Errorf("illegal key type %s: unsupported curve", keyType)
+			}
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+func encodePrivateKey(key crypto.Signer) (string, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return "", fmt.Errorf("cannot encode private key: %w", err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+	default:
+		b, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return "", fmt.Errorf("cannot encode private key: %w", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: b}
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+func decodePrivateKey(plain string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(plain))
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key: %T", key)
+		}
+		return signer, nil
+	}
+}
+
+type certificateRequest struct {
+	commonName string
+	ips        []interface{}
+	dnsNames   []interface{}
+	days       int
+	ca         bool
+	parent     *Certificate
+}
+
+func generateCertificateWithKey(request certificateRequest, plainKey string) (Certificate, error) {
+	if err := requirePositive("days", request.days); err != nil {
+		return Certificate{}, err
+	}
+	var key crypto.Signer
+	var err error
+	if plainKey != "" {
+		key, err = decodePrivateKey(plainKey)
+	} else {
+		key, err = generatePrivateKey("ecdsa")
+	}
+	if err != nil {
+		return Certificate{}, err
+	}
+
+	// The serial number has to be positive: [1, 2^128)
+	serial, err := rand.Int(rand.Reader, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)))
+	if err != nil {
+		return Certificate{}, fmt.Errorf("cannot generate serial number: %w", err)
+	}
+	serial.Add(serial, big.NewInt(1))
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: request.commonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(time.Duration(request.days) * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  request.ca,
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if request.ca {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	for _, plain := range request.ips {
+		ip := net.ParseIP(fmt.Sprint(plain))
+		if ip == nil {
+			return Certificate{}, fmt.Errorf("illegal IP address: %v", plain)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+	for _, dnsName := range request.dnsNames {
+		template.DNSNames = append(template.DNSNames, fmt.Sprint(dnsName))
+	}
+
+	parent, signer := template, key
+	if request.parent != nil {
+		if parent, signer, err = decodeCertificate(*request.parent); err != nil {
+			return Certificate{}, fmt.Errorf("cannot use CA: %w", err)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("cannot create certificate for %s: %w", request.commonName, err)
+	}
+	plainKey, err = encodePrivateKey(key)
+	if err != nil {
+		return Certificate{}, err
+	}
+	return Certificate{
+		Cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:  plainKey,
+	}, nil
+}
+
+func decodeCertificate(in Certificate) (*x509.Certificate, crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(in.Cert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := decodePrivateKey(in.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(mustMarshalPublicKey(cert.PublicKey), mustMarshalPublicKey(key.Public())) {
+		return nil, nil, fmt.Errorf("private key does not belong to the certificate")
+	}
+	return cert, key, nil
+}
+
+func mustMarshalPublicKey(key crypto.PublicKey) []byte {
+	b, _ := x509.MarshalPKIXPublicKey(key)
+	return b
+}
@@ -0,0 +1,56 @@
+package functions
+
+import (
+	"fmt"
+	"github.com/echocat/kubor/template"
+)
+
+var FuncEnv = Function{
+	Description: "Returns the value of the environment variable <name>. Only variables allowed by allowedEnv of the project are available.",
+	Parameters: Parameters{{
+		Name: "name",
+	}},
+	Returns: Return{
+		Description: "Value of the variable or an empty string if it is not set.",
+	},
+}.MustWithFunc(func(context template.ExecutionContext, name string) string {
+	value, _ := context.GetFactory().LookupEnv(name)
+	return value
+})
+
+var FuncRequiredEnv = Function{
+	Description: "Returns the value of the environment variable <name> and fails if it is not set or empty." +
+		" Only variables allowed by allowedEnv of the project are available.",
+	Parameters: Parameters{{
+		Name: "name",
+	}},
+}.MustWithFunc(func(context template.ExecutionContext, name string) (string, error) {
+	if value, _ := context.GetFactory().LookupEnv(name); value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("%s: required environment variable %s is not set (or not allowed by allowedEnv of the project)", context.GetTemplate().GetSource(), name)
+})
+
+var FuncEnvOr = Function{
+	Description: "Returns the value of the environment variable <name> or <default> if it is not set or empty." +
+		" Only variables allowed by allowedEnv of the project are available.",
+	Parameters: Parameters{{
+		Name: "name",
+	}, {
+		Name: "default",
+	}},
+}.MustWithFunc(func(context template.ExecutionContext, name string, def string) string {
+	if value, _ := context.GetFactory().LookupEnv(name); value != "" {
+		return value
+	}
+	return def
+})
+
+var FuncsEnv = Functions{
+	"env":         FuncEnv,
+	"requiredEnv": FuncRequiredEnv,
+	"envOr":       FuncEnvOr,
+}
+var CategoryEnv = Category{
+	Functions: FuncsEnv,
+}
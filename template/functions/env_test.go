@@ -0,0 +1,45 @@
+package functions
+
+import (
+	"github.com/echocat/kubor/secret"
+	"github.com/echocat/kubor/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func Test_FuncsEnv(t *testing.T) {
+	factory := &template.FactoryImpl{
+		FunctionProvider: CategoriesDefault,
+		Environment:      map[string]string{"CI_COMMIT_SHA": "abc123", "EMPTY": ""},
+	}
+	execute := func(source string) (string, error) {
+		tmpl, err := factory.New("deployment.yaml", source)
+		require.NoError(t, err)
+		return tmpl.ExecuteToString(nil)
+	}
+
+	actual, err := execute(`{{ env "CI_COMMIT_SHA" }}|{{ env "HOME" }}|{{ envOr "EMPTY" "none" }}|{{ envOr "CI_COMMIT_SHA" "none" }}|{{ requiredEnv "CI_COMMIT_SHA" }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123||none|abc123|abc123", actual)
+
+	_, err = execute(`{{ requiredEnv "CI_COMMIT_SHA" }}{{ requiredEnv "CI_JOB_TOKEN" }}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deployment.yaml: required environment variable CI_JOB_TOKEN is not set")
+}
+
+func Test_FuncsEnv_withoutEnvironment(t *testing.T) {
+	require.NoError(t, os.Setenv("KUBOR_TEST_ENV", "value"))
+	require.NoError(t, os.Setenv(secret.KeyEnvar, "key"))
+	//noinspection GoUnhandledErrorResult
+	defer os.Unsetenv("KUBOR_TEST_ENV")
+	//noinspection GoUnhandledErrorResult
+	defer os.Unsetenv(secret.KeyEnvar)
+
+	tmpl, err := DefaultTemplateFactory().New("test", `{{ env "KUBOR_TEST_ENV" }}{{ env "KUBOR_SECRET_KEY" }}{{ envOr "KUBOR_TEST_ENV" "none" }}`)
+	require.NoError(t, err)
+	actual, err := tmpl.ExecuteToString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "none", actual)
+}
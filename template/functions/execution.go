@@ -50,7 +50,7 @@ func (instance Function) createExecutionArguments(ft reflect.Type, context templ
 		switch ft.In(i) {
 		case executionContextType:
 		default:
-			if !ft.IsVariadic() {
+			if !ft.IsVariadic() || i < ft.NumIn()-1 {
 				numberOfRequiredParameters++
 			} else {
 				variadic = true
@@ -71,7 +71,7 @@ func (instance Function) createExecutionArguments(ft reflect.Type, context templ
 		pt := ft.In(i)
 		if pt == executionContextType {
 			result[i] = reflect.ValueOf(context)
-		} else if ft.IsVariadic() {
+		} else if ft.IsVariadic() && i == ft.NumIn()-1 {
 			if pv, err := instance.createExecutionVarargArgument(argIndex, pt, args[argIndex:]); err != nil {
 				return []reflect.Value{}, err
 			} else {
@@ -101,6 +101,9 @@ func (instance Function) createExecutionArgument(index int, pt reflect.Type, arg
 	av := valOf(pt, arg)
 	at := av.Type()
 	if !at.AssignableTo(pt) {
+		if coerced, ok := coerceArgument(pt, av); ok {
+			return coerced, nil
+		}
 		return reflect.Value{}, fmt.Errorf("%v is not assignable to %v for argument #%d", at, pt, index)
 	}
 	return av, nil
@@ -114,8 +117,17 @@ func (instance Function) createExecutionVarargArgument(index int, pt reflect.Typ
 		return reflect.New(pt).Elem()
 	}
 	av := reflect.MakeSlice(pt, len(args), len(args))
+	et := pt.Elem()
 	for i := 0; i < len(args); i++ {
-		av.Index(i).Set(valOf(pt, args[i]))
+		ev := valOf(et, args[i])
+		if !ev.Type().AssignableTo(et) {
+			if coerced, ok := coerceArgument(et, ev); ok {
+				ev = coerced
+			} else {
+				return reflect.Value{}, fmt.Errorf("%v is not assignable to %v for argument #%d", ev.Type(), et, index+i)
+			}
+		}
+		av.Index(i).Set(ev)
 	}
 	at := av.Type()
 	if !at.AssignableTo(pt) {
@@ -123,3 +135,29 @@ func (instance Function) createExecutionVarargArgument(index int, pt reflect.Typ
 	}
 	return av, nil
 }
+
+var (
+	mapOfStringsType = reflect.TypeOf(map[string]interface{}{})
+	sliceOfAnyType   = reflect.TypeOf([]interface{}{})
+)
+
+// coerceArgument converts maps of every key type (like the ones decoded from
+// YAML) into map[string]interface{} and slices of every element type into
+// []interface{} if required by the parameter type.
+func coerceArgument(pt reflect.Type, av reflect.Value) (reflect.Value, bool) {
+	switch {
+	case pt == mapOfStringsType && av.Kind() == reflect.Map:
+		result := make(map[string]interface{}, av.Len())
+		for _, key := range av.MapKeys() {
+			result[fmt.Sprint(key.Interface())] = av.MapIndex(key).Interface()
+		}
+		return reflect.ValueOf(result), true
+	case pt == sliceOfAnyType && (av.Kind() == reflect.Slice || av.Kind() == reflect.Array):
+		result := make([]interface{}, av.Len())
+		for i := 0; i < av.Len(); i++ {
+			result[i] = av.Index(i).Interface()
+		}
+		return reflect.ValueOf(result), true
+	}
+	return reflect.Value{}, false
+}
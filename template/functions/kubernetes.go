@@ -1,7 +1,9 @@
 package functions
 
 import (
+	"fmt"
 	"github.com/echocat/kubor/kubernetes/support"
+	"github.com/echocat/kubor/template"
 )
 
 var FuncNormalizeLabelValue = Function{
@@ -16,8 +18,74 @@ var FuncNormalizeLabelValue = Function{
 	return support.NormalizeLabelValue(source)
 })
 
+var FuncLookup = Function{
+	Description: "Looks up the live object with the given <name> in the cluster. Without access to a cluster (like with --kubeconfig=mock or evaluate --offline) nothing is found.",
+	Parameters: Parameters{{
+		Name:        "apiVersion",
+		Description: "Like v1 or apps/v1.",
+	}, {
+		Name: "kind",
+	}, {
+		Name:        "namespace",
+		Description: "Namespace of the object; empty for cluster scoped objects.",
+	}, {
+		Name: "name",
+	}},
+	Returns: Return{
+		Description: "The object or an empty map if it does not exist.",
+	},
+}.MustWithFunc(func(context template.ExecutionContext, apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+	lookup := context.GetFactory().GetObjectLookup()
+	if lookup == nil {
+		return map[string]interface{}{}, nil
+	}
+	result, err := lookup.Lookup(apiVersion, kind, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lookup %s %s %s/%s: %w", apiVersion, kind, namespace, name, err)
+	}
+	if result == nil {
+		return map[string]interface{}{}, nil
+	}
+	return result, nil
+})
+
+var FuncLookupList = Function{
+	Description: "Looks up all live objects of the given <kind> in the cluster which match <labelSelector>. Without access to a cluster (like with --kubeconfig=mock or evaluate --offline) nothing is found.",
+	Parameters: Parameters{{
+		Name:        "apiVersion",
+		Description: "Like v1 or apps/v1.",
+	}, {
+		Name: "kind",
+	}, {
+		Name:        "namespace",
+		Description: "Namespace of the objects; empty for cluster scoped objects or all namespaces.",
+	}, {
+		Name:        "labelSelector",
+		Description: "Like app=foo,tier!=cache; empty selects everything.",
+	}},
+	Returns: Return{
+		Description: "List of the found objects sorted by namespace and name.",
+	},
+}.MustWithFunc(func(context template.ExecutionContext, apiVersion, kind, namespace, labelSelector string) ([]interface{}, error) {
+	lookup := context.GetFactory().GetObjectLookup()
+	if lookup == nil {
+		return []interface{}{}, nil
+	}
+	objects, err := lookup.LookupList(apiVersion, kind, namespace, labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lookup %s %s in namespace '%s' with selector '%s': %w", apiVersion, kind, namespace, labelSelector, err)
+	}
+	result := make([]interface{}, len(objects))
+	for i, object := range objects {
+		result[i] = object
+	}
+	return result, nil
+})
+
 var FuncsKubernetes = Functions{
 	"normalizeLabelValue": FuncNormalizeLabelValue,
+	"lookup":              FuncLookup,
+	"lookupList":          FuncLookupList,
 }
 var CategoryKubernetes = Category{
 	Functions: FuncsKubernetes,
@@ -138,6 +138,11 @@ var CategoryPath = Category{
 }
 
 func resolvePathOfContext(context template.ExecutionContext, path string) (string, error) {
+	if resolved, ok, err := context.GetFactory().ResolvePath(path); err != nil {
+		return "", err
+	} else if ok {
+		return resolved, nil
+	}
 	if filepath.IsAbs(path) {
 		return path, nil
 	}
@@ -152,7 +157,7 @@ func resolvePathOfContext(context template.ExecutionContext, path string) (strin
 			dir = cwd
 		}
 	}
-	cleaned := filepath.Clean(dir + path)
+	cleaned := filepath.Join(dir, path)
 	return filepath.Abs(cleaned)
 }
 
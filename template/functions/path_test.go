@@ -0,0 +1,31 @@
+package functions
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_resolvePathOfContext_relativeToTemplateFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "kubor-path")
+	require.NoError(t, err)
+	//noinspection GoUnhandledErrorResult
+	defer os.RemoveAll(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sub", "data.txt"), []byte("data"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "part.tpl"), []byte("part"), 0644))
+	// The directory of the template file has no trailing separator; the path
+	// must not be appended to it directly (<root>/subdata.txt).
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "subdata.txt"), []byte("wrong"), 0644))
+	file := filepath.Join(root, "sub", "template.yml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{{ readFile "data.txt" }} {{ include "../part.tpl" . }}`), 0644))
+
+	tmpl, err := DefaultTemplateFactory().NewFromFile(file)
+	require.NoError(t, err)
+	actual, err := tmpl.ExecuteToString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "data part", actual)
+}
@@ -0,0 +1,191 @@
+package functions
+
+import (
+	"github.com/echocat/kubor/template/semver"
+	"sort"
+)
+
+var FuncSemver = Function{
+	Description: "Parses <version> as semantic version. Minor and patch could be omitted and are 0 in this case.",
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "Parsed version with the fields .Major, .Minor, .Patch, .Prerelease and .Metadata.",
+	},
+}.MustWithFunc(semver.Parse)
+
+var FuncSemverCompare = Function{
+	Description: "Checks if <version> matches <constraint> like '>=1.2 <2'. Constraints could be combined using , or spaces (and) and || (or)." +
+		" Supported operators are =, !=, >, >=, <, <=, ~ (same minor) and ^ (same major)." +
+		" A prerelease is lower than its release: 1.2.0-rc.1 does not match '>=1.2' but does match '>=1.2.0-0'.",
+	Parameters: Parameters{{
+		Name: "constraint",
+	}, {
+		Name: "version",
+	}},
+}.MustWithFunc(func(constraint string, plainVersion string) (bool, error) {
+	version, err := semver.Parse(plainVersion)
+	if err != nil {
+		return false, err
+	}
+	return semver.MatchesConstraint(constraint, version)
+})
+
+var FuncSemverOrder = Function{
+	Description: "Compares <left> with <right> respecting the order of prereleases defined by semver.org.",
+	Parameters: Parameters{{
+		Name: "left",
+	}, {
+		Name: "right",
+	}},
+	Returns: Return{
+		Description: "-1, 0 or 1 if <left> is lower, equal or greater than <right>.",
+	},
+}.MustWithFunc(func(left, right string) (int, error) {
+	l, err := semver.Parse(left)
+	if err != nil {
+		return 0, err
+	}
+	r, err := semver.Parse(right)
+	if err != nil {
+		return 0, err
+	}
+	return l.Compare(r), nil
+})
+
+var FuncSemverSort = Function{
+	Parameters: Parameters{{
+		Name: "versions",
+	}},
+	Returns: Return{
+		Description: "<versions> sorted ascending by their semantic version.",
+	},
+}.MustWithFunc(func(versions []interface{}) ([]string, error) {
+	parsed := make([]semver.Version, len(versions))
+	result := make([]string, len(versions))
+	for i, version := range versions {
+		v, err := semver.Parse(scalarString(version))
+		if err != nil {
+			return nil, err
+		}
+		parsed[i], result[i] = v, scalarString(version)
+	}
+	sort.Stable(semversByOrder{parsed, result})
+	return result, nil
+})
+
+var FuncSemverIsPrerelease = Function{
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "<true> if <version> is a prerelease like 1.2.3-rc.1.",
+	},
+}.MustWithFunc(func(plainVersion string) (bool, error) {
+	version, err := semver.Parse(plainVersion)
+	return version.IsPrerelease(), err
+})
+
+var FuncSemverRelease = Function{
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "<version> without its prerelease and metadata.",
+	},
+}.MustWithFunc(semverFunc(semver.Version.Release))
+
+var FuncSemverWithPrerelease = Function{
+	Parameters: Parameters{{
+		Name: "prerelease",
+	}, {
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "<version> with <prerelease> and without metadata.",
+	},
+}.MustWithFunc(func(prerelease string, plainVersion string) (string, error) {
+	version, err := semver.Parse(plainVersion)
+	if err != nil {
+		return "", err
+	}
+	version = version.Release()
+	version.Prerelease = prerelease
+	// Parse again to ensure that <prerelease> only contains legal characters.
+	if _, err := semver.Parse(version.String()); err != nil {
+		return "", err
+	}
+	return version.String(), nil
+})
+
+var FuncSemverBumpMajor = Function{
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "Next major version of <version>. A prerelease like 2.0.0-rc.1 is bumped to 2.0.0.",
+	},
+}.MustWithFunc(semverFunc(semver.Version.BumpMajor))
+
+var FuncSemverBumpMinor = Function{
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "Next minor version of <version>. A prerelease like 1.3.0-rc.1 is bumped to 1.3.0.",
+	},
+}.MustWithFunc(semverFunc(semver.Version.BumpMinor))
+
+var FuncSemverBumpPatch = Function{
+	Parameters: Parameters{{
+		Name: "version",
+	}},
+	Returns: Return{
+		Description: "Next patch version of <version>. A prerelease like 1.2.3-rc.1 is bumped to 1.2.3.",
+	},
+}.MustWithFunc(semverFunc(semver.Version.BumpPatch))
+
+var FuncsSemver = Functions{
+	"semver":               FuncSemver,
+	"semverCompare":        FuncSemverCompare,
+	"semverOrder":          FuncSemverOrder,
+	"semverSort":           FuncSemverSort,
+	"semverIsPrerelease":   FuncSemverIsPrerelease,
+	"semverRelease":        FuncSemverRelease,
+	"semverWithPrerelease": FuncSemverWithPrerelease,
+	"semverBumpMajor":      FuncSemverBumpMajor,
+	"semverBumpMinor":      FuncSemverBumpMinor,
+	"semverBumpPatch":      FuncSemverBumpPatch,
+}
+var CategorySemver = Category{
+	Functions: FuncsSemver,
+}
+
+func semverFunc(f func(semver.Version) semver.Version) func(string) (string, error) {
+	return func(plainVersion string) (string, error) {
+		version, err := semver.Parse(plainVersion)
+		if err != nil {
+			return "", err
+		}
+		return f(version).String(), nil
+	}
+}
+
+type semversByOrder struct {
+	parsed []semver.Version
+	plain  []string
+}
+
+func (instance semversByOrder) Len() int {
+	return len(instance.parsed)
+}
+
+func (instance semversByOrder) Less(i, j int) bool {
+	return instance.parsed[i].Compare(instance.parsed[j]) < 0
+}
+
+func (instance semversByOrder) Swap(i, j int) {
+	instance.parsed[i], instance.parsed[j] = instance.parsed[j], instance.parsed[i]
+	instance.plain[i], instance.plain[j] = instance.plain[j], instance.plain[i]
+}
@@ -0,0 +1,23 @@
+package functions
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_FuncsSemver(t *testing.T) {
+	data := map[string]interface{}{"release": "v1.4.2-rc.2+build.7"}
+	assert.Equal(t, "1 4 2 rc.2 build.7", mustExecuteTemplate(t, `{{ with semver .release }}{{ .Major }} {{ .Minor }} {{ .Patch }} {{ .Prerelease }} {{ .Metadata }}{{ end }}`, data))
+	assert.Equal(t, "false true", mustExecuteTemplate(t, `{{ semverCompare ">=1.4.2 <2" .release }} {{ semverCompare ">=1.4.2-0 <2" .release }}`, data))
+	assert.Equal(t, "true", mustExecuteTemplate(t, `{{ semverIsPrerelease .release }}`, data))
+	assert.Equal(t, "1.4.2 1.4.2 1.5.0 2.0.0", mustExecuteTemplate(t, `{{ semverRelease .release }} {{ semverBumpPatch .release }} {{ semverBumpMinor .release }} {{ semverBumpMajor .release }}`, data))
+	assert.Equal(t, "1.4.3 2.0.0", mustExecuteTemplate(t, `{{ semverBumpPatch "1.4.2" }} {{ semverBumpMajor "2.0.0-beta.1" }}`, nil))
+	assert.Equal(t, "1.4.2-beta.1", mustExecuteTemplate(t, `{{ semverWithPrerelease "beta.1" .release }}`, data))
+	assert.Equal(t, "-1", mustExecuteTemplate(t, `{{ semverOrder "1.0.0-rc.2" "1.0.0-rc.10" }}`, nil))
+	assert.Equal(t, "[1.0.0-alpha 1.0.0-alpha.1 1.0.0-beta.2 1.0.0-beta.11 1.0.0 1.2]", mustExecuteTemplate(t, `{{ semverSort (slice "1.2" "1.0.0" "1.0.0-beta.11" "1.0.0-alpha.1" "1.0.0-beta.2" "1.0.0-alpha") }}`, nil))
+
+	_, err := executeTemplate(t, `{{ semverBumpMinor "latest" }}`, nil)
+	assert.Error(t, err)
+	_, err = executeTemplate(t, `{{ semverWithPrerelease "rc_1" "1.0.0" }}`, nil)
+	assert.Error(t, err)
+}
@@ -95,11 +95,164 @@ var FuncDecodeJsonFromFile = Function{
 	}
 })
 
+var FuncToYaml = Function{
+	Description: "Encodes the given <value> as YAML.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "YAML document without trailing line break.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodeYaml(value)
+})
+
+var FuncToYamlIndent = Function{
+	Description: "Encodes the given <value> as YAML and indents every line by <indent> spaces. This is useful to embed it into block scalars like '|'.",
+	Parameters: Parameters{{
+		Name: "indent",
+	}, {
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Indented YAML document without trailing line break.",
+	},
+}.MustWithFunc(func(indent int, value interface{}) (string, error) {
+	return indentEncoded(indent, value, encodeYaml)
+})
+
+var FuncToJson = Function{
+	Description: "Encodes the given <value> as compact JSON.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "JSON document in one line.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodeJson(value)
+})
+
+var FuncToPrettyJson = Function{
+	Description: "Encodes the given <value> as JSON which is indented by two spaces per level.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "JSON document without trailing line break.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodePrettyJson(value)
+})
+
+var FuncToPrettyJsonIndent = Function{
+	Description: "Encodes the given <value> as pretty JSON (see toPrettyJson) and indents every line by <indent> spaces. This is useful to embed it into block scalars like '|'.",
+	Parameters: Parameters{{
+		Name: "indent",
+	}, {
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Indented JSON document without trailing line break.",
+	},
+}.MustWithFunc(func(indent int, value interface{}) (string, error) {
+	return indentEncoded(indent, value, encodePrettyJson)
+})
+
+var FuncToToml = Function{
+	Description: "Encodes the given <value> as TOML. <value> has to be a map; null values are omitted because TOML does not support them.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "TOML document without trailing line break.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodeToml(value)
+})
+
+var FuncToTomlIndent = Function{
+	Description: "Encodes the given <value> as TOML (see toToml) and indents every line by <indent> spaces. This is useful to embed it into block scalars like '|'.",
+	Parameters: Parameters{{
+		Name: "indent",
+	}, {
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Indented TOML document without trailing line break.",
+	},
+}.MustWithFunc(func(indent int, value interface{}) (string, error) {
+	return indentEncoded(indent, value, encodeToml)
+})
+
+var FuncToProperties = Function{
+	Description: "Encodes the given <value> as Java properties. <value> has to be a map; nested keys are joined by '.' and list elements are addressed as key[<index>].",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Properties sorted by key without trailing line break.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodeProperties(value)
+})
+
+var FuncToPropertiesIndent = Function{
+	Description: "Encodes the given <value> as Java properties (see toProperties) and indents every line by <indent> spaces. This is useful to embed it into block scalars like '|'.",
+	Parameters: Parameters{{
+		Name: "indent",
+	}, {
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Indented properties without trailing line break.",
+	},
+}.MustWithFunc(func(indent int, value interface{}) (string, error) {
+	return indentEncoded(indent, value, encodeProperties)
+})
+
+var FuncToEnv = Function{
+	Description: "Encodes the given <value> as .env file. <value> has to be a map; nested keys and list indexes are joined by '_' and converted to upper case.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Variables sorted by name without trailing line break.",
+	},
+}.MustWithFunc(func(value interface{}) (string, error) {
+	return encodeEnv(value)
+})
+
+var FuncToEnvIndent = Function{
+	Description: "Encodes the given <value> as .env file (see toEnv) and indents every line by <indent> spaces. This is useful to embed it into block scalars like '|'.",
+	Parameters: Parameters{{
+		Name: "indent",
+	}, {
+		Name: "value",
+	}},
+	Returns: Return{
+		Description: "Indented variables without trailing line break.",
+	},
+}.MustWithFunc(func(indent int, value interface{}) (string, error) {
+	return indentEncoded(indent, value, encodeEnv)
+})
+
 var FuncsSerialization = Functions{
 	"decodeYaml":         FuncDecodeYaml,
 	"decodeJson":         FuncDecodeJson,
 	"decodeYamlFromFile": FuncDecodeYamlFromFile,
 	"decodeJsonFromFile": FuncDecodeJsonFromFile,
+	"toYaml":             FuncToYaml,
+	"toYamlIndent":       FuncToYamlIndent,
+	"toJson":             FuncToJson,
+	"toPrettyJson":       FuncToPrettyJson,
+	"toPrettyJsonIndent": FuncToPrettyJsonIndent,
+	"toToml":             FuncToToml,
+	"toTomlIndent":       FuncToTomlIndent,
+	"toProperties":       FuncToProperties,
+	"toPropertiesIndent": FuncToPropertiesIndent,
+	"toEnv":              FuncToEnv,
+	"toEnvIndent":        FuncToEnvIndent,
 }
 var CategorySerialization = Category{
 	Functions: FuncsSerialization,
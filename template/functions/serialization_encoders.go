@@ -0,0 +1,347 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	tomlBareKeyRegexp  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	envPlainRegexp     = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,-]*$`)
+	envIllegalRegexp   = regexp.MustCompile(`[^A-Z0-9_]`)
+	propertiesReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\f", `\f`)
+)
+
+func encodeYaml(value interface{}) (string, error) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode value as YAML: %w", err)
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+func encodeJson(value interface{}) (string, error) {
+	b, err := json.Marshal(normalizeForEncoding(value))
+	if err != nil {
+		return "", fmt.Errorf("cannot encode value as JSON: %w", err)
+	}
+	return string(b), nil
+}
+
+func encodePrettyJson(value interface{}) (string, error) {
+	b, err := json.MarshalIndent(normalizeForEncoding(value), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("cannot encode value as JSON: %w", err)
+	}
+	return string(b), nil
+}
+
+func indentEncoded(indent int, value interface{}, encoder func(interface{}) (string, error)) (string, error) {
+	encoded, err := encoder(value)
+	if err != nil {
+		return "", err
+	}
+	pad := strings.Repeat(" ", indent)
+	lines := strings.Split(encoded, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// normalizeForEncoding converts all maps (like the ones decoded by YAML which
+// have keys of type interface{}) into map[string]interface{}.
+func normalizeForEncoding(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			result[fmt.Sprint(key.Interface())] = normalizeForEncoding(v.MapIndex(key).Interface())
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return value
+		}
+		result := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = normalizeForEncoding(v.Index(i).Interface())
+		}
+		return result
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return normalizeForEncoding(v.Elem().Interface())
+	default:
+		return value
+	}
+}
+
+// plainOf converts the given value into its JSON representation using only
+// maps, slices, strings, json.Number, bool and nil.
+func plainOf(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(normalizeForEncoding(value))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var result interface{}
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func plainMapOf(value interface{}, format string) (map[string]interface{}, error) {
+	plain, err := plainOf(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode value as %s: %w", format, err)
+	}
+	if plain == nil {
+		return map[string]interface{}{}, nil
+	}
+	result, ok := plain.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot encode value as %s: expected a map but got %v", format, reflect.TypeOf(value))
+	}
+	return result, nil
+}
+
+func sortedKeysOf(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func encodeToml(value interface{}) (string, error) {
+	m, err := plainMapOf(value, "TOML")
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	writeTomlTable(buf, nil, m)
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func writeTomlTable(buf *bytes.Buffer, path []string, m map[string]interface{}) {
+	var tables, tableArrays []string
+	for _, key := range sortedKeysOf(m) {
+		switch v := m[key].(type) {
+		case nil:
+		case map[string]interface{}:
+			tables = append(tables, key)
+		case []interface{}:
+			if isTomlTableArray(v) {
+				tableArrays = append(tableArrays, key)
+			} else {
+				buf.WriteString(tomlKey(key) + " = " + tomlValue(v) + "\n")
+			}
+		default:
+			buf.WriteString(tomlKey(key) + " = " + tomlValue(v) + "\n")
+		}
+	}
+	for _, key := range tables {
+		childPath := append(append([]string{}, path...), key)
+		buf.WriteString("\n[" + tomlPath(childPath) + "]\n")
+		writeTomlTable(buf, childPath, m[key].(map[string]interface{}))
+	}
+	for _, key := range tableArrays {
+		childPath := append(append([]string{}, path...), key)
+		for _, element := range m[key].([]interface{}) {
+			buf.WriteString("\n[[" + tomlPath(childPath) + "]]\n")
+			writeTomlTable(buf, childPath, element.(map[string]interface{}))
+		}
+	}
+}
+
+func isTomlTableArray(v []interface{}) bool {
+	if len(v) == 0 {
+		return false
+	}
+	for _, element := range v {
+		if _, ok := element.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+func tomlKey(key string) string {
+	if tomlBareKeyRegexp.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return tomlString(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			if element != nil {
+				elements = append(elements, tomlValue(element))
+			}
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case map[string]interface{}:
+		entries := make([]string, 0, len(v))
+		for _, key := range sortedKeysOf(v) {
+			if v[key] != nil {
+				entries = append(entries, tomlKey(key)+" = "+tomlValue(v[key]))
+			}
+		}
+		return "{ " + strings.Join(entries, ", ") + " }"
+	default:
+		return tomlString(fmt.Sprint(v))
+	}
+}
+
+func tomlString(s string) string {
+	buf := new(strings.Builder)
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				_, _ = fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// flatten calls consumer for every scalar of the given value with the path of
+// keys (and list indexes) which lead to it.
+func flatten(path []string, value interface{}, consumer func(path []string, index []bool, value interface{}), index []bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeysOf(v) {
+			flatten(append(append([]string{}, path...), key), v[key], consumer, append(append([]bool{}, index...), false))
+		}
+	case []interface{}:
+		for i, element := range v {
+			flatten(append(append([]string{}, path...), strconv.Itoa(i)), element, consumer, append(append([]bool{}, index...), true))
+		}
+	default:
+		consumer(path, index, v)
+	}
+}
+
+func scalarString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func encodeProperties(value interface{}) (string, error) {
+	m, err := plainMapOf(value, "properties")
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	flatten(nil, m, func(path []string, index []bool, value interface{}) {
+		key := new(strings.Builder)
+		for i, element := range path {
+			if index[i] {
+				key.WriteString("[" + element + "]")
+			} else {
+				if i > 0 {
+					key.WriteByte('.')
+				}
+				key.WriteString(element)
+			}
+		}
+		lines = append(lines, propertiesEscape(key.String(), true)+"="+propertiesEscape(scalarString(value), false))
+	}, nil)
+	return strings.Join(lines, "\n"), nil
+}
+
+func propertiesEscape(s string, key bool) string {
+	s = propertiesReplacer.Replace(s)
+	buf := new(strings.Builder)
+	for i, r := range s {
+		switch {
+		case key && (r == ' ' || r == '=' || r == ':' || r == '#' || r == '!'):
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case !key && i == 0 && (r == ' ' || r == '#' || r == '!'):
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r > 0x7e:
+			for _, c := range utf16.Encode([]rune{r}) {
+				_, _ = fmt.Fprintf(buf, `\u%04x`, c)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func encodeEnv(value interface{}) (string, error) {
+	m, err := plainMapOf(value, "env")
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	flatten(nil, m, func(path []string, _ []bool, value interface{}) {
+		name := envIllegalRegexp.ReplaceAllString(strings.ToUpper(strings.Join(path, "_")), "_")
+		lines = append(lines, name+"="+envValue(scalarString(value)))
+	}, nil)
+	return strings.Join(lines, "\n"), nil
+}
+
+func envValue(s string) string {
+	if envPlainRegexp.MatchString(s) {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,87 @@
+package functions
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_FuncToYaml(t *testing.T) {
+	v := map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 1}}
+	assert.Equal(t, "a:\n  b: 1", mustExecuteTemplate(t, `{{ toYaml . }}`, v))
+	assert.Equal(t, "    a:\n      b: 1", mustExecuteTemplate(t, `{{ . | toYamlIndent 4 }}`, v))
+}
+
+func Test_FuncToJson(t *testing.T) {
+	v := map[interface{}]interface{}{"a": []interface{}{1, "b"}}
+	assert.Equal(t, `{"a":[1,"b"]}`, mustExecuteTemplate(t, `{{ toJson . }}`, v))
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    \"b\"\n  ]\n}", mustExecuteTemplate(t, `{{ toPrettyJson . }}`, v))
+	assert.Equal(t, "  {\n    \"a\": 1\n  }", mustExecuteTemplate(t, `{{ toPrettyJsonIndent 2 (map "a" 1) }}`, nil))
+}
+
+func Test_FuncToToml(t *testing.T) {
+	_, err := executeTemplate(t, `{{ toToml "foo" }}`, nil)
+	assert.Error(t, err)
+}
+
+func Test_FuncToProperties(t *testing.T) {
+	assert.Equal(t, `  a=multi\nline \u00e4`, mustExecuteTemplate(t, `{{ toPropertiesIndent 2 (map "a" "multi\nline ä") }}`, nil))
+}
+
+func Test_FuncToEnv(t *testing.T) {
+	assert.Equal(t, `A="x \"\$y\""`, mustExecuteTemplate(t, `{{ toEnv (map "a" "x \"$y\"") }}`, nil))
+}
+
+func Test_FuncsSerialization_nested(t *testing.T) {
+	v := map[interface{}]interface{}{
+		"name":    "demo",
+		"port":    8080,
+		"enabled": true,
+		"server": map[interface{}]interface{}{
+			"hosts": []interface{}{"a", "b"},
+			"tls":   map[interface{}]interface{}{"key file": "/etc/tls.key"},
+		},
+		"users": []interface{}{
+			map[interface{}]interface{}{"name": "x", "admin": true},
+		},
+		"empty": nil,
+	}
+	cases := []struct {
+		template string
+		expected string
+	}{
+		{`{{ toToml . }}`, `enabled = true
+name = "demo"
+port = 8080
+
+[server]
+hosts = ["a", "b"]
+
+[server.tls]
+"key file" = "/etc/tls.key"
+
+[[users]]
+admin = true
+name = "x"`},
+		{`{{ toProperties . }}`, `empty=
+enabled=true
+name=demo
+port=8080
+server.hosts[0]=a
+server.hosts[1]=b
+server.tls.key\ file=/etc/tls.key
+users[0].admin=true
+users[0].name=x`},
+		{`{{ toEnv . }}`, `EMPTY=
+ENABLED=true
+NAME=demo
+PORT=8080
+SERVER_HOSTS_0=a
+SERVER_HOSTS_1=b
+SERVER_TLS_KEY_FILE=/etc/tls.key
+USERS_0_ADMIN=true
+USERS_0_NAME=x`},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, mustExecuteTemplate(t, c.template, v), c.template)
+	}
+}
@@ -32,7 +32,7 @@ var FuncInclude = Function{
 	Description: "Takes the given <file> and renders the contained template using <data> as regular Golang template.",
 	Parameters: Parameters{{
 		Name:        "file",
-		Description: "The actual template file which should be rendered using the provided <data>.",
+		Description: "The actual template file which should be rendered using the provided <data>. Files of libraries could be referenced using @<library>/<file>.",
 	}, {
 		Name:        "data",
 		Description: "The data that could be accessed while the rendering the content of the template of the provided <file>.",
@@ -0,0 +1,75 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echocat/kubor/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func Test_FuncInclude_error(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubor-templating")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	main := filepath.Join(dir, "main.yaml")
+	partial := filepath.Join(dir, "partial.tpl")
+	require.NoError(t, ioutil.WriteFile(main, []byte("a: 1\nb: {{ include \"partial.tpl\" . }}\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(partial, []byte("x: 1\n\ty: {{ .foo.bar }}\nz: 3\n"), 0644))
+
+	tmpl, err := DefaultTemplateFactory().NewFromFile(main)
+	require.NoError(t, err)
+	err = tmpl.Execute(map[string]interface{}{}, ioutil.Discard)
+
+	var actual *template.Error
+	require.True(t, errors.As(err, &actual), "%v", err)
+	assert.Equal(t, template.Location{Source: partial, Line: 2, Column: 12}, actual.Location)
+	assert.Equal(t, ".foo.bar", actual.Expression)
+	assert.Equal(t, `map has no entry for key "foo"`, actual.Message)
+	assert.Equal(t, []template.Location{{Source: main, Line: 2, Column: 7}}, actual.IncludedFrom)
+	assert.Equal(t, "1 | x: 1\n2 | \ty: {{ .foo.bar }}\n  | \t          ^\n3 | z: 3", actual.Snippet)
+	assert.Equal(t, partial+`:2:12: at <.foo.bar>: map has no entry for key "foo" (included from `+main+`:2:7)`, actual.Error())
+}
+
+func Test_template_parseError(t *testing.T) {
+	_, err := DefaultTemplateFactory().New("broken.yaml", "a: 1\nb: {{ unknownFunction }}\n")
+
+	var actual *template.Error
+	require.True(t, errors.As(err, &actual), "%v", err)
+	assert.Equal(t, template.Location{Source: "broken.yaml", Line: 2}, actual.Location)
+	assert.Equal(t, `function "unknownFunction" not defined`, actual.Message)
+	assert.Equal(t, "1 | a: 1\n2 | b: {{ unknownFunction }}", actual.Snippet)
+}
+
+func Test_template_executionContextData(t *testing.T) {
+	factory := &template.FactoryImpl{FunctionProvider: Categories{"test": Category{Functions: Functions{
+		"data": Function{}.MustWithFunc(func(context template.ExecutionContext) (interface{}, error) {
+			return context.GetData(), nil
+		}),
+	}}}}
+	tmpl, err := factory.New("test", `{{ data }}`)
+	require.NoError(t, err)
+
+	results := make([]string, 20)
+	errs := make([]error, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = tmpl.ExecuteToString(i)
+		}(i)
+	}
+	wg.Wait()
+	for i, actual := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, fmt.Sprint(i), actual)
+	}
+}
@@ -0,0 +1,378 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// startTime is returned by now to ensure that all templates of one run
+	// (for example for rollout annotations) see the same time.
+	startTime = time.Now()
+
+	timeLayouts = map[string]string{
+		"ANSIC":       time.ANSIC,
+		"UnixDate":    time.UnixDate,
+		"RubyDate":    time.RubyDate,
+		"RFC822":      time.RFC822,
+		"RFC822Z":     time.RFC822Z,
+		"RFC850":      time.RFC850,
+		"RFC1123":     time.RFC1123,
+		"RFC1123Z":    time.RFC1123Z,
+		"RFC3339":     time.RFC3339,
+		"RFC3339Nano": time.RFC3339Nano,
+		"Kitchen":     time.Kitchen,
+		"Stamp":       time.Stamp,
+		"DateTime":    "2006-01-02 15:04:05",
+		"DateOnly":    "2006-01-02",
+		"TimeOnly":    "15:04:05",
+	}
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+const timeLayoutDescription = " <layout> is either a Go layout like '2006-01-02T15:04:05Z07:00' or one of the names" +
+	" ANSIC, UnixDate, RubyDate, RFC822, RFC822Z, RFC850, RFC1123, RFC1123Z, RFC3339, RFC3339Nano, Kitchen, Stamp, DateTime, DateOnly or TimeOnly."
+
+const timeDescription = " <time> could be a time, a RFC 3339 string or Unix seconds."
+
+const durationDescription = " <duration> could be a duration, a string like '1h30m' or seconds."
+
+var FuncNow = Function{
+	Description: "Returns the current time. It is the same for all calls of one run, so all resources get the same timestamp.",
+}.MustWithFunc(func() time.Time {
+	return startTime
+})
+
+var FuncFormatTime = Function{
+	Description: "Formats <time> using <layout>." + timeLayoutDescription + timeDescription,
+	Parameters: Parameters{{
+		Name: "layout",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(layout string, value interface{}) (string, error) {
+	t, err := timeOf(value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(timeLayoutOf(layout)), nil
+})
+
+var FuncStrftime = Function{
+	Description: "Formats <time> using the strftime <format> like '%Y-%m-%d %H:%M:%S'." +
+		" Supported are %a %A %b %B %c %d %e %F %h %H %I %j %k %l %L %m %M %n %N %p %s %S %t %T %u %w %y %Y %z %Z and %%." + timeDescription,
+	Parameters: Parameters{{
+		Name: "format",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(format string, value interface{}) (string, error) {
+	t, err := timeOf(value)
+	if err != nil {
+		return "", err
+	}
+	return strftime(format, t)
+})
+
+var FuncParseTime = Function{
+	Description: "Parses <value> using <layout>. If <value> does not contain a time zone it is interpreted as UTC." + timeLayoutDescription,
+	Parameters: Parameters{{
+		Name: "layout",
+	}, {
+		Name: "value",
+	}},
+}.MustWithFunc(func(layout string, value string) (time.Time, error) {
+	return time.Parse(timeLayoutOf(layout), value)
+})
+
+var FuncParseDuration = Function{
+	Description: "Parses <value> like '1h30m', '-15s' or '300ms'.",
+	Parameters: Parameters{{
+		Name: "value",
+	}},
+}.MustWithFunc(time.ParseDuration)
+
+var FuncDurationSeconds = Function{
+	Description: "Returns the seconds of <duration>." + durationDescription,
+	Parameters: Parameters{{
+		Name: "duration",
+	}},
+}.MustWithFunc(func(value interface{}) (float64, error) {
+	d, err := durationOf(value)
+	return d.Seconds(), err
+})
+
+var FuncUnixTime = Function{
+	Description: "Returns the Unix timestamp (seconds since 1970-01-01 UTC) of <time>." + timeDescription,
+	Parameters: Parameters{{
+		Name: "time",
+	}},
+}.MustWithFunc(func(value interface{}) (int64, error) {
+	t, err := timeOf(value)
+	return t.Unix(), err
+})
+
+var FuncFromUnixTime = Function{
+	Description: "Returns the time (in UTC) of the Unix timestamp <seconds>.",
+	Parameters: Parameters{{
+		Name: "seconds",
+	}},
+}.MustWithFunc(func(seconds interface{}) (time.Time, error) {
+	f, err := secondsOf(seconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return unixSecondsToTime(f), nil
+})
+
+var FuncAddDuration = Function{
+	Description: "Adds <duration> (which could be negative) to <time>." + durationDescription + timeDescription,
+	Parameters: Parameters{{
+		Name: "duration",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(duration interface{}, value interface{}) (time.Time, error) {
+	d, err := durationOf(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := timeOf(value)
+	return t.Add(d), err
+})
+
+var FuncAddDate = Function{
+	Description: "Adds <years>, <months> and <days> (which could be negative) to <time>." + timeDescription,
+	Parameters: Parameters{{
+		Name: "years",
+	}, {
+		Name: "months",
+	}, {
+		Name: "days",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(years, months, days int, value interface{}) (time.Time, error) {
+	t, err := timeOf(value)
+	return t.AddDate(years, months, days), err
+})
+
+var FuncTruncateTime = Function{
+	Description: "Rounds <time> down to a multiple of <duration> (since the zero time) like '1h' for the full hour." + durationDescription + timeDescription,
+	Parameters: Parameters{{
+		Name: "duration",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(duration interface{}, value interface{}) (time.Time, error) {
+	d, err := durationOf(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := timeOf(value)
+	return t.Truncate(d), err
+})
+
+var FuncUtc = Function{
+	Description: "Returns <time> in UTC." + timeDescription,
+	Parameters: Parameters{{
+		Name: "time",
+	}},
+}.MustWithFunc(func(value interface{}) (time.Time, error) {
+	t, err := timeOf(value)
+	return t.UTC(), err
+})
+
+var FuncInTimeZone = Function{
+	Description: "Returns <time> in the time zone <location> like 'Europe/Berlin'." + timeDescription,
+	Parameters: Parameters{{
+		Name: "location",
+	}, {
+		Name: "time",
+	}},
+}.MustWithFunc(func(location string, value interface{}) (time.Time, error) {
+	l, err := time.LoadLocation(location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := timeOf(value)
+	return t.In(l), err
+})
+
+var FuncsTime = Functions{
+	"now":             FuncNow,
+	"formatTime":      FuncFormatTime,
+	"strftime":        FuncStrftime,
+	"parseTime":       FuncParseTime,
+	"parseDuration":   FuncParseDuration,
+	"durationSeconds": FuncDurationSeconds,
+	"unixTime":        FuncUnixTime,
+	"fromUnixTime":    FuncFromUnixTime,
+	"addDuration":     FuncAddDuration,
+	"addDate":         FuncAddDate,
+	"truncateTime":    FuncTruncateTime,
+	"utc":             FuncUtc,
+	"inTimeZone":      FuncInTimeZone,
+}
+var CategoryTime = Category{
+	Functions: FuncsTime,
+}
+
+func timeLayoutOf(layout string) string {
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+func timeOf(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("illegal time: %s", v)
+		}
+		return t, nil
+	default:
+		if f, err := secondsOf(v); err == nil {
+			return unixSecondsToTime(f), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("illegal time: %v", value)
+}
+
+func durationOf(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("illegal duration: %s", v)
+		}
+		return d, nil
+	default:
+		if f, err := secondsOf(v); err == nil {
+			return time.Duration(f * float64(time.Second)), nil
+		}
+	}
+	return 0, fmt.Errorf("illegal duration: %v", value)
+}
+
+func secondsOf(value interface{}) (float64, error) {
+	if n, ok := value.(json.Number); ok {
+		return n.Float64()
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() != durationType {
+			return float64(v.Int()), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("illegal seconds: %v", value)
+}
+
+func unixSecondsToTime(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second))).UTC()
+}
+
+func strftime(format string, t time.Time) (string, error) {
+	buf := new(strings.Builder)
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return "", fmt.Errorf("illegal strftime format %q: trailing %%", format)
+		}
+		switch format[i] {
+		case 'a':
+			buf.WriteString(t.Format("Mon"))
+		case 'A':
+			buf.WriteString(t.Format("Monday"))
+		case 'b', 'h':
+			buf.WriteString(t.Format("Jan"))
+		case 'B':
+			buf.WriteString(t.Format("January"))
+		case 'c':
+			buf.WriteString(t.Format(time.ANSIC))
+		case 'd':
+			buf.WriteString(t.Format("02"))
+		case 'e':
+			buf.WriteString(t.Format("_2"))
+		case 'F':
+			buf.WriteString(t.Format("2006-01-02"))
+		case 'H':
+			buf.WriteString(t.Format("15"))
+		case 'I':
+			buf.WriteString(t.Format("03"))
+		case 'j':
+			_, _ = fmt.Fprintf(buf, "%03d", t.YearDay())
+		case 'k':
+			_, _ = fmt.Fprintf(buf, "%2d", t.Hour())
+		case 'l':
+			buf.WriteString(fmt.Sprintf("%2s", t.Format("3")))
+		case 'L':
+			_, _ = fmt.Fprintf(buf, "%03d", t.Nanosecond()/int(time.Millisecond))
+		case 'm':
+			buf.WriteString(t.Format("01"))
+		case 'M':
+			buf.WriteString(t.Format("04"))
+		case 'n':
+			buf.WriteByte('\n')
+		case 'N':
+			_, _ = fmt.Fprintf(buf, "%09d", t.Nanosecond())
+		case 'p':
+			buf.WriteString(t.Format("PM"))
+		case 's':
+			buf.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			buf.WriteString(t.Format("05"))
+		case 't':
+			buf.WriteByte('\t')
+		case 'T':
+			buf.WriteString(t.Format("15:04:05"))
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			buf.WriteString(strconv.Itoa(wd))
+		case 'w':
+			buf.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'y':
+			buf.WriteString(t.Format("06"))
+		case 'Y':
+			buf.WriteString(strconv.Itoa(t.Year()))
+		case 'z':
+			buf.WriteString(t.Format("-0700"))
+		case 'Z':
+			buf.WriteString(t.Format("MST"))
+		case '%':
+			buf.WriteByte('%')
+		default:
+			return "", fmt.Errorf("illegal strftime format %q: unsupported directive %%%c", format, format[i])
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_FuncsTime(t *testing.T) {
+	data := map[string]interface{}{"at": "2020-07-04T13:45:30.25Z"}
+	assert.Equal(t, "2020-07-04 13:45:30", mustExecuteTemplate(t, `{{ .at | formatTime "DateTime" }}`, data))
+	assert.Equal(t, "04.07.20 13:45", mustExecuteTemplate(t, `{{ .at | formatTime "02.01.06 15:04" }}`, data))
+	assert.Equal(t, "Sat, 04 Jul 2020 01:45:30 PM 186 250 % +0000", mustExecuteTemplate(t, `{{ .at | strftime "%a, %d %b %Y %I:%M:%S %p %j %L %% %z" }}`, data))
+	assert.Equal(t, "1593870330", mustExecuteTemplate(t, `{{ .at | unixTime }}`, data))
+	assert.Equal(t, "2020-07-04T13:45:30Z", mustExecuteTemplate(t, `{{ fromUnixTime 1593870330 | formatTime "RFC3339" }}`, nil))
+	assert.Equal(t, "2020-07-04T15:15:30Z", mustExecuteTemplate(t, `{{ .at | addDuration "1h30m" | formatTime "RFC3339" }}`, data))
+	assert.Equal(t, "2021-06-03", mustExecuteTemplate(t, `{{ .at | addDate 1 -1 -1 | formatTime "DateOnly" }}`, data))
+	assert.Equal(t, "2020-07-04T13:00:00Z", mustExecuteTemplate(t, `{{ .at | truncateTime "1h" | formatTime "RFC3339" }}`, data))
+	assert.Equal(t, "2020-07-04T15:45:30+02:00", mustExecuteTemplate(t, `{{ .at | inTimeZone "Europe/Berlin" | formatTime "RFC3339" }}`, data))
+	assert.Equal(t, "2020-01-02T03:04:00Z", mustExecuteTemplate(t, `{{ parseTime "2006-01-02 15:04" "2020-01-02 03:04" | formatTime "RFC3339" }}`, nil))
+	assert.Equal(t, "5400 1h30m0s", mustExecuteTemplate(t, `{{ durationSeconds "1h30m" }} {{ parseDuration "90m" }}`, nil))
+	assert.Equal(t, mustExecuteTemplate(t, `{{ now | unixTime }}`, nil), mustExecuteTemplate(t, `{{ now | unixTime }}`, nil))
+
+	_, err := executeTemplate(t, `{{ "yesterday" | formatTime "RFC3339" }}`, nil)
+	assert.Error(t, err)
+	_, err = executeTemplate(t, `{{ now | strftime "%Q" }}`, nil)
+	assert.Error(t, err)
+}
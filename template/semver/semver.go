@@ -0,0 +1,257 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pattern           = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+	constraintPattern = regexp.MustCompile(`^(>=|<=|!=|=>|=<|>|<|=|~|\^)?\s*(\S+)$`)
+	operatorPattern   = regexp.MustCompile(`^(>=|<=|!=|=>|=<|>|<|=|~|\^)$`)
+)
+
+// Version is a semantic version like 1.2.3-rc.1+build.5. Minor and patch
+// could be omitted while parsing and are 0 in this case.
+type Version struct {
+	Major      int64  `yaml:"major" json:"major"`
+	Minor      int64  `yaml:"minor" json:"minor"`
+	Patch      int64  `yaml:"patch" json:"patch"`
+	Prerelease string `yaml:"prerelease,omitempty" json:"prerelease,omitempty"`
+	Metadata   string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+func Parse(plain string) (Version, error) {
+	result, _, err := parse(plain)
+	return result, err
+}
+
+// parse parses the given version and returns additionally how many of major,
+// minor and patch are given; a version with a prerelease is always complete.
+func parse(plain string) (Version, int, error) {
+	match := pattern.FindStringSubmatch(strings.TrimSpace(plain))
+	if match == nil {
+		return Version{}, 0, fmt.Errorf("illegal version: %s", plain)
+	}
+	precision := 1
+	var result Version
+	result.Major, _ = strconv.ParseInt(match[1], 10, 64)
+	if match[2] != "" {
+		result.Minor, _ = strconv.ParseInt(match[2], 10, 64)
+		precision = 2
+	}
+	if match[3] != "" {
+		result.Patch, _ = strconv.ParseInt(match[3], 10, 64)
+		precision = 3
+	}
+	result.Prerelease = match[4]
+	result.Metadata = match[5]
+	if result.Prerelease != "" {
+		precision = 3
+	}
+	return result, precision, nil
+}
+
+func (instance Version) String() string {
+	result := fmt.Sprintf("%d.%d.%d", instance.Major, instance.Minor, instance.Patch)
+	if instance.Prerelease != "" {
+		result += "-" + instance.Prerelease
+	}
+	if instance.Metadata != "" {
+		result += "+" + instance.Metadata
+	}
+	return result
+}
+
+func (instance Version) IsPrerelease() bool {
+	return instance.Prerelease != ""
+}
+
+// Release returns this version without prerelease and metadata.
+func (instance Version) Release() Version {
+	return Version{Major: instance.Major, Minor: instance.Minor, Patch: instance.Patch}
+}
+
+// BumpMajor returns the next major version. Like npm does a prerelease of a
+// major version (like 2.0.0-rc.1) is bumped to its release (2.0.0).
+func (instance Version) BumpMajor() Version {
+	if instance.IsPrerelease() && instance.Minor == 0 && instance.Patch == 0 {
+		return instance.Release()
+	}
+	return Version{Major: instance.Major + 1}
+}
+
+// BumpMinor returns the next minor version. Like npm does a prerelease of a
+// minor version (like 1.3.0-rc.1) is bumped to its release (1.3.0).
+func (instance Version) BumpMinor() Version {
+	if instance.IsPrerelease() && instance.Patch == 0 {
+		return instance.Release()
+	}
+	return Version{Major: instance.Major, Minor: instance.Minor + 1}
+}
+
+// BumpPatch returns the next patch version. A prerelease (like 1.2.3-rc.1) is
+// bumped to its release (1.2.3).
+func (instance Version) BumpPatch() Version {
+	if instance.IsPrerelease() {
+		return instance.Release()
+	}
+	return Version{Major: instance.Major, Minor: instance.Minor, Patch: instance.Patch + 1}
+}
+
+// Compare returns -1, 0 or 1 if this version is lower, equal or greater than
+// the other one. Prereleases are ordered as defined by semver.org; metadata is
+// ignored.
+func (instance Version) Compare(other Version) int {
+	for _, diff := range []int64{instance.Major - other.Major, instance.Minor - other.Minor, instance.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		} else if diff > 0 {
+			return 1
+		}
+	}
+	switch {
+	case instance.Prerelease == other.Prerelease:
+		return 0
+	case instance.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(instance.Prerelease, other.Prerelease)
+}
+
+func comparePrerelease(left, right string) int {
+	ls, rs := strings.Split(left, "."), strings.Split(right, ".")
+	for i := 0; i < len(ls) && i < len(rs); i++ {
+		ln, lErr := strconv.ParseUint(ls[i], 10, 64)
+		rn, rErr := strconv.ParseUint(rs[i], 10, 64)
+		switch {
+		case lErr == nil && rErr == nil:
+			if ln < rn {
+				return -1
+			} else if ln > rn {
+				return 1
+			}
+		case lErr == nil:
+			return -1
+		case rErr == nil:
+			return 1
+		case ls[i] < rs[i]:
+			return -1
+		case ls[i] > rs[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(ls) < len(rs):
+		return -1
+	case len(ls) > len(rs):
+		return 1
+	}
+	return 0
+}
+
+// MatchesConstraint checks if the given version matches the given
+// constraint. Constraints could be combined using , or spaces (and) and ||
+// (or); an operator could be separated from its version by spaces (like
+// ">= 1.2, < 2"). Supported operators are =, !=, >, >=, <, <=, ~ (same minor)
+// and ^ (same major; like npm ^0.2.3 means same minor and ^0.0.3 same patch).
+// Partial versions (like 1.2) match every version they are a prefix of: 1.2
+// and =1.2 match 1.2.x, >1.2 matches 1.3.0 and above and <=1.2 matches
+// everything below 1.3.0. Like npm a prerelease only matches if one of the
+// combined constraints names a prerelease of the same version, so <2 does not
+// match 2.0.0-rc.1 but >=2.0.0-rc.1 matches 2.0.0-rc.2.
+func MatchesConstraint(constraint string, version Version) (bool, error) {
+	for _, alternative := range strings.Split(constraint, "||") {
+		matches, prereleaseAllowed := true, !version.IsPrerelease()
+		for _, part := range constraintParts(alternative) {
+			ok, expected, err := matchesConstraintPart(part, version)
+			if err != nil {
+				return false, err
+			} else if !ok {
+				matches = false
+			}
+			if expected.IsPrerelease() && expected.Release() == version.Release() {
+				prereleaseAllowed = true
+			}
+		}
+		if matches && prereleaseAllowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// constraintParts splits the given constraint into its parts and joins every
+// operator with the version which follows it.
+func constraintParts(constraint string) []string {
+	var result []string
+	operator := ""
+	for _, field := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if operatorPattern.MatchString(field) {
+			operator += field
+			continue
+		}
+		result = append(result, operator+field)
+		operator = ""
+	}
+	if operator != "" {
+		result = append(result, operator)
+	}
+	return result
+}
+
+// matchesConstraintPart checks the given version against a single constraint
+// and returns additionally the version named by the constraint.
+func matchesConstraintPart(constraint string, version Version) (bool, Version, error) {
+	match := constraintPattern.FindStringSubmatch(constraint)
+	if match == nil {
+		return false, Version{}, fmt.Errorf("illegal constraint: %s", constraint)
+	}
+	expected, precision, err := parse(match[2])
+	if err != nil {
+		return false, Version{}, fmt.Errorf("illegal constraint: %s: %w", constraint, err)
+	}
+	c := version.Compare(expected)
+	// A partial version is a range up to (excluding) the next version of its
+	// precision, like 1.2 is [1.2.0, 1.3.0-0).
+	inRange, beyond := c == 0, c > 0
+	if precision < 3 {
+		next := Version{Major: expected.Major + 1, Prerelease: "0"}
+		if precision == 2 {
+			next = Version{Major: expected.Major, Minor: expected.Minor + 1, Prerelease: "0"}
+		}
+		inRange, beyond = c >= 0 && version.Compare(next) < 0, version.Compare(next) >= 0
+	}
+	switch match[1] {
+	case "", "=":
+		return inRange, expected, nil
+	case "!=":
+		return !inRange, expected, nil
+	case ">":
+		return beyond, expected, nil
+	case ">=", "=>":
+		return c >= 0, expected, nil
+	case "<":
+		return c < 0, expected, nil
+	case "<=", "=<":
+		return !beyond, expected, nil
+	case "~":
+		if precision == 1 {
+			return inRange, expected, nil
+		}
+		return c >= 0 && version.Major == expected.Major && version.Minor == expected.Minor, expected, nil
+	default: // ^
+		sameMajor := version.Major == expected.Major
+		switch {
+		case expected.Major > 0 || precision == 1:
+			return c >= 0 && sameMajor, expected, nil
+		case expected.Minor > 0 || precision == 2:
+			return c >= 0 && sameMajor && version.Minor == expected.Minor, expected, nil
+		}
+		return c >= 0 && sameMajor && version.Minor == expected.Minor && version.Patch == expected.Patch, expected, nil
+	}
+}
@@ -0,0 +1,105 @@
+package semver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	actual, err := Parse("v1.4.2-rc.2+build.7")
+	require.NoError(t, err)
+	assert.Equal(t, Version{Major: 1, Minor: 4, Patch: 2, Prerelease: "rc.2", Metadata: "build.7"}, actual)
+	assert.Equal(t, "1.4.2-rc.2+build.7", actual.String())
+
+	actual, err = Parse("1.2")
+	require.NoError(t, err)
+	assert.Equal(t, Version{Major: 1, Minor: 2}, actual)
+
+	_, err = Parse("latest")
+	assert.EqualError(t, err, "illegal version: latest")
+}
+
+func Test_Version_Compare(t *testing.T) {
+	cases := []struct {
+		left     string
+		right    string
+		expected int
+	}{
+		{"1.0.0", "1.0.0+build.1", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+	}
+	for _, c := range cases {
+		left, err := Parse(c.left)
+		require.NoError(t, err)
+		right, err := Parse(c.right)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, left.Compare(right), "%s <> %s", c.left, c.right)
+	}
+}
+
+func Test_MatchesConstraint(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"!=1.2.3", "1.2.3", false},
+		{">=1.2, <2", "1.9.0", true},
+		{">= 1.2, < 2", "1.9.0", true},
+		{">= 1.2 < 2", "2.0.0", false},
+		{"> 1.2.3", "1.2.3", false},
+		{"1.2", "1.2.7", true},
+		{"=1.2", "1.2.0", true},
+		{"=1.2", "1.3.0", false},
+		{"1", "1.9.9", true},
+		{"1", "2.0.0", false},
+		{"!=1.2", "1.2.5", false},
+		{"!=1.2", "1.3.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"<1.2", "1.1.9", true},
+		{"<1.2", "1.2.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.2.3", "0.9.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0", "0.9.0", true},
+		{"<2", "2.0.0-rc.1", false},
+		{"<2", "1.9.0-rc.1", false},
+		{">=1.0.0", "1.1.0-rc.1", false},
+		{">=1.0.0-rc.1", "1.0.0-rc.2", true},
+		{">=1.0.0-rc.1", "1.0.0", true},
+		{">=1.0.0-rc.1", "1.0.1-rc.1", false},
+		{">=1.0.0-rc.1 <2", "1.0.0-rc.2", true},
+		{"<1.0 || >=1.0.0-rc.1", "1.0.0-rc.1", true},
+		{"<1.0 || >=1.19", "1.19.0", true},
+		{"<1.0 || >= 1.19", "1.18.0", false},
+	}
+	for _, c := range cases {
+		version, err := Parse(c.version)
+		require.NoError(t, err)
+		actual, err := MatchesConstraint(c.constraint, version)
+		require.NoError(t, err, "%s %s", c.constraint, c.version)
+		assert.Equal(t, c.expected, actual, "%s %s", c.constraint, c.version)
+	}
+
+	_, err := MatchesConstraint(">= 1.2, <", Version{Major: 1})
+	assert.EqualError(t, err, "illegal constraint: <: illegal version: <")
+}
@@ -3,6 +3,7 @@ package template
 import (
 	"bytes"
 	"io"
+	"sync"
 	nt "text/template"
 )
 
@@ -19,10 +20,8 @@ type Template interface {
 	GetSourceFile() *string
 }
 
-func newDelegate(name string, code string, functionProvider FunctionProvider) (*nt.Template, error) {
-	if functions, err := functionProvider.GetFunctions(); err != nil {
-		return nil, err
-	} else if funcMap, err := functions.CreateDummyFuncMap(); err != nil {
+func newDelegate(name string, code string, functions Functions) (*nt.Template, error) {
+	if funcMap, err := functions.CreateDummyFuncMap(); err != nil {
 		return nil, err
 	} else {
 		return nt.New(name).
@@ -37,37 +36,83 @@ type Impl struct {
 	sourceFile *string
 	sourceCode string
 
-	factory          Factory
-	functionProvider FunctionProvider
-	delegate         *nt.Template
+	factory Factory
+	// functions are resolved by the factory only once.
+	functions Functions
+	// delegate is never executed itself (only its clones); so it could be
+	// shared between multiple instances (see Cache).
+	delegate *nt.Template
+
+	// executables contains the idle clones of delegate with the functions
+	// bound to their own execution context. They are reused, so the functions
+	// are only bound once per execution context and not per execution.
+	executablesMutex sync.Mutex
+	executables      []*executable
+}
+
+// executable is a clone of a delegate with the functions bound to context.
+// The Data of context is set for every execution.
+type executable struct {
+	template *nt.Template
+	context  *ExecutionContextImpl
 }
 
 func (instance *Impl) WithSourceFile(sourceFile string) (Template, error) {
 	return &Impl{
-		sourceName:       instance.sourceName,
-		sourceFile:       &sourceFile,
-		sourceCode:       instance.sourceCode,
-		functionProvider: instance.functionProvider,
-		factory:          instance.factory,
-		delegate:         instance.delegate,
+		sourceName: instance.sourceName,
+		sourceFile: &sourceFile,
+		sourceCode: instance.sourceCode,
+		functions:  instance.functions,
+		factory:    instance.factory,
+		delegate:   instance.delegate,
 	}, nil
 }
 
+// acquireExecutable returns an idle executable or creates a new one if all
+// are in use (by parallel or nested executions).
+func (instance *Impl) acquireExecutable() (*executable, error) {
+	instance.executablesMutex.Lock()
+	if n := len(instance.executables); n > 0 {
+		result := instance.executables[n-1]
+		instance.executables = instance.executables[:n-1]
+		instance.executablesMutex.Unlock()
+		return result, nil
+	}
+	instance.executablesMutex.Unlock()
+
+	context := instance.newExecutionContext()
+	if clone, err := instance.delegate.Clone(); err != nil {
+		return nil, err
+	} else if funcMap, err := instance.functions.CreateFuncMap(context); err != nil {
+		return nil, err
+	} else {
+		return &executable{
+			template: clone.
+				Option("missingkey=error").
+				Funcs(funcMap),
+			context: context,
+		}, nil
+	}
+}
+
+func (instance *Impl) releaseExecutable(e *executable) {
+	e.context.Data = nil
+	instance.executablesMutex.Lock()
+	instance.executables = append(instance.executables, e)
+	instance.executablesMutex.Unlock()
+}
+
 func (instance *Impl) Execute(data interface{}, target io.Writer) error {
-	if context, err := instance.newExecutionContext(data); err != nil {
-		return err
-	} else if clone, err := instance.delegate.Clone(); err != nil {
+	e, err := instance.acquireExecutable()
+	if err != nil {
 		return err
-	} else if functions, err := instance.functionProvider.GetFunctions(); err != nil {
-		return err
-	} else if funcMap, err := functions.CreateFuncMap(context); err != nil {
-		return err
-	} else {
-		return clone.
-			Option("missingkey=error").
-			Funcs(funcMap).
-			Execute(target, data)
 	}
+	defer instance.releaseExecutable(e)
+	e.context.Data = data
+	if err := e.template.Execute(target, data); err != nil {
+		return NewError(instance.GetSource(), instance.sourceName, instance.sourceCode, err)
+	}
+	return nil
 }
 
 func (instance *Impl) ExecuteToString(data interface{}) (string, error) {
@@ -107,10 +152,9 @@ func (instance *Impl) GetSource() string {
 	return instance.sourceName
 }
 
-func (instance *Impl) newExecutionContext(data interface{}) (ExecutionContext, error) {
+func (instance *Impl) newExecutionContext() *ExecutionContextImpl {
 	return &ExecutionContextImpl{
 		Template: instance,
 		Factory:  instance.factory,
-		Data:     data,
-	}, nil
+	}
 }